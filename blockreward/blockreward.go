@@ -0,0 +1,97 @@
+// Package blockreward models the full reward paid to smeshers in each layer: the block subsidy from the emission
+// schedule plus the transaction fees paid in the layer.
+package blockreward
+
+import (
+	"log"
+	"math/bits"
+
+	"github.com/spacemeshos/economics/distribution"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Reward is the reward paid in a layer, denominated in smidge.
+type Reward struct {
+	Layer   types.Layer
+	Subsidy uint64
+	Fees    uint64
+}
+
+// Total returns the sum of subsidy and fees.
+func (r Reward) Total() uint64 {
+	return total(r.Subsidy, r.Fees)
+}
+
+func total(subsidy, fees uint64) uint64 {
+	sum, carry := bits.Add64(subsidy, fees, 0)
+	if carry != 0 {
+		log.Fatal("integer overflow")
+	}
+	return sum
+}
+
+// Share is one recipient's part of a layer reward, denominated in smidge.
+type Share struct {
+	ID      string
+	Subsidy uint64
+	Fees    uint64
+}
+
+// Total returns the sum of subsidy and fees.
+func (s Share) Total() uint64 {
+	return total(s.Subsidy, s.Fees)
+}
+
+// Distribute divides the reward among the recipients in proportion to their weights. Subsidy and fees are divided
+// separately, so the subsidy shares sum to exactly the subsidy and the fee shares to exactly the fees. It returns one
+// share per recipient, in input order.
+func (r Reward) Distribute(recipients []distribution.Recipient) ([]Share, error) {
+	subsidy, err := distribution.Distribute(r.Subsidy, recipients)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := distribution.Distribute(r.Fees, recipients)
+	if err != nil {
+		return nil, err
+	}
+	shares := make([]Share, len(recipients))
+	for i, recipient := range recipients {
+		shares[i] = Share{ID: recipient.ID, Subsidy: subsidy[i], Fees: fees[i]}
+	}
+	return shares, nil
+}
+
+// Model combines a subsidy schedule with a fee model.
+type Model struct {
+	// EffectiveGenesis is the first layer, post-genesis, in which subsidy is issued
+	EffectiveGenesis types.Layer
+
+	// Subsidy is the subsidy schedule; if nil, the mainnet schedule is used
+	Subsidy simulation.SubsidySchedule
+
+	// Fees is the fee model; if nil, no fees are paid
+	Fees fees.Model
+}
+
+// RewardAtLayer returns the reward paid in the given layer, post-genesis.
+func (m Model) RewardAtLayer(layerID types.Layer) Reward {
+	reward := Reward{Layer: layerID}
+	if effectiveLayer, ok := layerID.Effective(m.EffectiveGenesis); ok {
+		schedule := m.Subsidy
+		if schedule == nil {
+			schedule = rewards.Mainnet
+		}
+		// the difference between the total issued as of this layer and as of the previous one, as in the simulation
+		reward.Subsidy = schedule.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+		if effectiveLayer > 0 {
+			reward.Subsidy -= schedule.TotalAccumulatedSubsidyAtLayer(effectiveLayer - 1)
+		}
+	}
+	if m.Fees != nil {
+		reward.Fees = m.Fees.FeesAtLayer(layerID)
+	}
+	return reward
+}
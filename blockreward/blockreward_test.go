@@ -0,0 +1,58 @@
+package blockreward
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/distribution"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RewardAtLayer(t *testing.T) {
+	model := Model{EffectiveGenesis: 2 * constants.OneEpoch, Fees: fees.Constant(1000)}
+
+	// fees may be paid before effective genesis, subsidy may not
+	reward := model.RewardAtLayer(2*constants.OneEpoch - 1)
+	assert.Equal(t, Reward{Layer: 2*constants.OneEpoch - 1, Fees: 1000}, reward)
+	assert.Equal(t, uint64(1000), reward.Total())
+
+	reward = model.RewardAtLayer(2*constants.OneEpoch + 10)
+	assert.Equal(t, rewards.TotalSubsidyAtLayer(10), reward.Subsidy)
+	assert.Equal(t, uint64(1000), reward.Fees)
+	assert.Equal(t, reward.Subsidy+1000, reward.Total())
+
+	// no fee model means no fees
+	schedule := rewards.NewSchedule(constants.TotalSubsidy, constants.TotalSubsidy/2)
+	model = Model{Subsidy: schedule}
+	reward = model.RewardAtLayer(0)
+	assert.Equal(t, schedule.TotalSubsidyAtLayer(0), reward.Subsidy)
+	assert.NotEqual(t, rewards.TotalSubsidyAtLayer(0), reward.Subsidy)
+	assert.Zero(t, reward.Fees)
+
+	// any schedule of the simulation will do
+	model = Model{Subsidy: linear{}}
+	assert.Equal(t, uint64(5), model.RewardAtLayer(0).Subsidy)
+	assert.Equal(t, uint64(5), model.RewardAtLayer(7).Subsidy)
+}
+
+// linear issues five smidge per layer.
+type linear struct{}
+
+func (linear) TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	return 5 * (uint64(layersAfterEffectiveGenesis) + 1)
+}
+
+func Test_Distribute(t *testing.T) {
+	reward := Reward{Subsidy: 1000, Fees: 11}
+	shares, err := reward.Distribute([]distribution.Recipient{{ID: "a", Weight: 1}, {ID: "b", Weight: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, []Share{{ID: "a", Subsidy: 333, Fees: 4}, {ID: "b", Subsidy: 667, Fees: 7}}, shares)
+	assert.Equal(t, uint64(337), shares[0].Total())
+
+	_, err = reward.Distribute(nil)
+	assert.ErrorIs(t, err, distribution.ErrNoWeight)
+}
@@ -0,0 +1,87 @@
+// Package burn models fees that are burned, i.e., removed from the supply, rather than paid to smeshers.
+package burn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Model returns the amount burned in a layer, in smidge, given the fees paid in the layer.
+type Model interface {
+	BurnAtLayer(layerID types.Layer, fees uint64) uint64
+}
+
+// Fraction burns a fixed fraction of the fees paid in each layer, rounded down to the nearest smidge.
+type Fraction float64
+
+func (f Fraction) BurnAtLayer(_ types.Layer, fees uint64) uint64 {
+	switch {
+	case f <= 0:
+		return 0
+	case f >= 1:
+		return fees
+	default:
+		return uint64(float64(fees) * float64(f))
+	}
+}
+
+// Series burns the amount given by a series of per-layer volumes, regardless of the fees paid.
+type Series struct {
+	Volume fees.Model
+}
+
+func (s Series) BurnAtLayer(layerID types.Layer, _ uint64) uint64 {
+	return s.Volume.FeesAtLayer(layerID)
+}
+
+// ReadCSV reads a series of per-layer burn volumes from CSV with a header row and the columns layer and burn (in
+// smidge). Layers without a record burn nothing.
+func ReadCSV(r io.Reader) (Series, error) {
+	records, err := keyed.ReadCSV(r, keyed.Layer, keyed.Column[uint64]{Name: "burn", Parse: keyed.Uint},
+		func(layerID types.Layer, amount uint64) fees.Record { return fees.Record{Layer: layerID, Fees: amount} })
+	if err != nil {
+		return Series{}, err
+	}
+	table, err := fees.NewTable(records)
+	if err != nil {
+		return Series{}, err
+	}
+	return Series{Volume: table}, nil
+}
+
+// Parse returns the burn model described by a scenario, one of:
+//
+//	fraction:<fraction of fees burned>
+//	series:<fee scenario giving the amount burned per layer, e.g., constant:1000>
+//	file:<path to CSV of per-layer burn volumes>
+func Parse(scenario string) (Model, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	switch kind {
+	case "fraction":
+		f, err := strconv.ParseFloat(args, 64)
+		if err != nil || !(f >= 0 && f <= 1) {
+			return nil, fmt.Errorf("invalid fraction %q: must be between 0 and 1", args)
+		}
+		return Fraction(f), nil
+	case "series":
+		volume, err := fees.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		return Series{Volume: volume}, nil
+	case "file":
+		volume, err := keyed.Open(args, ReadCSV)
+		if err != nil {
+			return nil, err
+		}
+		return volume, nil
+	default:
+		return nil, fmt.Errorf("unknown burn scenario %q", scenario)
+	}
+}
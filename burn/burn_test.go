@@ -0,0 +1,60 @@
+package burn
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/fees"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Fraction(t *testing.T) {
+	assert.Equal(t, uint64(0), Fraction(0).BurnAtLayer(1, 1000))
+	assert.Equal(t, uint64(333), Fraction(1.0/3).BurnAtLayer(1, 1000), "rounded down")
+	assert.Equal(t, uint64(1000), Fraction(1).BurnAtLayer(1, 1000))
+	assert.Equal(t, uint64(0), Fraction(0.5).BurnAtLayer(1, 0), "nothing to burn without fees")
+}
+
+func Test_Series(t *testing.T) {
+	s := Series{Volume: fees.Constant(500)}
+	assert.Equal(t, uint64(500), s.BurnAtLayer(1, 0), "independent of fees")
+	assert.Equal(t, uint64(500), s.BurnAtLayer(1, 10_000))
+}
+
+func Test_ReadCSV(t *testing.T) {
+	series, err := ReadCSV(strings.NewReader("layer,burn\n10,100\n20,200\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), series.BurnAtLayer(5, 0))
+	assert.Equal(t, uint64(100), series.BurnAtLayer(10, 0))
+	assert.Equal(t, uint64(0), series.BurnAtLayer(15, 0))
+	assert.Equal(t, uint64(200), series.BurnAtLayer(20, 0))
+
+	_, err = ReadCSV(strings.NewReader("layer,fees\n10,100\n"))
+	assert.ErrorContains(t, err, "expected header")
+	_, err = ReadCSV(strings.NewReader("layer,burn\n10,-1\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func Test_Parse(t *testing.T) {
+	m, err := Parse("fraction:0.5")
+	require.NoError(t, err)
+	assert.Equal(t, Fraction(0.5), m)
+
+	m, err = Parse("series:constant:1000")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1000), m.BurnAtLayer(1, 0))
+
+	path := filepath.Join(t.TempDir(), "burn.csv")
+	require.NoError(t, os.WriteFile(path, []byte("layer,burn\n1,42\n"), 0o600))
+	m, err = Parse("file:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42), m.BurnAtLayer(1, 0))
+
+	for _, scenario := range []string{"", "fraction:1.5", "fraction:x", "series:bogus", "file:", "bogus:1"} {
+		_, err := Parse(scenario)
+		assert.Error(t, err, scenario)
+	}
+}
@@ -0,0 +1,286 @@
+// Package chart draws simple time series charts, as line charts or stacked area charts with a date axis, and renders
+// them as SVG or PNG without any external tools.
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Default size of a chart, in pixels.
+const (
+	DefaultWidth  = 800
+	DefaultHeight = 400
+)
+
+// margins around the plot area, in pixels, leaving room for the title, legend and axis labels
+const (
+	marginLeft   = 70
+	marginRight  = 20
+	marginTop    = 60
+	marginBottom = 40
+)
+
+// Series is a named sequence of values, one per date of the chart.
+type Series struct {
+	Name string
+
+	// Color is an RGB color as #rrggbb
+	Color string
+
+	Values []float64
+}
+
+type Chart struct {
+	Title string
+
+	// Dates are the x values, in increasing order
+	Dates []time.Time
+
+	Series []Series
+
+	// Stacked draws each series as an area stacked on top of the previous ones, rather than as a line
+	Stacked bool
+
+	// YFormat formats the labels of the y axis; if nil, values are shown in compact form
+	YFormat func(v float64) string
+
+	// Width and Height are the size of the chart in pixels; if zero, the default size is used
+	Width, Height int
+}
+
+// Compact formats a value with a suffix for thousands (k), millions (M) or billions (B).
+func Compact(v float64) string {
+	abs := math.Abs(v)
+	switch {
+	case abs >= 1e9:
+		return trim(v/1e9) + "B"
+	case abs >= 1e6:
+		return trim(v/1e6) + "M"
+	case abs >= 1e3:
+		return trim(v/1e3) + "k"
+	default:
+		return trim(v)
+	}
+}
+
+// Percent formats a value as a percentage.
+func Percent(v float64) string {
+	return trim(v) + "%"
+}
+
+// trim formats a value with up to two decimals, without trailing zeros.
+func trim(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// point is a position on the canvas, in pixels from the top left corner.
+type point struct {
+	x, y float64
+}
+
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is a drawing surface, implemented for each output format.
+type canvas interface {
+	polygon(points []point, fill string)
+	polyline(points []point, stroke string, width float64)
+	text(p point, s string, a anchor, color string)
+}
+
+const (
+	axisColor = "#333333"
+	gridColor = "#dddddd"
+	textColor = "#333333"
+)
+
+func (c Chart) size() (int, int) {
+	w, h := c.Width, c.Height
+	if w == 0 {
+		w = DefaultWidth
+	}
+	if h == 0 {
+		h = DefaultHeight
+	}
+	return w, h
+}
+
+func (c Chart) validate() error {
+	if len(c.Dates) < 2 {
+		return errors.New("chart needs at least two dates")
+	}
+	if !c.Dates[len(c.Dates)-1].After(c.Dates[0]) {
+		return errors.New("chart dates must increase")
+	}
+	for _, s := range c.Series {
+		if len(s.Values) != len(c.Dates) {
+			return fmt.Errorf("series %q has %d values for %d dates", s.Name, len(s.Values), len(c.Dates))
+		}
+		if _, err := parseColor(s.Color); err != nil {
+			return fmt.Errorf("series %q: %w", s.Name, err)
+		}
+	}
+	return nil
+}
+
+// stacks returns the cumulative values of the series if stacked, or the values themselves otherwise.
+func (c Chart) stacks() [][]float64 {
+	stacks := make([][]float64, len(c.Series))
+	for i, s := range c.Series {
+		stacks[i] = append([]float64(nil), s.Values...)
+		if c.Stacked && i > 0 {
+			for j := range stacks[i] {
+				stacks[i][j] += stacks[i-1][j]
+			}
+		}
+	}
+	return stacks
+}
+
+// niceStep returns a step of 1, 2 or 5 times a power of ten that divides the range into at most about n intervals.
+func niceStep(span float64, n int) float64 {
+	if span <= 0 {
+		return 1
+	}
+	raw := span / float64(n)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*magnitude >= raw {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// dateTick is a labeled position on the date axis.
+type dateTick struct {
+	date  time.Time
+	label string
+}
+
+// dateTicks returns ticks at the start of years, or of months for spans shorter than two years, with at most about
+// ten ticks.
+func dateTicks(first, last time.Time) []dateTick {
+	var ticks []dateTick
+	if last.Sub(first) < 2*365*24*time.Hour {
+		months := int(last.Sub(first).Hours()/(24*30)) + 1
+		step := 1
+		for _, s := range []int{1, 2, 3, 6, 12} {
+			step = s
+			if months/s <= 10 {
+				break
+			}
+		}
+		t := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, first.Location())
+		for ; !t.After(last); t = t.AddDate(0, step, 0) {
+			if !t.Before(first) {
+				ticks = append(ticks, dateTick{t, t.Format("Jan 2006")})
+			}
+		}
+		return ticks
+	}
+	step := int(niceStep(float64(last.Year()-first.Year()), 10))
+	if step < 1 {
+		step = 1
+	}
+	start := (first.Year()/step + 1) * step
+	if first.Year()%step == 0 && first.Month() == time.January && first.Day() == 1 {
+		start = first.Year()
+	}
+	for year := start; year <= last.Year(); year += step {
+		t := time.Date(year, time.January, 1, 0, 0, 0, 0, first.Location())
+		ticks = append(ticks, dateTick{t, fmt.Sprint(year)})
+	}
+	return ticks
+}
+
+// draw lays out the chart on a canvas of the given size.
+func (c Chart) draw(cv canvas, width, height int) {
+	left, right := float64(marginLeft), float64(width-marginRight)
+	top, bottom := float64(marginTop), float64(height-marginBottom)
+	first, last := c.Dates[0], c.Dates[len(c.Dates)-1]
+	stacks := c.stacks()
+
+	// y axis from zero (or the minimum, if negative) to a round number above the maximum
+	var lo, hi float64
+	for _, values := range stacks {
+		for _, v := range values {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	step := niceStep(hi-lo, 5)
+	lo, hi = math.Floor(lo/step)*step, math.Ceil(hi/step)*step
+	if hi == lo {
+		hi = lo + step
+	}
+	x := func(t time.Time) float64 {
+		return left + (right-left)*float64(t.Sub(first))/float64(last.Sub(first))
+	}
+	y := func(v float64) float64 {
+		return bottom - (bottom-top)*(v-lo)/(hi-lo)
+	}
+	yFormat := c.YFormat
+	if yFormat == nil {
+		yFormat = Compact
+	}
+
+	// title and legend
+	cv.text(point{float64(width) / 2, 20}, c.Title, anchorMiddle, textColor)
+	legendX := left
+	for _, s := range c.Series {
+		cv.polygon([]point{{legendX, 34}, {legendX + 12, 34}, {legendX + 12, 46}, {legendX, 46}}, s.Color)
+		cv.text(point{legendX + 16, 44}, s.Name, anchorStart, textColor)
+		legendX += 16 + 7*float64(len(s.Name)) + 20
+	}
+
+	// grid and y axis labels
+	for k := 0; k <= int(math.Round((hi-lo)/step)); k++ {
+		v := lo + float64(k)*step
+		cv.polyline([]point{{left, y(v)}, {right, y(v)}}, gridColor, 1)
+		cv.text(point{left - 6, y(v) + 4}, yFormat(v), anchorEnd, textColor)
+	}
+
+	// date axis
+	for _, tick := range dateTicks(first, last) {
+		cv.polyline([]point{{x(tick.date), bottom}, {x(tick.date), bottom + 5}}, axisColor, 1)
+		cv.text(point{x(tick.date), bottom + 18}, tick.label, anchorMiddle, textColor)
+	}
+
+	// series: areas are drawn between each stack and the one below it
+	for i, s := range c.Series {
+		line := make([]point, len(c.Dates))
+		for j, d := range c.Dates {
+			line[j] = point{x(d), y(stacks[i][j])}
+		}
+		if !c.Stacked {
+			cv.polyline(line, s.Color, 2)
+			continue
+		}
+		area := append([]point(nil), line...)
+		for j := len(c.Dates) - 1; j >= 0; j-- {
+			var below float64
+			if i > 0 {
+				below = stacks[i-1][j]
+			}
+			area = append(area, point{x(c.Dates[j]), y(below)})
+		}
+		cv.polygon(area, s.Color)
+	}
+
+	// axes on top
+	cv.polyline([]point{{left, top}, {left, bottom}, {right, bottom}}, axisColor, 1)
+}
@@ -0,0 +1,113 @@
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	assert.Equal(t, "0", Compact(0))
+	assert.Equal(t, "950", Compact(950))
+	assert.Equal(t, "1.5k", Compact(1500))
+	assert.Equal(t, "2.4B", Compact(2.4e9))
+	assert.Equal(t, "-500M", Compact(-5e8))
+	assert.Equal(t, "12.35%", Percent(12.345))
+	assert.Equal(t, "100%", Percent(100))
+}
+
+func Test_NiceStep(t *testing.T) {
+	assert.Equal(t, 1.0, niceStep(0, 5))
+	assert.Equal(t, 500e6, niceStep(2.4e9, 5))
+	assert.Equal(t, 20.0, niceStep(100, 5))
+	assert.InDelta(t, 0.2, niceStep(0.9, 5), 1e-12)
+}
+
+func Test_DateTicks(t *testing.T) {
+	first := time.Date(2023, time.July, 14, 0, 0, 0, 0, time.UTC)
+
+	ticks := dateTicks(first, first.AddDate(10, 0, 0))
+	require.Len(t, ticks, 10)
+	assert.Equal(t, "2024", ticks[0].label)
+	assert.Equal(t, "2033", ticks[9].label)
+
+	ticks = dateTicks(first, first.AddDate(40, 0, 0))
+	require.Len(t, ticks, 8)
+	assert.Equal(t, "2025", ticks[0].label)
+
+	// months for short spans
+	ticks = dateTicks(first, first.AddDate(0, 6, 0))
+	require.Len(t, ticks, 6)
+	assert.Equal(t, "Aug 2023", ticks[0].label)
+	assert.Equal(t, "Jan 2024", ticks[5].label)
+}
+
+func testChart(stacked bool) Chart {
+	first := time.Date(2023, time.July, 14, 0, 0, 0, 0, time.UTC)
+	return Chart{
+		Title: "Supply <SMESH>",
+		Dates: []time.Time{first, first.AddDate(1, 0, 0), first.AddDate(2, 0, 0)},
+		Series: []Series{
+			{Name: "a", Color: "#1f77b4", Values: []float64{1, 2, 3}},
+			{Name: "b", Color: "#ff7f0e", Values: []float64{3, 2, 1}},
+		},
+		Stacked: stacked,
+		Width:   300,
+		Height:  200,
+	}
+}
+
+func Test_SVG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testChart(true).SVG(&buf))
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, "<svg "))
+	assert.Contains(t, svg, `width="300" height="200"`)
+	assert.Contains(t, svg, "Supply &lt;SMESH&gt;")
+	assert.Contains(t, svg, `fill="#ff7f0e"`)
+	assert.Contains(t, svg, ">2024</text>")
+	// one area per series, plus the legend
+	assert.Equal(t, 4, strings.Count(svg, "<polygon"))
+
+	buf.Reset()
+	require.NoError(t, testChart(false).SVG(&buf))
+	assert.Equal(t, 2, strings.Count(buf.String(), "<polygon"))
+	assert.Contains(t, buf.String(), `stroke="#1f77b4" stroke-width="2"`)
+}
+
+func Test_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testChart(true).PNG(&buf))
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 200, img.Bounds().Dy())
+
+	// just above the x axis is the bottom of the stack, the first series
+	r, g, b, _ := img.At(marginLeft+5, 200-marginBottom-5).RGBA()
+	assert.Equal(t, [3]uint32{0x1f1f, 0x7777, 0xb4b4}, [3]uint32{r, g, b})
+}
+
+func Test_Validate(t *testing.T) {
+	c := testChart(false)
+	c.Dates = c.Dates[:1]
+	assert.Error(t, c.SVG(&bytes.Buffer{}))
+
+	c = testChart(false)
+	c.Dates[2] = c.Dates[0]
+	c.Dates[1] = c.Dates[0]
+	assert.Error(t, c.SVG(&bytes.Buffer{}))
+
+	c = testChart(false)
+	c.Series[1].Values = c.Series[1].Values[:2]
+	assert.ErrorContains(t, c.PNG(&bytes.Buffer{}), `series "b"`)
+
+	c = testChart(false)
+	c.Series[0].Color = "blue"
+	assert.ErrorContains(t, c.SVG(&bytes.Buffer{}), "#rrggbb")
+}
@@ -0,0 +1,112 @@
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// parseColor parses a color given as #rrggbb.
+func parseColor(s string) (color.RGBA, error) {
+	if len(s) != 7 || s[0] != '#' {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: expected #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(s[1:], 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q: expected #rrggbb", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// pngCanvas rasterizes shapes without antialiasing, which is plenty for charts of this kind.
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func (c pngCanvas) color(s string) color.RGBA {
+	// colors are validated up front
+	rgba, _ := parseColor(s)
+	return rgba
+}
+
+// polygon fills the polygon using the even-odd rule, sampling each row of pixels at its center.
+func (c pngCanvas) polygon(points []point, fill string) {
+	rgba := c.color(fill)
+	bounds := c.img.Bounds()
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+	var xs []float64
+	first := int(math.Max(math.Floor(minY), float64(bounds.Min.Y)))
+	for row := first; row < bounds.Max.Y && float64(row) <= maxY; row++ {
+		y := float64(row) + 0.5
+		xs = xs[:0]
+		for i, a := range points {
+			b := points[(i+1)%len(points)]
+			if (a.y <= y) != (b.y <= y) {
+				xs = append(xs, a.x+(y-a.y)*(b.x-a.x)/(b.y-a.y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			for col := int(math.Round(xs[i])); col < int(math.Round(xs[i+1])); col++ {
+				c.img.SetRGBA(col, row, rgba)
+			}
+		}
+	}
+}
+
+// polyline draws each segment by stamping a square of the given width at every half pixel along it.
+func (c pngCanvas) polyline(points []point, stroke string, width float64) {
+	rgba := c.color(stroke)
+	half := width / 2
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		steps := int(math.Ceil(2*math.Hypot(b.x-a.x, b.y-a.y))) + 1
+		for s := 0; s <= steps; s++ {
+			f := float64(s) / float64(steps)
+			x, y := a.x+f*(b.x-a.x), a.y+f*(b.y-a.y)
+			for py := int(math.Floor(y - half + 0.5)); py < int(math.Floor(y+half+0.5)); py++ {
+				for px := int(math.Floor(x - half + 0.5)); px < int(math.Floor(x+half+0.5)); px++ {
+					c.img.SetRGBA(px, py, rgba)
+				}
+			}
+		}
+	}
+}
+
+func (c pngCanvas) text(p point, s string, a anchor, col string) {
+	d := font.Drawer{Dst: c.img, Src: image.NewUniform(c.color(col)), Face: basicfont.Face7x13}
+	x := p.x
+	switch a {
+	case anchorMiddle:
+		x -= float64(d.MeasureString(s).Round()) / 2
+	case anchorEnd:
+		x -= float64(d.MeasureString(s).Round())
+	}
+	d.Dot = fixed.P(int(math.Round(x)), int(math.Round(p.y)))
+	d.DrawString(s)
+}
+
+// PNG writes the chart as a PNG image.
+func (c Chart) PNG(w io.Writer) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	width, height := c.size()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	c.draw(pngCanvas{img}, width, height)
+	return png.Encode(w, img)
+}
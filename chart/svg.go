@@ -0,0 +1,55 @@
+package chart
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// svgCanvas writes each shape as an SVG element.
+type svgCanvas struct {
+	w *bufio.Writer
+}
+
+func svgPoints(points []point) string {
+	var b strings.Builder
+	for i, p := range points {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%.1f,%.1f", p.x, p.y)
+	}
+	return b.String()
+}
+
+func (c svgCanvas) polygon(points []point, fill string) {
+	fmt.Fprintf(c.w, "<polygon points=\"%s\" fill=\"%s\"/>\n", svgPoints(points), fill)
+}
+
+func (c svgCanvas) polyline(points []point, stroke string, width float64) {
+	fmt.Fprintf(c.w, "<polyline points=\"%s\" fill=\"none\" stroke=\"%s\" stroke-width=\"%g\"/>\n",
+		svgPoints(points), stroke, width)
+}
+
+func (c svgCanvas) text(p point, s string, a anchor, color string) {
+	anchors := [...]string{anchorStart: "start", anchorMiddle: "middle", anchorEnd: "end"}
+	fmt.Fprintf(c.w, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"%s\" fill=\"%s\">%s</text>\n", p.x, p.y, anchors[a],
+		color, html.EscapeString(s))
+}
+
+// SVG writes the chart as a standalone SVG document.
+func (c Chart) SVG(w io.Writer) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	width, height := c.size()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" "+
+		"font-family=\"sans-serif\" font-size=\"12\">\n", width, height, width, height)
+	fmt.Fprintf(bw, "<rect width=\"%d\" height=\"%d\" fill=\"#ffffff\"/>\n", width, height)
+	c.draw(svgCanvas{bw}, width, height)
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spacemeshos/economics/chart"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+)
+
+// Chart formats
+const (
+	chartSVG = "svg"
+	chartPNG = "png"
+)
+
+// minChartTicks is the number of ticks it takes to chart a run, since a chart spans at least two dates.
+const minChartTicks = 2
+
+// namedChart is a chart and the base name of the file it's written to.
+type namedChart struct {
+	name  string
+	chart chart.Chart
+}
+
+func smesh(amount uint64) float64 {
+	return float64(amount) / constants.OneSmesh
+}
+
+// supplyCharts plots the simulation ticks: the breakdown of total issuance, the decay of the per-layer subsidy, the
+// progress of vesting and issuance as a percentage of final issuance.
+func supplyCharts(ticks []simulation.Snapshot) []namedChart {
+	dates := make([]time.Time, len(ticks))
+	circulating := make([]float64, len(ticks))
+	unvested := make([]float64, len(ticks))
+	unissued := make([]float64, len(ticks))
+	subsidy := make([]float64, len(ticks))
+	vested := make([]float64, len(ticks))
+	issued := make([]float64, len(ticks))
+	for i, s := range ticks {
+		dates[i] = s.Date
+		circulating[i] = smesh(s.CirculatingTotal)
+		unvested[i] = smesh(s.VaultTotal - s.VaultTotalVest)
+		if s.IssuanceTotal < constants.TotalIssuance {
+			unissued[i] = smesh(constants.TotalIssuance - s.IssuanceTotal)
+		}
+		subsidy[i] = smesh(s.SubsidyPerLayer)
+		if s.VaultTotal > 0 {
+			vested[i] = 100 * float64(s.VaultTotalVest) / float64(s.VaultTotal)
+		}
+		issued[i] = 100 * float64(s.IssuanceTotal) / constants.TotalIssuance
+	}
+	return []namedChart{
+		{"supply", chart.Chart{
+			Title: "Supply (SMESH)",
+			Dates: dates,
+			Series: []chart.Series{
+				{Name: "circulating", Color: "#1f77b4", Values: circulating},
+				{Name: "vault (unvested)", Color: "#ff7f0e", Values: unvested},
+				{Name: "unissued", Color: "#c7c7c7", Values: unissued},
+			},
+			Stacked: true,
+		}},
+		{"subsidy", chart.Chart{
+			Title:  "Subsidy per layer (SMESH)",
+			Dates:  dates,
+			Series: []chart.Series{{Name: "subsidyPerLayer", Color: "#2ca02c", Values: subsidy}},
+		}},
+		{"vesting", chart.Chart{
+			Title:   "Vesting progress",
+			Dates:   dates,
+			Series:  []chart.Series{{Name: "vaultPctVest", Color: "#ff7f0e", Values: vested}},
+			YFormat: chart.Percent,
+		}},
+		{"issuance", chart.Chart{
+			Title:   "Percent of final issuance",
+			Dates:   dates,
+			Series:  []chart.Series{{Name: "pctFinalIssuance", Color: "#9467bd", Values: issued}},
+			YFormat: chart.Percent,
+		}},
+	}
+}
+
+// writeCharts writes each chart to a file in the given directory, in the given format, and returns the paths written.
+func writeCharts(dir, format string, charts []namedChart) ([]string, error) {
+	if format != chartSVG && format != chartPNG {
+		return nil, fmt.Errorf("unknown chart format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, c := range charts {
+		path := filepath.Join(dir, c.name+"."+format)
+		f, err := os.Create(path)
+		if err != nil {
+			return paths, err
+		}
+		if format == chartSVG {
+			err = c.chart.SVG(f)
+		} else {
+			err = c.chart.PNG(f)
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, fmt.Errorf("%s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WriteCharts(t *testing.T) {
+	result := simulation.Run(testConfig(2*defaultTickInterval), nil)
+	charts := supplyCharts(result.Ticks)
+	require.Len(t, charts, 4)
+	assert.True(t, charts[0].chart.Stacked)
+
+	dir := t.TempDir()
+	paths, err := writeCharts(dir, chartSVG, charts)
+	require.NoError(t, err)
+	require.Len(t, paths, 4)
+	assert.Equal(t, filepath.Join(dir, "supply.svg"), paths[0])
+	svg, err := os.ReadFile(paths[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(svg), "Supply (SMESH)")
+
+	paths, err = writeCharts(dir, chartPNG, charts[1:2])
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "subsidy.png")}, paths)
+
+	_, err = writeCharts(dir, "gif", charts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/formula"
+	"github.com/spacemeshos/economics/simulation"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// derivedColumn is a column calculated from the other columns of each snapshot.
+type derivedColumn struct {
+	name    string
+	formula *formula.Formula
+}
+
+// parseDerivedColumn parses a column definition given as name=formula.
+func parseDerivedColumn(spec string) (derivedColumn, error) {
+	name, source, ok := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return derivedColumn{}, fmt.Errorf("expected name=formula, got %q", spec)
+	}
+	for i := 0; i < len(name); i++ {
+		if c := name[i]; !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			return derivedColumn{}, fmt.Errorf("invalid column name %q", name)
+		}
+	}
+	for _, key := range sqliteKeyColumns {
+		if strings.EqualFold(name, key) {
+			return derivedColumn{}, fmt.Errorf("column name %q is reserved", name)
+		}
+	}
+	f, err := formula.Parse(source)
+	if err != nil {
+		return derivedColumn{}, err
+	}
+	return derivedColumn{name, f}, nil
+}
+
+// derivedColumns is a flag of derived columns, which may be repeated to define one column after another.
+type derivedColumns []derivedColumn
+
+func (d *derivedColumns) String() string {
+	names := make([]string, len(*d))
+	for i, c := range *d {
+		names[i] = c.name
+	}
+	return strings.Join(names, ",")
+}
+
+func (d *derivedColumns) Set(spec string) error {
+	c, err := parseDerivedColumn(spec)
+	if err != nil {
+		return err
+	}
+	*d = append(*d, c)
+	return nil
+}
+
+// derivedColumnsFlag defines a flag of derived columns with the given name and usage, as flag.String does for strings.
+func derivedColumnsFlag(name, usage string) *derivedColumns {
+	d := &derivedColumns{}
+	flag.Var(d, name, usage)
+	return d
+}
+
+// formulaConstants are the variables available to every formula besides the columns.
+var formulaConstants = formula.Vars{
+	"oneSmesh":       decimal.WithContext(formula.Ctx).SetUint64(constants.OneSmesh),
+	"layersPerEpoch": decimal.WithContext(formula.Ctx).SetUint64(constants.OneEpoch),
+	"layersPerYear":  decimal.WithContext(formula.Ctx).SetUint64(constants.OneYear),
+	"totalIssuance":  decimal.WithContext(formula.Ctx).SetUint64(constants.TotalIssuance),
+}
+
+// decimalValue converts the exact value of a column to a decimal. Values that aren't numbers, including infinite
+// rates, are undefined.
+func decimalValue(v any) (*decimal.Big, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return decimal.WithContext(formula.Ctx).SetUint64(v), true
+	case int:
+		return decimal.WithContext(formula.Ctx).SetMantScale(int64(v), 0), true
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return decimal.WithContext(formula.Ctx).SetFloat64(v), true
+	case json.Number:
+		// the value of a derived column
+		return decimal.WithContext(formula.Ctx).SetString(string(v))
+	default:
+		return nil, false
+	}
+}
+
+// formatDecimal formats a decimal in plain notation for the locale of the printer, with the integer part grouped if it
+// fits.
+func formatDecimal(p *printer, d *decimal.Big) string {
+	s := fmt.Sprintf("%f", d)
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+	if n, err := strconv.ParseInt(integer, 10, 64); err == nil {
+		integer = p.Sprintf("%d", n)
+		if n == 0 && d.Signbit() {
+			integer = "-0"
+		}
+	}
+	if hasFraction {
+		return integer + p.point + fraction
+	}
+	return integer
+}
+
+// formulaColumn returns a column whose value is a formula of the other columns. Values are exact in the machine
+// readable formats, and shown in full in the table.
+func formulaColumn(name string, f *formula.Formula, vars func(s simulation.Snapshot) formula.Vars) snapshotColumn {
+	return snapshotColumn{
+		name:  name,
+		align: text.AlignRight,
+		cell: func(p *printer, s simulation.Snapshot) any {
+			value, ok := f.Eval(vars(s))
+			if !ok {
+				return "n/a"
+			}
+			return formatDecimal(p, value)
+		},
+		value: func(s simulation.Snapshot) any {
+			value, ok := f.Eval(vars(s))
+			if !ok {
+				return nil
+			}
+			// a JSON number can hold any number of digits, though readers may round it
+			return json.Number(fmt.Sprintf("%f", value))
+		},
+	}
+}
+
+// selectColumns returns the named columns, in the order given, from the available columns and the derived columns.
+// Derived columns may refer to any available column with a numeric value, to derived columns defined before them and
+// to the formula constants. If no names are given, all available columns are selected, followed by the derived columns.
+func selectColumns(available []snapshotColumn, names []string, derived []derivedColumn) ([]snapshotColumn, error) {
+	byName := make(map[string]snapshotColumn, len(available)+len(derived))
+	for _, c := range available {
+		byName[c.name] = c
+	}
+	all := append([]snapshotColumn(nil), available...)
+	for _, d := range derived {
+		if _, ok := byName[d.name]; ok {
+			return nil, fmt.Errorf("column %q is already defined", d.name)
+		}
+		if _, ok := formulaConstants[d.name]; ok {
+			return nil, fmt.Errorf("column %q is the name of a constant", d.name)
+		}
+
+		// each variable is a constant or a column with numeric values, which we check on an empty snapshot
+		getters := make(map[string]func(s simulation.Snapshot) (*decimal.Big, bool), len(d.formula.Vars()))
+		for _, name := range d.formula.Vars() {
+			if constant, ok := formulaConstants[name]; ok {
+				getters[name] = func(simulation.Snapshot) (*decimal.Big, bool) { return constant, true }
+				continue
+			}
+			c, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("column %q: unknown column %q", d.name, name)
+			}
+			if _, ok := c.value(simulation.Snapshot{}).(string); ok {
+				return nil, fmt.Errorf("column %q: column %q isn't a number", d.name, name)
+			}
+			getters[name] = func(s simulation.Snapshot) (*decimal.Big, bool) { return decimalValue(c.value(s)) }
+		}
+		vars := func(s simulation.Snapshot) formula.Vars {
+			vars := make(formula.Vars, len(getters))
+			for name, get := range getters {
+				if value, ok := get(s); ok {
+					vars[name] = value
+				}
+			}
+			return vars
+		}
+		c := formulaColumn(d.name, d.formula, vars)
+		byName[d.name] = c
+		all = append(all, c)
+	}
+
+	if len(names) == 0 {
+		return all, nil
+	}
+	selected := make([]snapshotColumn, 0, len(names))
+	for _, name := range names {
+		c, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			names := make([]string, len(all))
+			for i, c := range all {
+				names[i] = c.name
+			}
+			return nil, fmt.Errorf("unknown column %q; the columns are %s", name, strings.Join(names, ", "))
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
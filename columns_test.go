@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/formula"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SelectColumns(t *testing.T) {
+	free, err := parseDerivedColumn("free = smesh(circulatingTotal - vaultTotalVest)")
+	require.NoError(t, err)
+	epoch, err := parseDerivedColumn("subsidyEpoch=round(smesh(subsidyPerLayer * layersPerEpoch), 4)")
+	require.NoError(t, err)
+	double, err := parseDerivedColumn("double=subsidyEpoch * 2")
+	require.NoError(t, err)
+	opts := renderOptions{
+		columns: []string{"layer", "subsidyEpoch", "double", "free", "vaultPctVest"},
+		derived: []derivedColumn{free, epoch, double},
+	}
+	columns, err := selectTickColumns(opts)
+	require.NoError(t, err)
+	require.Len(t, columns, 5)
+
+	s := simulation.Snapshot{
+		Layer:            8064,
+		SubsidyPerLayer:  382094799184,
+		CirculatingTotal: 200*constants.OneSmesh + 1,
+		VaultTotalVest:   50 * constants.OneSmesh,
+		VaultTotal:       100 * constants.OneSmesh,
+	}
+	p := defaultPrinter()
+	assert.Equal(t, "1,540,606.2303", columns[1].cell(p, s))
+	assert.Equal(t, "3,081,212.4606", columns[2].cell(p, s))
+	assert.Equal(t, "150.000000001", columns[3].cell(p, s))
+
+	var buf bytes.Buffer
+	result := simulation.Result{Ticks: []simulation.Snapshot{s}}
+	require.NoError(t, render(&buf, formatCSV, opts, result))
+	assert.Equal(t, "layer,subsidyEpoch,double,free,vaultPctVest\n8064,1540606.2303,3081212.4606,150.000000001,50\n",
+		buf.String())
+	buf.Reset()
+	require.NoError(t, render(&buf, formatJSON, opts, result))
+	assert.Contains(t, buf.String(), `"subsidyEpoch": 1540606.2303, "double": 3081212.4606, "free": 150.000000001`)
+
+	// undefined values
+	undefined, err := parseDerivedColumn("undefined=circulatingTotal / vaultTotal")
+	require.NoError(t, err)
+	columns, err = selectColumns(tickColumns(renderOptions{}), nil, []derivedColumn{undefined})
+	require.NoError(t, err)
+	last := columns[len(columns)-1]
+	assert.Equal(t, "undefined", last.name)
+	assert.Equal(t, "n/a", last.cell(p, simulation.Snapshot{}))
+	assert.Nil(t, last.value(simulation.Snapshot{}))
+
+	for _, opts := range []renderOptions{
+		{columns: []string{"bogus"}},
+		{columns: []string{"fees"}},
+		{derived: []derivedColumn{{"layer", free.formula}}},
+		{derived: []derivedColumn{{"oneSmesh", free.formula}}},
+		{derived: []derivedColumn{double}},
+		{derived: []derivedColumn{{"x", mustFormula(t, "date + 1")}}},
+	} {
+		_, err := selectTickColumns(opts)
+		assert.Error(t, err)
+	}
+	for _, spec := range []string{"free", "=1", "1x=1", "x=1 +", "epoch=1", "Layers=1", "lastlayer=1"} {
+		_, err := parseDerivedColumn(spec)
+		assert.Error(t, err, spec)
+	}
+
+	// the flag may be repeated, and keeps the columns in order
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var derived derivedColumns
+	fs.Var(&derived, "define", "")
+	require.NoError(t, fs.Parse([]string{"-define", "a=1", "-define", "b=a + 1"}))
+	assert.Equal(t, "a,b", derived.String())
+	assert.Error(t, fs.Parse([]string{"-define", "x=1 +"}))
+}
+
+func mustFormula(t *testing.T, source string) *formula.Formula {
+	f, err := formula.Parse(source)
+	require.NoError(t, err)
+	return f
+}
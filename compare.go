@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/economics/burn"
+	"github.com/spacemeshos/economics/compare"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// compareCommand runs two configurations over the same ticks and prints the differences between them.
+func compareCommand(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "quiet mode (don't render progress)")
+	baselineSpec := fs.String("baseline", "", "baseline profile (default mainnet)")
+	alternativeSpec := fs.String("alternative", "", "alternative profile (required), as key=value pairs separated "+
+		"by semicolons, with keys total, vaulted, target, vestStart, vestEnd, effectiveGenesis, fees, empty, "+
+		"emptyPolicy, malfeasance and burn")
+	metricNames := fs.String("metrics", "circulatingTotal,issuanceTotal,subsidyPerLayer",
+		"comma-separated metrics to compare")
+	tickInterval := fs.Uint64("tick", defaultTickInterval, "number of layers between ticks")
+	endLayer := fs.Uint64("end", defaultEndLayer, "last layer (post-genesis) to simulate")
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	divergencesOnly := fs.Bool("divergences", false, "with -format csv, write the largest divergence in each metric "+
+		"rather than the differences per tick")
+	_ = fs.Parse(args)
+
+	if *alternativeSpec == "" {
+		log.Fatal("missing alternative profile")
+	}
+	if *tickInterval == 0 {
+		log.Fatal("tick interval must be positive")
+	}
+	metrics, err := compare.ParseMetrics(*metricNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+	base := simulation.Config{
+		Genesis:          defaultGenesisDate,
+		EffectiveGenesis: effectiveGenesis,
+		TickInterval:     *tickInterval,
+		EndLayer:         types.Layer(*endLayer),
+	}
+	baseline, err := parseProfile(*baselineSpec, base)
+	if err != nil {
+		log.Fatalf("invalid baseline profile: %v", err)
+	}
+	alternative, err := parseProfile(*alternativeSpec, base)
+	if err != nil {
+		log.Fatalf("invalid alternative profile: %v", err)
+	}
+	switch *format {
+	case formatTable, formatCSV, formatJSON:
+	default:
+		log.Fatalf("unknown output format %q", *format)
+	}
+
+	pw := progress.NewWriter()
+	pw.SetUpdateFrequency(time.Millisecond * 100)
+	if !*quiet {
+		go pw.Render()
+	}
+	start := time.Now()
+	configs := []simulation.Config{baseline, alternative}
+	results := make([]simulation.Result, len(configs))
+	var wg sync.WaitGroup
+	for i, name := range []string{"baseline", "alternative"} {
+		tracker := &progress.Tracker{Message: name, Total: int64(base.EndLayer), Units: progress.Units{
+			Formatter:        progress.FormatNumber,
+			Notation:         " layers",
+			NotationPosition: progress.UnitsNotationPositionAfter,
+		}}
+		pw.AppendTracker(tracker)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = simulation.Run(configs[i], func(layerID types.Layer) {
+				if layerID > 0 && layerID%1000 == 0 {
+					tracker.Increment(1000)
+				}
+			})
+			tracker.MarkAsDone()
+		}(i)
+	}
+	wg.Wait()
+	if !*quiet {
+		pw.Stop()
+	}
+	log.Printf("simulated both profiles to layer %d in %s\n", base.EndLayer, time.Since(start).Round(time.Second))
+
+	rows, divergences, err := compare.Compare(results[0].Ticks, results[1].Ticks, metrics)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p := message.NewPrinter(language.English)
+	for _, d := range divergences {
+		rel, ok := d.Diff.Relative()
+		pct := "n/a"
+		if ok {
+			pct = p.Sprintf("%+.2f%%", 100*rel)
+		}
+		log.Print(p.Sprintf("largest divergence in %s is %s smidge (%s) at layer %d (%s)\n", d.Metric,
+			formatDiff(p, d.Diff, 1), pct, d.Layer, d.Date.Format("2006-01-02")))
+	}
+	if err := renderComparison(os.Stdout, *format, *divergencesOnly, metrics, rows, divergences); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseProfile returns the simulation config described by a profile: key=value pairs separated by semicolons, which
+// modify the mainnet parameters. Total issuance and the vault are in SMESH, and the subsidy curve is given by a solver
+// target; if either total issuance or the vault is given without a target, the curve meets the mainnet ten year target.
+func parseProfile(spec string, base simulation.Config) (simulation.Config, error) {
+	cfg := base
+	total, vaulted := uint64(constants.TotalIssuance), uint64(constants.TotalVaulted)
+	vest := vesting.Mainnet
+	var target rewards.Target
+	var subsidyChanged, vestChanged bool
+	smesh := func(value string) (uint64, error) {
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || v > ^uint64(0)/constants.OneSmesh {
+			return 0, fmt.Errorf("invalid amount %q", value)
+		}
+		return v * constants.OneSmesh, nil
+	}
+	layer := func(value string) (types.Layer, error) {
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid layer %q", value)
+		}
+		return types.Layer(v), nil
+	}
+
+	for _, pair := range strings.Split(spec, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return cfg, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		var err error
+		switch strings.TrimSpace(key) {
+		case "total":
+			total, err = smesh(value)
+			subsidyChanged = true
+		case "vaulted":
+			vaulted, err = smesh(value)
+			subsidyChanged, vestChanged = true, true
+		case "target":
+			target, err = rewards.ParseTarget(value)
+			subsidyChanged = true
+		case "vestStart":
+			vest.VestStart, err = layer(value)
+			vestChanged = true
+		case "vestEnd":
+			vest.VestEnd, err = layer(value)
+			vestChanged = true
+		case "effectiveGenesis":
+			cfg.EffectiveGenesis, err = layer(value)
+		case "fees":
+			cfg.Fees, err = fees.Parse(value)
+		case "empty":
+			cfg.EmptyLayers, err = emptylayers.Parse(value)
+		case "emptyPolicy":
+			cfg.EmptyPolicy, err = emptylayers.ParsePolicy(value)
+		case "malfeasance":
+			cfg.Malfeasance, err = malfeasance.Parse(value)
+		case "burn":
+			cfg.Burn, err = burn.Parse(value)
+		default:
+			err = errors.New("unknown key")
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if err := checkBurn(cfg.Fees, cfg.Burn); err != nil {
+		return cfg, fmt.Errorf("burn: %w", err)
+	}
+
+	if subsidyChanged {
+		if target == nil {
+			target = rewards.IssuanceTarget{Issuance: constants.TenYearTarget, Years: decimal.New(10, 0)}
+		}
+		solution, err := rewards.Solve(total, vaulted, target)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Subsidy = solution.Schedule
+	}
+	if vestChanged {
+		vest.TotalVaulted = vaulted
+		vest.VestedAtCliff = uint64(constants.CliffRatio * float64(vaulted))
+		if err := vest.Validate(); err != nil {
+			return cfg, err
+		}
+		cfg.Vesting = &vest
+	}
+	return cfg, nil
+}
+
+// formatDiff formats a difference with its sign, in the given unit, rounded toward zero.
+func formatDiff(p *message.Printer, d compare.Diff, unit uint64) string {
+	magnitude, negative := d.Abs()
+	if negative && magnitude >= unit {
+		return "-" + p.Sprintf("%d", magnitude/unit)
+	}
+	return "+" + p.Sprintf("%d", magnitude/unit)
+}
+
+// diffValue returns a difference as an exact number of smidge for the machine readable formats. It may exceed the
+// range of an int64, so it's given as its digits.
+func diffValue(d compare.Diff) json.Number {
+	magnitude, negative := d.Abs()
+	if negative {
+		return json.Number("-" + strconv.FormatUint(magnitude, 10))
+	}
+	return json.Number(strconv.FormatUint(magnitude, 10))
+}
+
+func diffColumn[T any](name string, diff func(T) compare.Diff) column[T] {
+	return column[T]{
+		name:  name,
+		align: text.AlignRight,
+		cell:  func(p *printer, row T) any { return formatDiff(p.Printer, diff(row), constants.OneSmesh) },
+		value: func(row T) any { return diffValue(diff(row)) },
+	}
+}
+
+// comparisonColumns has, for each metric, its value in the baseline and the alternative, and the absolute and
+// relative differences.
+func comparisonColumns(metrics []compare.Metric) []column[compare.Row] {
+	columns := []column[compare.Row]{
+		{
+			name:  "layer",
+			cell:  func(_ *printer, r compare.Row) any { return uint64(r.Layer) },
+			value: func(r compare.Row) any { return uint64(r.Layer) },
+		},
+		{
+			name:  "epoch",
+			cell:  func(_ *printer, r compare.Row) any { return uint64(r.Epoch) },
+			value: func(r compare.Row) any { return uint64(r.Epoch) },
+		},
+		dateColumn("date", func(r compare.Row) time.Time { return r.Date }),
+	}
+	for i, m := range metrics {
+		i := i
+		columns = append(columns,
+			amountColumn(m.Name+"Base", 11, text.AlignRight,
+				func(r compare.Row) uint64 { return r.Diffs[i].Baseline }),
+			amountColumn(m.Name+"Alt", 11, text.AlignRight,
+				func(r compare.Row) uint64 { return r.Diffs[i].Alternative }),
+			diffColumn(m.Name+"Diff", func(r compare.Row) compare.Diff { return r.Diffs[i] }),
+			rateColumn(m.Name+"PctDiff", true, func(r compare.Row) (float64, bool) { return r.Diffs[i].Relative() }),
+		)
+	}
+	return columns
+}
+
+func divergenceColumns() []column[compare.Divergence] {
+	return []column[compare.Divergence]{
+		{
+			name:  "metric",
+			cell:  func(_ *printer, d compare.Divergence) any { return d.Metric },
+			value: func(d compare.Divergence) any { return d.Metric },
+		},
+		{
+			name:  "layer",
+			cell:  func(_ *printer, d compare.Divergence) any { return uint64(d.Layer) },
+			value: func(d compare.Divergence) any { return uint64(d.Layer) },
+		},
+		dateColumn("date", func(d compare.Divergence) time.Time { return d.Date }),
+		amountColumn("base", 11, text.AlignRight, func(d compare.Divergence) uint64 { return d.Diff.Baseline }),
+		amountColumn("alt", 11, text.AlignRight, func(d compare.Divergence) uint64 { return d.Diff.Alternative }),
+		diffColumn("diff", func(d compare.Divergence) compare.Diff { return d.Diff }),
+		rateColumn("pctDiff", true, func(d compare.Divergence) (float64, bool) { return d.Diff.Relative() }),
+	}
+}
+
+// renderComparison writes the differences per tick, followed by the largest divergences, which are logged in any case.
+// CSV output has room for one or the other, so divergencesOnly selects the largest divergences instead.
+func renderComparison(w io.Writer, format string, divergencesOnly bool, metrics []compare.Metric, rows []compare.Row,
+	divergences []compare.Divergence,
+) error {
+	columns := comparisonColumns(metrics)
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, rows, "Please note:\n"+
+			"- All figures in SMESH (rounded toward zero)\n"+
+			"- Differences are of the alternative from the baseline, relative to the baseline\n")
+		renderTable(w, defaultPrinter(), divergenceColumns(), divergences, "Please note:\n"+
+			"- The largest absolute difference in each metric, and the tick at which it occurs\n")
+		return nil
+	case formatCSV:
+		if divergencesOnly {
+			return renderCSV(w, divergenceColumns(), divergences)
+		}
+		return renderCSV(w, columns, rows)
+	case formatJSON:
+		return renderJSONSections(w, jsonSection[compare.Row]{"ticks", columns, rows},
+			jsonSection[compare.Divergence]{"divergences", divergenceColumns(), divergences})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
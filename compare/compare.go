@@ -0,0 +1,129 @@
+// Package compare lines up the results of two simulations over the same ticks, metric by metric, and finds where they
+// diverge the most.
+package compare
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Metric is an amount, in smidge, taken from each snapshot.
+type Metric struct {
+	Name  string
+	Value func(s simulation.Snapshot) uint64
+}
+
+// Metrics are the metrics that may be compared, in output order.
+var Metrics = []Metric{
+	{"vaultTotalVest", func(s simulation.Snapshot) uint64 { return s.VaultTotalVest }},
+	{"subsidyPerLayer", func(s simulation.Snapshot) uint64 { return s.SubsidyPerLayer }},
+	{"subsidyNew", func(s simulation.Snapshot) uint64 { return s.SubsidyNew }},
+	{"subsidyTotal", func(s simulation.Snapshot) uint64 { return s.SubsidyTotal }},
+	{"circulatingTotal", func(s simulation.Snapshot) uint64 { return s.CirculatingTotal }},
+	{"issuanceTotal", func(s simulation.Snapshot) uint64 { return s.IssuanceTotal }},
+	{"feesTotal", func(s simulation.Snapshot) uint64 { return s.FeesTotal }},
+	{"forfeitedTotal", func(s simulation.Snapshot) uint64 { return s.ForfeitedTotal }},
+	{"burnedTotal", func(s simulation.Snapshot) uint64 { return s.BurnedTotal }},
+	{"netIssuance", simulation.Snapshot.NetIssuance},
+}
+
+// ParseMetrics returns the metrics with the given comma-separated names, in the order given.
+func ParseMetrics(names string) ([]Metric, error) {
+	var metrics []Metric
+	for _, name := range strings.Split(names, ",") {
+		found := false
+		for _, m := range Metrics {
+			if m.Name == strings.TrimSpace(name) {
+				metrics = append(metrics, m)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown metric %q", name)
+		}
+	}
+	return metrics, nil
+}
+
+// Diff is the value of a metric in the baseline and the alternative.
+type Diff struct {
+	Baseline    uint64
+	Alternative uint64
+}
+
+// Abs returns the difference of the alternative from the baseline, as its magnitude and sign. Amounts such as fee and
+// burn totals read from a file aren't bounded by total issuance, so the difference needn't fit in an int64.
+func (d Diff) Abs() (magnitude uint64, negative bool) {
+	if d.Alternative >= d.Baseline {
+		return d.Alternative - d.Baseline, false
+	}
+	return d.Baseline - d.Alternative, true
+}
+
+// magnitude returns the absolute value of the difference.
+func (d Diff) magnitude() uint64 {
+	m, _ := d.Abs()
+	return m
+}
+
+// Relative returns the difference as a fraction of the baseline. It returns false if the baseline is zero.
+func (d Diff) Relative() (float64, bool) {
+	if d.Baseline == 0 {
+		return 0, false
+	}
+	return (float64(d.Alternative) - float64(d.Baseline)) / float64(d.Baseline), true
+}
+
+// Row holds the differences in each metric as of one tick.
+type Row struct {
+	Layer types.Layer
+	Epoch types.Epoch
+	Date  time.Time
+
+	// Diffs holds one difference per metric, in the order the metrics were given
+	Diffs []Diff
+}
+
+// Divergence is the tick at which a metric differs the most between the two simulations.
+type Divergence struct {
+	Metric string
+	Layer  types.Layer
+	Date   time.Time
+	Diff   Diff
+}
+
+// Compare lines up the ticks of two simulations and returns the differences in each metric per tick, and for each
+// metric the tick with the largest absolute difference (the earliest, in case of a tie). Both simulations must have
+// the same ticks.
+func Compare(baseline, alternative []simulation.Snapshot, metrics []Metric) ([]Row, []Divergence, error) {
+	if len(baseline) != len(alternative) {
+		return nil, nil, fmt.Errorf("baseline has %d ticks but alternative has %d", len(baseline), len(alternative))
+	}
+	rows := make([]Row, len(baseline))
+	divergences := make([]Divergence, len(metrics))
+	for i, m := range metrics {
+		divergences[i].Metric = m.Name
+	}
+	for i, b := range baseline {
+		a := alternative[i]
+		if a.Layer != b.Layer {
+			return nil, nil, fmt.Errorf("tick %d is layer %d in baseline but layer %d in alternative", i, b.Layer, a.Layer)
+		}
+		rows[i] = Row{Layer: b.Layer, Epoch: b.Epoch, Date: b.Date, Diffs: make([]Diff, len(metrics))}
+		for j, m := range metrics {
+			d := Diff{Baseline: m.Value(b), Alternative: m.Value(a)}
+			rows[i].Diffs[j] = d
+			if i == 0 || d.magnitude() > divergences[j].Diff.magnitude() {
+				divergences[j].Layer = b.Layer
+				divergences[j].Date = b.Date
+				divergences[j].Diff = d
+			}
+		}
+	}
+	return rows, divergences, nil
+}
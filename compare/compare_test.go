@@ -0,0 +1,95 @@
+package compare
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Diff(t *testing.T) {
+	d := Diff{Baseline: 100, Alternative: 150}
+	magnitude, negative := d.Abs()
+	assert.Equal(t, uint64(50), magnitude)
+	assert.False(t, negative)
+	rel, ok := d.Relative()
+	assert.True(t, ok)
+	assert.InDelta(t, 0.5, rel, 1e-12)
+
+	d = Diff{Baseline: 100, Alternative: 25}
+	magnitude, negative = d.Abs()
+	assert.Equal(t, uint64(75), magnitude)
+	assert.True(t, negative)
+	assert.Equal(t, uint64(75), d.magnitude())
+	rel, _ = d.Relative()
+	assert.InDelta(t, -0.75, rel, 1e-12)
+
+	_, ok = Diff{Alternative: 1}.Relative()
+	assert.False(t, ok)
+
+	// differences beyond the range of an int64
+	magnitude, negative = Diff{Baseline: 0, Alternative: math.MaxUint64}.Abs()
+	assert.Equal(t, uint64(math.MaxUint64), magnitude)
+	assert.False(t, negative)
+	magnitude, negative = Diff{Baseline: math.MaxUint64, Alternative: 1}.Abs()
+	assert.Equal(t, uint64(math.MaxUint64-1), magnitude)
+	assert.True(t, negative)
+}
+
+func Test_ParseMetrics(t *testing.T) {
+	metrics, err := ParseMetrics("issuanceTotal, circulatingTotal")
+	require.NoError(t, err)
+	require.Len(t, metrics, 2)
+	assert.Equal(t, "issuanceTotal", metrics[0].Name)
+	assert.Equal(t, "circulatingTotal", metrics[1].Name)
+
+	_, err = ParseMetrics("circulatingTotal,bogus")
+	assert.ErrorContains(t, err, "bogus")
+}
+
+func Test_Compare(t *testing.T) {
+	cfg := simulation.Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         6 * constants.OneEpoch,
+	}
+	baseline := simulation.Run(cfg, nil).Ticks
+	cfg.Subsidy = rewards.NewSchedule(constants.TotalSubsidy, constants.TotalSubsidy/2)
+	alternative := simulation.Run(cfg, nil).Ticks
+
+	metrics, err := ParseMetrics("subsidyPerLayer,subsidyTotal,vaultTotalVest")
+	require.NoError(t, err)
+	rows, divergences, err := Compare(baseline, alternative, metrics)
+	require.NoError(t, err)
+	require.Len(t, rows, len(baseline))
+	for i, row := range rows {
+		assert.Equal(t, baseline[i].Layer, row.Layer)
+		assert.Equal(t, Diff{Baseline: baseline[i].SubsidyTotal, Alternative: alternative[i].SubsidyTotal}, row.Diffs[1])
+	}
+
+	// the faster curve pays more per layer from the start, but the gap in per-layer subsidy narrows as it decays
+	// faster, while the gap in total subsidy keeps growing
+	require.Len(t, divergences, 3)
+	assert.Equal(t, "subsidyPerLayer", divergences[0].Metric)
+	assert.Equal(t, cfg.EffectiveGenesis, divergences[0].Layer)
+	magnitude, negative := divergences[0].Diff.Abs()
+	assert.Positive(t, magnitude)
+	assert.False(t, negative)
+	assert.Equal(t, cfg.EndLayer, divergences[1].Layer)
+	assert.Equal(t, rows[len(rows)-1].Diffs[1], divergences[1].Diff)
+
+	// with no difference, the earliest tick is reported
+	assert.Equal(t, baseline[0].Layer, divergences[2].Layer)
+	assert.Zero(t, divergences[2].Diff.magnitude())
+
+	_, _, err = Compare(baseline, alternative[1:], metrics)
+	assert.Error(t, err)
+	_, _, err = Compare(baseline[1:], alternative[:len(alternative)-1], metrics)
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/spacemeshos/economics/burn"
+	"github.com/spacemeshos/economics/compare"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseProfile(t *testing.T) {
+	base := simulation.Config{EffectiveGenesis: effectiveGenesis}
+
+	// the empty profile is mainnet
+	cfg, err := parseProfile("", base)
+	require.NoError(t, err)
+	assert.Equal(t, base, cfg)
+
+	cfg, err = parseProfile("vaulted=100000000; vestEnd=315360;effectiveGenesis=0;fees=constant:10;burn=fraction:0.5",
+		base)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Vesting)
+	assert.Equal(t, uint64(100_000_000*constants.OneSmesh), cfg.Vesting.TotalVaulted)
+	assert.Equal(t, types.Layer(constants.VestStart), cfg.Vesting.VestStart)
+	assert.Equal(t, types.Layer(315360), cfg.Vesting.VestEnd)
+	assert.Zero(t, cfg.EffectiveGenesis)
+	assert.Equal(t, fees.Constant(10), cfg.Fees)
+	assert.Equal(t, burn.Fraction(0.5), cfg.Burn)
+
+	// a smaller vault leaves more subsidy to meet the same ten year target
+	schedule := cfg.Subsidy.(*rewards.Schedule)
+	assert.Equal(t, 0, schedule.TotalSubsidy.Cmp(decimal.New(2_300_000_000*constants.OneSmesh, 0)))
+	assert.Equal(t, uint64(500_000_000*constants.OneSmesh),
+		schedule.TotalAccumulatedSubsidyAtLayer(10*constants.OneYear)+1)
+
+	cfg, err = parseProfile("target=halflife:8", base)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cfg.Subsidy.(*rewards.Schedule).HalfLife.Cmp(decimal.New(8*constants.OneYear, 0)))
+	assert.Nil(t, cfg.Vesting)
+
+	for _, spec := range []string{"bogus=1", "vaulted", "vaulted=x", "vestEnd=1", "target=halflife:0", "fees=bogus",
+		"vaulted=3000000000", "burn=fraction:0.5"} {
+		_, err := parseProfile(spec, base)
+		assert.Error(t, err, spec)
+	}
+}
+
+func Test_RenderComparison(t *testing.T) {
+	metrics, err := compare.ParseMetrics("circulatingTotal")
+	require.NoError(t, err)
+	diff := compare.Diff{Baseline: 200, Alternative: 150}
+	rows := []compare.Row{{Layer: 1, Epoch: 0, Date: defaultGenesisDate, Diffs: []compare.Diff{diff}}}
+	divergences := []compare.Divergence{{Metric: "circulatingTotal", Layer: 1, Diff: diff}}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderComparison(&buf, formatCSV, false, metrics, rows, divergences))
+	assert.Equal(t, "layer,epoch,date,circulatingTotalBase,circulatingTotalAlt,circulatingTotalDiff,"+
+		"circulatingTotalPctDiff\n1,0,2023-07-14,200,150,-50,-0.25\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderComparison(&buf, formatCSV, true, metrics, rows, divergences))
+	assert.Equal(t, "metric,layer,date,base,alt,diff,pctDiff\n"+
+		"circulatingTotal,1,0001-01-01,200,150,-50,-0.25\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, renderComparison(&buf, formatJSON, false, metrics, rows, divergences))
+	var output struct {
+		Ticks       []map[string]any `json:"ticks"`
+		Divergences []map[string]any `json:"divergences"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &output))
+	require.Len(t, output.Ticks, 1)
+	require.Len(t, output.Divergences, 1)
+	assert.Equal(t, "circulatingTotal", output.Divergences[0]["metric"])
+	assert.Equal(t, -50.0, output.Divergences[0]["diff"])
+
+	buf.Reset()
+	require.NoError(t, renderComparison(&buf, formatTable, false, metrics, rows, divergences))
+	assert.Contains(t, buf.String(), "The largest absolute difference in each metric")
+
+	// differences beyond the range of an int64 are exact
+	diff = compare.Diff{Baseline: math.MaxUint64, Alternative: 0}
+	rows[0].Diffs[0] = diff
+	buf.Reset()
+	require.NoError(t, renderComparison(&buf, formatCSV, false, metrics, rows, divergences))
+	assert.Contains(t, buf.String(), ",-18446744073709551615,")
+	assert.Equal(t, "-18,446,744,073,709,551,615", formatDiff(defaultPrinter().Printer, diff, 1))
+	assert.Equal(t, "+0", formatDiff(defaultPrinter().Printer, compare.Diff{Baseline: 1}, constants.OneSmesh))
+}
@@ -0,0 +1,83 @@
+// Package distribution divides a layer's reward among its eligible recipients in proportion to their weight, e.g.,
+// their number of eligibilities or space units.
+//
+// Amounts are exact: every recipient receives the integer part of its proportional share, and the smidge left over
+// from rounding down are handed out one at a time, in order of largest fractional share first, until none remain
+// (the largest remainder method). Ties between equal fractional shares go to the recipient with the lexicographically
+// smaller ID, then to the one that appears first in the input. The result depends only on the amount and the set of
+// recipients, so every implementation that follows these rules arrives at the same figures.
+package distribution
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+)
+
+// Recipient is a party eligible for a share of a reward.
+type Recipient struct {
+	// ID identifies the recipient, e.g., a hex-encoded coinbase or node ID; it's only used to break ties
+	ID string `json:"id"`
+
+	// Weight is the recipient's relative claim on the reward
+	Weight uint64 `json:"weight"`
+}
+
+var (
+	ErrNoWeight       = errors.New("total weight of recipients is zero")
+	ErrWeightOverflow = errors.New("total weight of recipients overflows")
+)
+
+// TotalWeight returns the sum of the recipients' weights.
+func TotalWeight(recipients []Recipient) (uint64, error) {
+	var total uint64
+	for _, r := range recipients {
+		var carry uint64
+		total, carry = bits.Add64(total, r.Weight, 0)
+		if carry != 0 {
+			return 0, ErrWeightOverflow
+		}
+	}
+	if total == 0 {
+		return 0, ErrNoWeight
+	}
+	return total, nil
+}
+
+// Distribute divides amount among the recipients in proportion to their weights. It returns one amount per recipient,
+// in input order, and the amounts sum to exactly the input amount.
+func Distribute(amount uint64, recipients []Recipient) ([]uint64, error) {
+	totalWeight, err := TotalWeight(recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	// amount*weight/totalWeight <= amount, so the 128-bit quotient always fits in 64 bits
+	shares := make([]uint64, len(recipients))
+	remainders := make([]uint64, len(recipients))
+	leftover := amount
+	for i, r := range recipients {
+		hi, lo := bits.Mul64(amount, r.Weight)
+		shares[i], remainders[i] = bits.Div64(hi, lo, totalWeight)
+		leftover -= shares[i]
+	}
+
+	// the fractional shares all have the same denominator, so we can order them by their numerators
+	order := make([]int, len(recipients))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := recipients[order[a]], recipients[order[b]]
+		if remainders[order[a]] != remainders[order[b]] {
+			return remainders[order[a]] > remainders[order[b]]
+		}
+		return ra.ID < rb.ID
+	})
+
+	// the leftover is strictly less than the number of recipients with a nonzero remainder
+	for _, i := range order[:leftover] {
+		shares[i]++
+	}
+	return shares, nil
+}
@@ -0,0 +1,108 @@
+package distribution
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"os"
+	"testing"
+	"testing/quick"
+
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type vector struct {
+	Name       string      `json:"name"`
+	Amount     uint64      `json:"amount"`
+	Recipients []Recipient `json:"recipients"`
+	Expected   []uint64    `json:"expected"`
+	Error      string      `json:"error"`
+}
+
+// Test_Vectors checks the shared test vectors, which other implementations can use to check that they follow the same
+// remainder rule.
+func Test_Vectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/vectors.json")
+	require.NoError(t, err)
+	var vectors struct {
+		Vectors []vector `json:"vectors"`
+		Errors  []vector `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(data, &vectors))
+	require.NotEmpty(t, vectors.Vectors)
+
+	for _, v := range vectors.Vectors {
+		shares, err := Distribute(v.Amount, v.Recipients)
+		require.NoError(t, err, v.Name)
+		assert.Equal(t, v.Expected, shares, v.Name)
+	}
+	for _, v := range vectors.Errors {
+		_, err := Distribute(v.Amount, v.Recipients)
+		assert.EqualError(t, err, v.Error, v.Name)
+	}
+}
+
+func Test_Properties(t *testing.T) {
+	err := quick.Check(func(amount uint64, weights []uint32) bool {
+		recipients := make([]Recipient, len(weights))
+		var totalWeight uint64
+		for i, w := range weights {
+			recipients[i] = Recipient{ID: string(rune('a' + i%26)), Weight: uint64(w)}
+			totalWeight += uint64(w)
+		}
+		shares, err := Distribute(amount, recipients)
+		if totalWeight == 0 {
+			return err == ErrNoWeight
+		}
+		require.NoError(t, err)
+
+		// the shares sum to the amount, and each share is its proportional share rounded down, plus at most one smidge
+		var sum uint64
+		for i, share := range shares {
+			sum += share
+			// floor(amount * weight / totalWeight) <= share <= floor(...) + 1
+			floor := new(big.Int).Mul(new(big.Int).SetUint64(amount), new(big.Int).SetUint64(recipients[i].Weight))
+			floor.Quo(floor, new(big.Int).SetUint64(totalWeight))
+			diff := new(big.Int).Sub(new(big.Int).SetUint64(share), floor)
+			if diff.Sign() < 0 || diff.Cmp(big.NewInt(1)) > 0 {
+				return false
+			}
+		}
+		return sum == amount
+	}, &quick.Config{MaxCount: 1000})
+	assert.NoError(t, err)
+}
+
+func Test_Deterministic(t *testing.T) {
+	// the same set of recipients in any order receive the same amounts
+	amount := rewards.TotalSubsidyAtLayer(0)
+	recipients := []Recipient{{"d", 3}, {"a", 3}, {"c", 3}, {"b", 3}, {"e", 1}}
+	expected := map[string]uint64{}
+	shares, err := Distribute(amount, recipients)
+	require.NoError(t, err)
+	for i, r := range recipients {
+		expected[r.ID] = shares[i]
+	}
+
+	for i := 0; i < len(recipients); i++ {
+		recipients = append(recipients[1:], recipients[0])
+		shares, err := Distribute(amount, recipients)
+		require.NoError(t, err)
+		for j, r := range recipients {
+			assert.Equal(t, expected[r.ID], shares[j], "unexpected share for %s in rotation %d", r.ID, i)
+		}
+	}
+}
+
+func Test_TotalWeight(t *testing.T) {
+	total, err := TotalWeight([]Recipient{{"a", 1}, {"b", math.MaxUint64 - 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), total)
+
+	_, err = TotalWeight([]Recipient{{"a", 2}, {"b", math.MaxUint64 - 1}})
+	assert.ErrorIs(t, err, ErrWeightOverflow)
+	_, err = TotalWeight(nil)
+	assert.ErrorIs(t, err, ErrNoWeight)
+}
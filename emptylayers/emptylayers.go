@@ -0,0 +1,158 @@
+// Package emptylayers models layers that produce no block, and thus pay no rewards. The subsidy scheduled for an empty
+// layer is either forfeited or redistributed to the next layer that isn't empty, according to a Policy.
+package emptylayers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Model decides which layers, post-genesis, are empty.
+type Model interface {
+	IsEmpty(layerID types.Layer) bool
+}
+
+// List is an explicit set of empty layers.
+type List map[types.Layer]struct{}
+
+func (l List) IsEmpty(layerID types.Layer) bool {
+	_, ok := l[layerID]
+	return ok
+}
+
+// ReadList reads a list of empty layers, one per line. Blank lines and lines starting with # are ignored.
+func ReadList(r io.Reader) (List, error) {
+	list := List{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		layerID, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid layer: %w", line, err)
+		}
+		list[types.Layer(layerID)] = struct{}{}
+	}
+	return list, scanner.Err()
+}
+
+// Rate spreads empty layers evenly, so that exactly the given fraction of layers is empty over any long enough span:
+// a layer is empty if the count of empty layers, rounded down, goes up by one in that layer.
+type Rate float64
+
+func (r Rate) IsEmpty(layerID types.Layer) bool {
+	return math.Floor(float64(r)*(float64(layerID)+1)) > math.Floor(float64(r)*float64(layerID))
+}
+
+// Random makes each layer empty independently with the given probability. The outcome for a layer depends only on the
+// seed and the layer, so the same seed always produces the same set of empty layers, in whatever order they're
+// queried.
+type Random struct {
+	Probability float64
+	Seed        uint64
+}
+
+func (r Random) IsEmpty(layerID types.Layer) bool {
+	// top 53 bits as a uniform float in [0, 1)
+	return float64(splitmix64(r.Seed^splitmix64(uint64(layerID)))>>11)/(1<<53) < r.Probability
+}
+
+// splitmix64 is the output function of the SplitMix64 generator, a fast, well distributed 64-bit hash.
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// Policy decides what happens to the subsidy scheduled for an empty layer.
+type Policy int
+
+const (
+	// Forfeit means the subsidy for an empty layer is never issued.
+	Forfeit Policy = iota
+
+	// Redistribute means the subsidy for an empty layer is added to that of the next layer that isn't empty.
+	Redistribute
+)
+
+func (p Policy) String() string {
+	switch p {
+	case Forfeit:
+		return "forfeit"
+	case Redistribute:
+		return "redistribute"
+	default:
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+}
+
+// ParsePolicy returns the policy with the given name.
+func ParsePolicy(name string) (Policy, error) {
+	for _, p := range []Policy{Forfeit, Redistribute} {
+		if p.String() == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown empty layer policy %q", name)
+}
+
+// Parse returns the model described by a scenario, one of:
+//
+//	file:<path to list of empty layers>
+//	rate:<fraction of layers that are empty, evenly spread>
+//	random:<probability that each layer is empty>:<seed>
+func Parse(scenario string) (Model, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	params := strings.Split(args, ":")
+	switch kind {
+	case "file":
+		list, err := keyed.Open(args, ReadList)
+		if err != nil {
+			return nil, err
+		}
+		return list, nil
+	case "rate":
+		if len(params) != 1 {
+			return nil, errors.New("usage: rate:<fraction>")
+		}
+		p, err := parseProbability(params[0])
+		if err != nil {
+			return nil, err
+		}
+		return Rate(p), nil
+	case "random":
+		if len(params) != 2 {
+			return nil, errors.New("usage: random:<probability>:<seed>")
+		}
+		p, err := parseProbability(params[0])
+		if err != nil {
+			return nil, err
+		}
+		seed, err := strconv.ParseUint(params[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed: %w", err)
+		}
+		return Random{Probability: p, Seed: seed}, nil
+	default:
+		return nil, fmt.Errorf("unknown empty layer scenario %q", scenario)
+	}
+}
+
+func parseProbability(s string) (float64, error) {
+	p, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(p >= 0 && p <= 1) {
+		return 0, fmt.Errorf("invalid probability %q: must be between 0 and 1", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,89 @@
+package emptylayers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func count(model Model, layers uint64) (empty uint64) {
+	for layerID := types.Layer(0); uint64(layerID) < layers; layerID++ {
+		if model.IsEmpty(layerID) {
+			empty++
+		}
+	}
+	return empty
+}
+
+func Test_ReadList(t *testing.T) {
+	list, err := ReadList(strings.NewReader("# empty layers\n5\n\n 10 \n5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, List{5: {}, 10: {}}, list)
+	assert.True(t, list.IsEmpty(5))
+	assert.False(t, list.IsEmpty(6))
+
+	_, err = ReadList(strings.NewReader("5\nfive\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func Test_Rate(t *testing.T) {
+	assert.Equal(t, uint64(0), count(Rate(0), 1000))
+	assert.Equal(t, uint64(1000), count(Rate(1), 1000))
+	assert.Equal(t, uint64(250), count(Rate(0.25), 1000))
+
+	// evenly spread: every fourth layer
+	for layerID := types.Layer(0); layerID < 100; layerID++ {
+		assert.Equal(t, layerID%4 == 3, Rate(0.25).IsEmpty(layerID), "layer %d", layerID)
+	}
+}
+
+func Test_Random(t *testing.T) {
+	model := Random{Probability: 0.1, Seed: 42}
+	empty := count(model, 100000)
+	assert.InDelta(t, 10000, empty, 500)
+
+	// deterministic for a given seed, and different for another
+	assert.Equal(t, empty, count(model, 100000))
+	assert.NotEqual(t, empty, count(Random{Probability: 0.1, Seed: 43}, 100000))
+
+	assert.Equal(t, uint64(0), count(Random{Probability: 0, Seed: 1}, 1000))
+	assert.Equal(t, uint64(1000), count(Random{Probability: 1, Seed: 1}, 1000))
+}
+
+func Test_Policy(t *testing.T) {
+	for _, p := range []Policy{Forfeit, Redistribute} {
+		parsed, err := ParsePolicy(p.String())
+		require.NoError(t, err)
+		assert.Equal(t, p, parsed)
+	}
+	_, err := ParsePolicy("burn")
+	assert.Error(t, err)
+}
+
+func Test_Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.txt")
+	require.NoError(t, os.WriteFile(path, []byte("3\n"), 0o600))
+
+	for scenario, expected := range map[string]Model{
+		"file:" + path:    List{3: {}},
+		"rate:0.01":       Rate(0.01),
+		"random:0.05:123": Random{Probability: 0.05, Seed: 123},
+	} {
+		model, err := Parse(scenario)
+		require.NoError(t, err, scenario)
+		assert.Equal(t, expected, model, scenario)
+	}
+
+	for _, scenario := range []string{
+		"", "none", "file:", "rate:", "rate:1.5", "rate:-0.1", "rate:NaN", "random:0.1", "random:0.1:-1",
+		"file:" + filepath.Join(t.TempDir(), "missing.txt"),
+	} {
+		_, err := Parse(scenario)
+		assert.Error(t, err, scenario)
+	}
+}
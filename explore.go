@@ -0,0 +1,635 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/term"
+)
+
+// exploreCommand runs a simulation and browses the ticks interactively in the terminal.
+func exploreCommand(args []string) {
+	fs := flag.NewFlagSet("explore", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "quiet mode (don't render progress)")
+	profileSpec := fs.String("profile", "", "profile to simulate (default mainnet), as key=value pairs separated by "+
+		"semicolons, as for compare")
+	tickInterval := fs.Uint64("tick", defaultTickInterval, "number of layers between ticks")
+	endLayer := fs.Uint64("end", defaultEndLayer, "last layer (post-genesis) to simulate")
+	inflation := fs.Bool("inflation", false, "show inflation metrics")
+	unitSpec := fs.String("unit", "smesh", "unit of amounts: smidge, smesh or ksmesh (thousands of SMESH), "+
+		"optionally with a number of decimals, e.g., smesh:4")
+	localeSpec := fs.String("locale", "", "locale of numbers and dates, e.g., de or en-GB (default English "+
+		"numbers and ISO 8601 dates)")
+	_ = fs.Parse(args)
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		log.Fatal("explore needs a terminal")
+	}
+	if *tickInterval == 0 {
+		log.Fatal("tick interval must be positive")
+	}
+	u, err := parseUnit(*unitSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	locale, err := parseLocale(*localeSpec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, err := parseProfile(*profileSpec, simulation.Config{
+		Genesis:          defaultGenesisDate,
+		EffectiveGenesis: effectiveGenesis,
+		TickInterval:     *tickInterval,
+		EndLayer:         types.Layer(*endLayer),
+	})
+	if err != nil {
+		log.Fatalf("invalid profile: %v", err)
+	}
+
+	result := runSimulation(cfg, !*quiet)
+	opts := renderOptions{
+		inflation:   *inflation,
+		fees:        cfg.Fees != nil,
+		empty:       cfg.EmptyLayers != nil,
+		malfeasance: cfg.Malfeasance != nil,
+		burn:        cfg.Burn != nil,
+	}
+	e := newExplorer(result, cfg.TickInterval, tickColumns(opts), simulationMilestones(cfg, result),
+		cfg.LayerClock(), newPrinter(locale, u))
+	if err := e.run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// key is a key press: a printable character, or the name of a special key.
+type key string
+
+const (
+	keyUp        key = "up"
+	keyDown      key = "down"
+	keyLeft      key = "left"
+	keyRight     key = "right"
+	keyPageUp    key = "pgup"
+	keyPageDown  key = "pgdn"
+	keyHome      key = "home"
+	keyEnd       key = "end"
+	keyEnter     key = "enter"
+	keyEscape    key = "esc"
+	keyBackspace key = "backspace"
+	keyInterrupt key = "ctrl+c"
+)
+
+// readKey reads a key press from a terminal in raw mode. Unknown escape sequences are returned as the empty key.
+func readKey(r *bufio.Reader) (key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch b {
+	case 0x1b:
+		// a lone escape arrives by itself; escape sequences arrive all at once
+		if r.Buffered() == 0 {
+			return keyEscape, nil
+		}
+		if next, _ := r.ReadByte(); next != '[' && next != 'O' {
+			return keyEscape, nil
+		}
+		// parameter bytes followed by a final byte
+		var seq []byte
+		for {
+			c, err := r.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			seq = append(seq, c)
+			if c >= 0x40 && c <= 0x7e {
+				break
+			}
+		}
+		switch string(seq) {
+		case "A":
+			return keyUp, nil
+		case "B":
+			return keyDown, nil
+		case "C":
+			return keyRight, nil
+		case "D":
+			return keyLeft, nil
+		case "5~":
+			return keyPageUp, nil
+		case "6~":
+			return keyPageDown, nil
+		case "H", "1~", "7~":
+			return keyHome, nil
+		case "F", "4~", "8~":
+			return keyEnd, nil
+		}
+		return "", nil
+	case '\r', '\n':
+		return keyEnter, nil
+	case 0x7f, 0x08:
+		return keyBackspace, nil
+	case 0x03:
+		return keyInterrupt, nil
+	}
+	_ = r.UnreadByte()
+	c, _, err := r.ReadRune()
+	return key(string(c)), err
+}
+
+// granularities are the number of ticks aggregated into each row, selected in turn; zero is one row per calendar year.
+var granularities = []int{1, 2, 4, 13, 26, 0}
+
+// frozenColumns is the number of leading columns (layer, epoch and date) that stay in view when scrolling sideways.
+const frozenColumns = 3
+
+type exploreMode int
+
+const (
+	modeBrowse exploreMode = iota
+	modeJump
+	modeColumns
+)
+
+// explorer is the state of the interactive view of a simulation. It's driven by key presses and drawn as a whole on
+// each one, so it's independent of the terminal.
+type explorer struct {
+	result       simulation.Result
+	tickInterval uint64
+	columns      []snapshotColumn
+	milestones   []milestone
+	clock        simulation.Clock
+	printer      *printer
+
+	// rows are the snapshots at the current granularity, and cells their text in the current unit
+	granularity int
+	rows        []simulation.Snapshot
+	cells       [][]string
+	widths      []int
+
+	visible []bool
+	smidge  bool
+
+	// cursor is the selected row, top the first row in view and offset the first scrollable column in view
+	cursor, top, offset int
+
+	// page is the number of rows in view, as of the last time the view was drawn
+	page int
+
+	mode   exploreMode
+	input  string
+	picked int
+	status string
+}
+
+func newExplorer(result simulation.Result, tickInterval uint64, columns []snapshotColumn,
+	milestones []milestone, clock simulation.Clock, p *printer,
+) *explorer {
+	e := &explorer{
+		result:       result,
+		tickInterval: tickInterval,
+		columns:      columns,
+		milestones:   milestones,
+		clock:        clock,
+		printer:      p,
+		visible:      make([]bool, len(columns)),
+		page:         1,
+	}
+	for i := range e.visible {
+		e.visible[i] = true
+	}
+	e.refresh()
+	return e
+}
+
+// refresh recalculates the rows and cells after a change of granularity or unit, keeping the selected layer in view.
+func (e *explorer) refresh() {
+	var layer types.Layer
+	if len(e.rows) > 0 {
+		layer = e.rows[e.cursor].Layer
+	}
+	n := granularities[e.granularity]
+	if n == 0 {
+		e.rows = e.result.Years
+	} else {
+		e.rows = e.rows[:0:0]
+		for i := 0; i < len(e.result.Ticks); i += n {
+			end := i + n
+			if end > len(e.result.Ticks) {
+				end = len(e.result.Ticks)
+			}
+			e.rows = append(e.rows, simulation.Aggregate(e.result.Ticks[i:end]))
+		}
+	}
+
+	e.cells = make([][]string, len(e.rows))
+	e.widths = make([]int, len(e.columns))
+	for j, c := range e.columns {
+		e.widths[j] = len(c.name)
+	}
+	for i, s := range e.rows {
+		e.cells[i] = make([]string, len(e.columns))
+		for j, c := range e.columns {
+			e.cells[i][j] = e.cell(c, s)
+			if w := len([]rune(e.cells[i][j])); w > e.widths[j] {
+				e.widths[j] = w
+			}
+		}
+	}
+	e.cursor = e.rowAtLayer(layer)
+}
+
+// cell returns the text of a cell. Amounts are in the unit of the printer, or in smidge.
+func (e *explorer) cell(c snapshotColumn, s simulation.Snapshot) string {
+	if c.amount != nil && e.smidge {
+		return e.printer.Sprintf("%d", c.amount(s))
+	}
+	return strings.TrimSpace(fmt.Sprint(c.cell(e.printer, s)))
+}
+
+// rowAtLayer returns the row that covers a layer, or the last row if the layer is past the end.
+func (e *explorer) rowAtLayer(layer types.Layer) int {
+	for i, s := range e.rows {
+		if s.Layer >= layer {
+			return i
+		}
+	}
+	return len(e.rows) - 1
+}
+
+// rowMilestones returns the milestones that fall within a row.
+func (e *explorer) rowMilestones(i int) []milestone {
+	var found []milestone
+	for _, m := range e.milestones {
+		if m.layer <= e.rows[i].Layer && (i == 0 || m.layer > e.rows[i-1].Layer) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+func (e *explorer) granularityName() string {
+	switch n := granularities[e.granularity]; n {
+	case 0:
+		return "calendar year"
+	case 1:
+		return e.printer.Sprintf("tick (%d layers)", e.tickInterval)
+	default:
+		return e.printer.Sprintf("%d ticks (%d layers)", n, uint64(n)*e.tickInterval)
+	}
+}
+
+// jump selects the row covering a layer, the first layer of an epoch or a date, given as "layer <n>", "epoch <n>" or
+// "date <yyyy-mm-dd>". A bare number is a layer and a bare date a date.
+func (e *explorer) jump(spec string) error {
+	kind, value, ok := strings.Cut(strings.TrimSpace(spec), " ")
+	if !ok {
+		kind, value = "layer", kind
+		if strings.Contains(value, "-") {
+			kind = "date"
+		}
+	}
+	value = strings.TrimSpace(value)
+	switch kind {
+	case "layer", "l":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid layer %q", value)
+		}
+		e.cursor = e.rowAtLayer(types.Layer(n))
+	case "epoch", "e":
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid epoch %q", value)
+		}
+		layer, _ := types.Epoch(n).FirstLayer()
+		e.cursor = e.rowAtLayer(layer)
+	case "date", "d":
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return fmt.Errorf("invalid date %q", value)
+		}
+		// the row covering the layer in progress at the start of the day; a date before genesis is the first row
+		layer, ok := simulation.LayerAt(e.clock, date)
+		if !ok && !date.Before(e.clock.LayerTime(0)) {
+			layer = e.rows[len(e.rows)-1].Layer
+		}
+		e.cursor = e.rowAtLayer(layer)
+	default:
+		return errors.New("usage: layer <n>, epoch <n> or date <yyyy-mm-dd>")
+	}
+	return nil
+}
+
+// scrollable returns the indexes of the visible columns that scroll sideways.
+func (e *explorer) scrollable() []int {
+	var indexes []int
+	for i := frozenColumns; i < len(e.columns); i++ {
+		if e.visible[i] {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+func (e *explorer) move(delta int) {
+	e.cursor += delta
+	if e.cursor >= len(e.rows) {
+		e.cursor = len(e.rows) - 1
+	}
+	if e.cursor < 0 {
+		e.cursor = 0
+	}
+}
+
+// key handles a key press. It returns true when the explorer should quit.
+func (e *explorer) key(k key) bool {
+	if k == keyInterrupt {
+		return true
+	}
+	switch e.mode {
+	case modeJump:
+		switch k {
+		case keyEnter:
+			e.mode = modeBrowse
+			if err := e.jump(e.input); err != nil {
+				e.status = err.Error()
+			}
+		case keyEscape:
+			e.mode = modeBrowse
+		case keyBackspace:
+			if r := []rune(e.input); len(r) > 0 {
+				e.input = string(r[:len(r)-1])
+			}
+		default:
+			if len([]rune(string(k))) == 1 {
+				e.input += string(k)
+			}
+		}
+		return false
+	case modeColumns:
+		switch k {
+		case keyUp, "k":
+			if e.picked > 0 {
+				e.picked--
+			}
+		case keyDown, "j":
+			if e.picked < len(e.columns)-1 {
+				e.picked++
+			}
+		case " ", keyEnter:
+			shown := 0
+			for _, v := range e.visible {
+				if v {
+					shown++
+				}
+			}
+			if !e.visible[e.picked] || shown > 1 {
+				e.visible[e.picked] = !e.visible[e.picked]
+			}
+			e.offset = 0
+		case "c", "q", keyEscape:
+			e.mode = modeBrowse
+		}
+		return false
+	}
+
+	e.status = ""
+	switch k {
+	case "q":
+		return true
+	case keyUp, "k":
+		e.move(-1)
+	case keyDown, "j":
+		e.move(1)
+	case keyPageUp:
+		e.move(-e.page)
+	case keyPageDown, " ":
+		e.move(e.page)
+	case keyHome, "g":
+		e.cursor = 0
+	case keyEnd, "G":
+		e.cursor = len(e.rows) - 1
+	case keyLeft, "h":
+		if e.offset > 0 {
+			e.offset--
+		}
+	case keyRight, "l":
+		if e.offset < len(e.scrollable())-1 {
+			e.offset++
+		}
+	case ":", "/":
+		e.mode, e.input = modeJump, ""
+	case "n", "N":
+		step := 1
+		if k == "N" {
+			step = -1
+		}
+		for i := e.cursor + step; i >= 0 && i < len(e.rows); i += step {
+			if len(e.rowMilestones(i)) > 0 {
+				e.cursor = i
+				return false
+			}
+		}
+		e.status = "no more milestones"
+	case "t", "T":
+		step := 1
+		if k == "T" {
+			step = len(granularities) - 1
+		}
+		e.granularity = (e.granularity + step) % len(granularities)
+		e.refresh()
+	case "u":
+		e.smidge = !e.smidge
+		e.refresh()
+	case "c":
+		e.mode = modeColumns
+	}
+	return false
+}
+
+// fit pads or truncates a line to the width of the terminal.
+func fit(line string, width int) string {
+	r := []rune(line)
+	if len(r) > width {
+		return string(r[:width])
+	}
+	return line + strings.Repeat(" ", width-len(r))
+}
+
+func pad(s string, width int, align text.Align) string {
+	if n := width - len([]rune(s)); n > 0 {
+		if align == text.AlignRight {
+			return strings.Repeat(" ", n) + s
+		}
+		return s + strings.Repeat(" ", n)
+	}
+	return s
+}
+
+const (
+	reverseVideo = "\x1b[7m"
+	resetVideo   = "\x1b[0m"
+	marker       = "◆"
+)
+
+// view draws the explorer on a terminal of the given size. Lines are separated by CRLF, as needed in raw mode.
+func (e *explorer) view(width, height int) string {
+	if width < 1 || height < 1 {
+		return ""
+	}
+	var lines []string
+	if e.mode == modeColumns {
+		lines = append(lines, "Columns (space to show or hide, c to return)")
+		first := 0
+		if e.picked >= height-1 {
+			first = e.picked - (height - 2)
+		}
+		for i := first; i < len(e.columns) && len(lines) < height; i++ {
+			check := " "
+			if e.visible[i] {
+				check = "x"
+			}
+			line := fit(fmt.Sprintf(" [%s] %s", check, e.columns[i].name), width)
+			if i == e.picked {
+				line = reverseVideo + line + resetVideo
+			}
+			lines = append(lines, line)
+		}
+		return strings.Join(lines, "\r\n")
+	}
+
+	// the title, the header, a line for milestones and a line for help leave the rest for rows
+	e.page = height - 4
+	if e.page < 1 {
+		e.page = 1
+	}
+	if e.cursor < e.top {
+		e.top = e.cursor
+	}
+	if e.cursor >= e.top+e.page {
+		e.top = e.cursor - e.page + 1
+	}
+
+	// frozen columns, then as many scrollable columns as fit
+	var shown []int
+	used := 2
+	for i := 0; i < frozenColumns && i < len(e.columns); i++ {
+		if e.visible[i] {
+			shown = append(shown, i)
+			used += 2 + e.widths[i]
+		}
+	}
+	scrollable := e.scrollable()
+	if e.offset >= len(scrollable) {
+		e.offset = 0
+	}
+	scrolled := 0
+	for _, i := range scrollable[e.offset:] {
+		if scrolled > 0 && used+2+e.widths[i] > width {
+			break
+		}
+		shown = append(shown, i)
+		used += 2 + e.widths[i]
+		scrolled++
+	}
+
+	unit := e.printer.unit.description()
+	if e.smidge {
+		unit = "smidge"
+	}
+	title := fmt.Sprintf("Rows: %s · Amounts: %s · Row %d of %d", e.granularityName(), unit, e.cursor+1, len(e.rows))
+	if scrolled > 0 {
+		title += fmt.Sprintf(" · Columns %d-%d of %d", e.offset+1, e.offset+scrolled, len(scrollable))
+	}
+	lines = append(lines, fit(title, width))
+
+	header := []string{" "}
+	for _, i := range shown {
+		header = append(header, pad(e.columns[i].name, e.widths[i], e.columns[i].align))
+	}
+	lines = append(lines, fit(strings.Join(header, "  "), width))
+	for r := e.top; r < len(e.rows) && r < e.top+e.page; r++ {
+		gutter := " "
+		if len(e.rowMilestones(r)) > 0 {
+			gutter = marker
+		}
+		cells := []string{gutter}
+		for _, i := range shown {
+			cells = append(cells, pad(e.cells[r][i], e.widths[i], e.columns[i].align))
+		}
+		line := fit(strings.Join(cells, "  "), width)
+		if r == e.cursor {
+			line = reverseVideo + line + resetVideo
+		}
+		lines = append(lines, line)
+	}
+	for len(lines) < height-2 {
+		lines = append(lines, "")
+	}
+
+	var notes []string
+	for _, m := range e.rowMilestones(e.cursor) {
+		notes = append(notes, fmt.Sprintf("%s at layer %d (%s)", m.name, m.layer, e.printer.date(m.date)))
+	}
+	if len(notes) > 0 {
+		lines = append(lines, fit(marker+" "+strings.Join(notes, "; "), width))
+	} else {
+		lines = append(lines, "")
+	}
+	switch {
+	case e.mode == modeJump:
+		lines = append(lines, fit("Jump to (layer <n>, epoch <n> or date <yyyy-mm-dd>): "+e.input, width))
+	case e.status != "":
+		lines = append(lines, fit(e.status, width))
+	default:
+		lines = append(lines, fit("↑↓ PgUp PgDn Home End: scroll · ←→: columns · :: jump · n/N: milestones · "+
+			"t: granularity · c: columns · u: units · q: quit", width))
+	}
+	return strings.Join(lines[:height], "\r\n")
+}
+
+// run takes over the terminal until the user quits.
+func (e *explorer) run() error {
+	in, out := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	state, err := term.MakeRaw(in)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = term.Restore(in, state) }()
+
+	// use the alternate screen, without a cursor, and leave the terminal as it was
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprint(w, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	r := bufio.NewReader(os.Stdin)
+	for {
+		width, height, err := term.GetSize(out)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, "\x1b[H\x1b[2J", e.view(width, height))
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		k, err := readKey(r)
+		if err != nil {
+			return err
+		}
+		if e.key(k) {
+			return nil
+		}
+	}
+}
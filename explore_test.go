@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func Test_ReadKey(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("\x1b[A\x1b[6~q\r\x7f\x1b[Z◆"))
+	for _, expected := range []key{keyUp, keyPageDown, "q", keyEnter, keyBackspace, "", "◆"} {
+		k, err := readKey(r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, k)
+	}
+	_, err := readKey(r)
+	assert.Error(t, err)
+}
+
+func Test_Explorer(t *testing.T) {
+	cfg := testConfig(2 * constants.OneYear)
+	result := simulation.Run(cfg, nil)
+	e := newExplorer(result, cfg.TickInterval, tickColumns(renderOptions{}), simulationMilestones(cfg, result),
+		cfg.LayerClock(), defaultPrinter())
+	require.Len(t, e.rows, len(result.Ticks))
+
+	view := e.view(200, 20)
+	lines := strings.Split(view, "\r\n")
+	require.Len(t, lines, 20)
+	assert.Contains(t, lines[0], "Rows: tick (4,032 layers)")
+	assert.Contains(t, lines[1], "layer   epoch  date        vaultNewVest")
+	// the first row covers genesis, which is a milestone
+	assert.True(t, strings.HasPrefix(lines[2], reverseVideo+"◆"))
+	assert.Contains(t, lines[18], "genesis at layer 0 (2023-07-14)")
+
+	// jump to the row covering a layer, the first layer of an epoch or a date
+	for spec, layer := range map[string]types.Layer{
+		"105120":          27 * constants.OneEpoch,
+		"layer 105120":    27 * constants.OneEpoch,
+		"epoch 26":        26 * constants.OneEpoch,
+		"date 2024-07-13": 27 * constants.OneEpoch,
+		"2024-07-13":      27 * constants.OneEpoch,
+		"l 1000000000":    cfg.EndLayer,
+		"date 2000-01-01": 0,
+		"date 9999-01-01": cfg.EndLayer,
+	} {
+		e.cursor = 0
+		for _, k := range ":" + spec {
+			e.key(key(string(k)))
+		}
+		e.key(keyEnter)
+		assert.Equal(t, layer, e.rows[e.cursor].Layer, spec)
+	}
+	e.key(":")
+	for _, k := range "105120" {
+		e.key(key(string(k)))
+	}
+	e.key(keyEnter)
+	assert.Contains(t, e.view(200, 20), "vesting cliff at layer 105120 (2024-07-13)")
+	e.key(":")
+	e.key("x")
+	e.key(keyEnter)
+	assert.Contains(t, e.view(200, 20), "invalid layer")
+
+	// milestones
+	e.key(keyHome)
+	e.key("n")
+	assert.Equal(t, types.Layer(effectiveGenesis), e.rows[e.cursor].Layer)
+
+	// coarser rows keep the same layer in view, and add up
+	e.key(keyEnd)
+	e.key("t")
+	assert.Equal(t, types.Layer(cfg.EndLayer), e.rows[e.cursor].Layer)
+	assert.Equal(t, (len(result.Ticks)+1)/2, len(e.rows))
+	e.key("T")
+	e.key("T")
+	assert.Equal(t, result.Years, e.rows)
+	assert.Contains(t, e.view(200, 20), "Rows: calendar year")
+
+	// units and columns
+	last := result.Ticks[len(result.Ticks)-1]
+	p := message.NewPrinter(language.English)
+	assert.Contains(t, e.view(400, 20), p.Sprintf("  %d", last.SubsidyTotal/constants.OneSmesh))
+	e.key("u")
+	assert.Contains(t, e.view(400, 20), p.Sprintf("  %d", last.SubsidyTotal))
+	e.key("c")
+	for i := 0; i < 3; i++ {
+		e.key(keyDown)
+	}
+	assert.Contains(t, e.view(200, 20), reverseVideo+" [x] vaultNewVest")
+	e.key(" ")
+	e.key(keyEscape)
+	assert.NotContains(t, e.view(400, 20), "vaultNewVest")
+
+	assert.True(t, e.key("q"))
+}
@@ -0,0 +1,160 @@
+// Package fees models the transaction fees paid to smeshers in each layer, on top of the block subsidy. A fee model is
+// either parametric, e.g., a constant amount per layer that grows over time, or read from a file of observed or
+// projected per-layer fee totals.
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Model returns the total fees, in smidge, paid in a layer. Layers are counted post-genesis.
+type Model interface {
+	FeesAtLayer(layerID types.Layer) uint64
+}
+
+// None is the model in which no fees are paid.
+type None struct{}
+
+func (None) FeesAtLayer(types.Layer) uint64 { return 0 }
+
+// Constant pays the same amount in every layer.
+type Constant uint64
+
+func (c Constant) FeesAtLayer(types.Layer) uint64 { return uint64(c) }
+
+// Linear pays Start in the genesis layer and adds Increase every layer thereafter. A negative increase is allowed,
+// and fees bottom out at zero.
+type Linear struct {
+	Start    uint64
+	Increase float64
+}
+
+func (l Linear) FeesAtLayer(layerID types.Layer) uint64 {
+	return clamp(float64(l.Start) + l.Increase*float64(layerID))
+}
+
+// Exponential pays Start in the genesis layer and grows continuously at the annual rate Growth thereafter, e.g., a
+// growth of 0.5 means fees per layer are 50% higher one year later. A negative growth is allowed.
+type Exponential struct {
+	Start  uint64
+	Growth float64
+}
+
+func (e Exponential) FeesAtLayer(layerID types.Layer) uint64 {
+	years := float64(layerID) / constants.OneYear
+	return clamp(float64(e.Start) * math.Pow(1+e.Growth, years))
+}
+
+// clamp rounds down a fee amount to the nearest smidge and bounds it to the representable range.
+func clamp(fees float64) uint64 {
+	switch {
+	case fees <= 0 || math.IsNaN(fees):
+		return 0
+	case fees >= math.MaxUint64:
+		return math.MaxUint64
+	default:
+		return uint64(fees)
+	}
+}
+
+// Record is the total fees paid in a single layer.
+type Record struct {
+	Layer types.Layer
+	Fees  uint64
+}
+
+// Table pays the fees recorded for each layer, and nothing in layers that have no record.
+type Table struct {
+	records []Record
+}
+
+// NewTable returns a table of the given records. Each layer may appear at most once.
+func NewTable(records []Record) (*Table, error) {
+	sorted, err := keyed.Sort(records, func(a, b Record) bool { return a.Layer < b.Layer }, func(r Record) error {
+		return fmt.Errorf("duplicate fee record for layer %d", r.Layer)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Table{records: sorted}, nil
+}
+
+func (t *Table) FeesAtLayer(layerID types.Layer) uint64 {
+	i := sort.Search(len(t.records), func(i int) bool { return t.records[i].Layer >= layerID })
+	if i < len(t.records) && t.records[i].Layer == layerID {
+		return t.records[i].Fees
+	}
+	return 0
+}
+
+// ReadCSV reads a table of per-layer fee totals from CSV with a header row and the columns layer and fees (in smidge).
+func ReadCSV(r io.Reader) (*Table, error) {
+	records, err := keyed.ReadCSV(r, keyed.Layer, keyed.Column[uint64]{Name: "fees", Parse: keyed.Uint},
+		func(layerID types.Layer, fees uint64) Record { return Record{Layer: layerID, Fees: fees} })
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(records)
+}
+
+// Parse returns the fee model described by a scenario, one of:
+//
+//	none
+//	constant:<smidge per layer>
+//	linear:<smidge in genesis layer>:<smidge added per layer>
+//	exponential:<smidge in genesis layer>:<annual growth rate, e.g., 0.1>
+//	file:<path to CSV of per-layer fees>
+func Parse(scenario string) (Model, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	params := strings.Split(args, ":")
+	switch kind {
+	case "none":
+		return None{}, nil
+	case "constant":
+		if len(params) != 1 {
+			return nil, errors.New("usage: constant:<smidge per layer>")
+		}
+		fees, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fees: %w", err)
+		}
+		return Constant(fees), nil
+	case "linear", "exponential":
+		if len(params) != 2 {
+			return nil, fmt.Errorf("usage: %s:<smidge in genesis layer>:<rate>", kind)
+		}
+		start, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid starting fees: %w", err)
+		}
+		rate, err := strconv.ParseFloat(params[1], 64)
+		if err != nil || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("invalid rate %q", params[1])
+		}
+		if kind == "linear" {
+			return Linear{Start: start, Increase: rate}, nil
+		}
+		if rate <= -1 {
+			return nil, errors.New("annual growth rate must be greater than -1")
+		}
+		return Exponential{Start: start, Growth: rate}, nil
+	case "file":
+		table, err := keyed.Open(args, ReadCSV)
+		if err != nil {
+			return nil, err
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("unknown fee scenario %q", scenario)
+	}
+}
@@ -0,0 +1,78 @@
+package fees
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parametric(t *testing.T) {
+	assert.Equal(t, uint64(0), None{}.FeesAtLayer(100))
+	assert.Equal(t, uint64(7), Constant(7).FeesAtLayer(100))
+
+	linear := Linear{Start: 1000, Increase: 0.5}
+	assert.Equal(t, uint64(1000), linear.FeesAtLayer(0))
+	assert.Equal(t, uint64(1050), linear.FeesAtLayer(100))
+	assert.Equal(t, uint64(0), Linear{Start: 1000, Increase: -1}.FeesAtLayer(2000), "fees bottom out at zero")
+
+	exponential := Exponential{Start: 1000, Growth: 1}
+	assert.Equal(t, uint64(1000), exponential.FeesAtLayer(0))
+	assert.Equal(t, uint64(2000), exponential.FeesAtLayer(constants.OneYear))
+	assert.Equal(t, uint64(8000), exponential.FeesAtLayer(3*constants.OneYear))
+	assert.Equal(t, uint64(math.MaxUint64), exponential.FeesAtLayer(types.MaxLayer), "fees saturate")
+}
+
+func Test_ReadCSV(t *testing.T) {
+	table, err := ReadCSV(strings.NewReader("layer,fees\n10,100\n5, 50\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(50), table.FeesAtLayer(5))
+	assert.Equal(t, uint64(100), table.FeesAtLayer(10))
+	assert.Equal(t, uint64(0), table.FeesAtLayer(7), "layers without a record pay no fees")
+	assert.Equal(t, uint64(0), table.FeesAtLayer(11))
+
+	for name, input := range map[string]string{
+		"bad header":   "layer,amount\n1,2\n",
+		"bad layer":    "layer,fees\n-1,2\n",
+		"bad fees":     "layer,fees\n1,x\n",
+		"extra column": "layer,fees\n1,2,3\n",
+		"duplicate":    "layer,fees\n1,2\n1,3\n",
+		"empty":        "",
+	} {
+		_, err := ReadCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.csv")
+	require.NoError(t, os.WriteFile(path, []byte("layer,fees\n3,30\n"), 0o600))
+
+	for scenario, expected := range map[string]Model{
+		"none":               None{},
+		"constant:5":         Constant(5),
+		"linear:5:0.25":      Linear{Start: 5, Increase: 0.25},
+		"linear:5:-1":        Linear{Start: 5, Increase: -1},
+		"exponential:5:0.1":  Exponential{Start: 5, Growth: 0.1},
+		"exponential:5:-0.5": Exponential{Start: 5, Growth: -0.5},
+		"file:" + path:       &Table{records: []Record{{Layer: 3, Fees: 30}}},
+	} {
+		model, err := Parse(scenario)
+		require.NoError(t, err, scenario)
+		assert.Equal(t, expected, model, scenario)
+	}
+
+	for _, scenario := range []string{
+		"", "free", "constant", "constant:-1", "constant:1:2", "linear:1", "linear:x:1", "linear:1:NaN",
+		"exponential:1:-1", "exponential:1:Inf", "file:", "file:" + filepath.Join(t.TempDir(), "missing.csv"),
+	} {
+		_, err := Parse(scenario)
+		assert.Error(t, err, scenario)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// unit is the unit of amounts in the table: smidge, SMESH or thousands of SMESH, shown with a number of decimals. The
+// zero value is whole SMESH.
+type unit struct {
+	// scale is the power of ten of the unit in SMESH: -9 for smidge, 0 for SMESH and 3 for thousands of SMESH
+	scale int
+
+	// decimals is the number of decimals shown; further digits are dropped, i.e., amounts are rounded down
+	decimals int
+}
+
+var unitScales = map[string]int{"smidge": -9, "smesh": 0, "ksmesh": 3}
+
+// parseUnit parses a unit given as a name, optionally followed by a colon and the number of decimals, e.g., "smesh:4".
+func parseUnit(spec string) (unit, error) {
+	name, decimals, hasDecimals := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), ":")
+	scale, ok := unitScales[name]
+	if !ok {
+		return unit{}, fmt.Errorf("unknown unit %q; the units are smidge, smesh and ksmesh", name)
+	}
+	u := unit{scale: scale}
+	if hasDecimals {
+		n, err := strconv.Atoi(decimals)
+		if err != nil || n < 0 || n > scale+9 {
+			return unit{}, fmt.Errorf("invalid number of decimals %q for %s: must be from 0 to %d", decimals, name,
+				scale+9)
+		}
+		u.decimals = n
+	}
+	return u, nil
+}
+
+func (u unit) String() string {
+	name := "smesh"
+	switch u.scale {
+	case -9:
+		name = "smidge"
+	case 3:
+		name = "ksmesh"
+	}
+	if u.decimals > 0 {
+		return fmt.Sprintf("%s:%d", name, u.decimals)
+	}
+	return name
+}
+
+// description returns the unit as shown in the notes below a table, e.g., "SMESH (rounded down)".
+func (u unit) description() string {
+	var name string
+	switch u.scale {
+	case -9:
+		return "smidge"
+	case 0:
+		name = "SMESH"
+	default:
+		name = "thousands of SMESH"
+	}
+	if u.decimals > 0 {
+		return fmt.Sprintf("%s to %d decimals (rounded down)", name, u.decimals)
+	}
+	return name + " (rounded down)"
+}
+
+// dateLayouts are the usual numeric date formats of some languages and regions, by language and then by region. Other
+// locales use ISO 8601, as does the default.
+var dateLayouts = map[string]map[string]string{
+	"en": {"": "02/01/2006", "US": "01/02/2006", "CA": "2006-01-02"},
+	"de": {"": "02.01.2006"},
+	"es": {"": "02/01/2006"},
+	"fr": {"": "02/01/2006", "CA": "2006-01-02"},
+	"it": {"": "02/01/2006"},
+	"ja": {"": "2006/01/02"},
+	"ko": {"": "2006. 01. 02."},
+	"nl": {"": "02-01-2006"},
+	"pl": {"": "02.01.2006"},
+	"pt": {"": "02/01/2006"},
+	"ru": {"": "02.01.2006"},
+	"tr": {"": "02.01.2006"},
+	"uk": {"": "02.01.2006"},
+	"zh": {"": "2006/01/02"},
+}
+
+// dateLayout returns the date format of a locale.
+func dateLayout(tag language.Tag) string {
+	if tag == language.Und {
+		return "2006-01-02"
+	}
+	base, _ := tag.Base()
+	layouts, ok := dateLayouts[base.String()]
+	if !ok {
+		return "2006-01-02"
+	}
+	region, _ := tag.Region()
+	if layout, ok := layouts[region.String()]; ok {
+		return layout
+	}
+	return layouts[""]
+}
+
+// parseLocale parses a locale given as a BCP 47 language tag, e.g., "de" or "en-GB". An empty locale is the default:
+// English numbers and ISO 8601 dates.
+func parseLocale(spec string) (language.Tag, error) {
+	if spec == "" {
+		return language.Und, nil
+	}
+	tag, err := language.Parse(spec)
+	if err != nil {
+		return language.Und, fmt.Errorf("invalid locale %q: %w", spec, err)
+	}
+	return tag, nil
+}
+
+// printer formats the cells of the table output: numbers and dates for a locale, and amounts in a unit.
+type printer struct {
+	*message.Printer
+	unit unit
+
+	// dates is the date layout of the locale
+	dates string
+
+	// digits, point and minus are the digits, decimal separator and minus sign of the locale
+	digits [10]string
+	point  string
+	minus  string
+}
+
+func newPrinter(tag language.Tag, u unit) *printer {
+	p := &printer{Printer: message.NewPrinter(language.English), unit: u, dates: dateLayout(tag)}
+	if tag != language.Und {
+		p.Printer = message.NewPrinter(tag)
+	}
+	// the symbols of the locale are as the printer formats small numbers, in the locale's own digits
+	for i := range p.digits {
+		p.digits[i] = p.Sprintf("%d", i)
+	}
+	p.point = strings.TrimSuffix(strings.TrimPrefix(p.Sprintf("%.1f", 0.5), p.digits[0]), p.digits[5])
+	p.minus = strings.TrimSuffix(p.Sprintf("%d", -1), p.digits[1])
+	return p
+}
+
+// date formats a date.
+func (p *printer) date(t time.Time) string {
+	return t.Format(p.dates)
+}
+
+// localDigits replaces the decimal digits of a string with those of the locale.
+func (p *printer) localDigits(digits string) string {
+	var b strings.Builder
+	for _, r := range digits {
+		if r >= '0' && r <= '9' {
+			b.WriteString(p.digits[r-'0'])
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// decimal formats a non-negative fixed point number, given as an integer and the number of its decimals, with the
+// integer part grouped.
+func (p *printer) decimal(n *big.Int, decimals int) string {
+	digits := n.String()
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	integer, fraction := digits[:len(digits)-decimals], digits[len(digits)-decimals:]
+	if i, err := strconv.ParseUint(integer, 10, 64); err == nil {
+		integer = p.Sprintf("%d", i)
+	} else {
+		integer = p.localDigits(integer)
+	}
+	if decimals == 0 {
+		return integer
+	}
+	return integer + p.point + p.localDigits(fraction)
+}
+
+// amount formats an amount in smidge in the unit, padded to a width.
+func (p *printer) amount(smidge uint64, width int) string {
+	// drop the digits past the decimals shown
+	drop := p.unit.scale + 9 - p.unit.decimals
+	n := new(big.Int).SetUint64(smidge)
+	n.Quo(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(drop)), nil))
+	return fmt.Sprintf("%*s", width, p.decimal(n, p.unit.decimals))
+}
+
+// percent formats num/denom as a percentage with two decimals, rounded exactly to nearest with ties to even, and padded
+// to a width. It returns n/a if the denominator is zero.
+func (p *printer) percent(num, denom uint64, width int) string {
+	if denom == 0 {
+		return fmt.Sprintf("%*s", width, "n/a")
+	}
+	d := new(big.Int).SetUint64(denom)
+	q, r := new(big.Int).QuoRem(new(big.Int).Mul(new(big.Int).SetUint64(num), big.NewInt(10_000)), d, new(big.Int))
+	switch r.Lsh(r, 1).Cmp(d) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return fmt.Sprintf("%*s", width, p.decimal(q, 2)+"%")
+}
+
+// rate formats a rate with two decimals, as a percentage if isPct, rounded to nearest and padded to a width.
+func (p *printer) rate(r float64, isPct bool, width int) string {
+	suffix := ""
+	if isPct {
+		r *= 100
+		suffix = "%"
+	}
+	if math.IsNaN(r) || math.IsInf(r, 0) {
+		return fmt.Sprintf("%*s", width, "n/a")
+	}
+	n, _ := big.NewFloat(math.RoundToEven(math.Abs(r) * 100)).Int(nil)
+	s := p.decimal(n, 2)
+	if r < 0 && n.Sign() != 0 {
+		s = p.minus + s
+	}
+	return fmt.Sprintf("%*s", width, s+suffix)
+}
+
+// defaultPrinter returns the printer of the default format: whole SMESH, English numbers and ISO 8601 dates.
+func defaultPrinter() *printer {
+	return newPrinter(language.Und, unit{})
+}
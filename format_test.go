@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/vesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func Test_Units(t *testing.T) {
+	for spec, expected := range map[string]unit{
+		"smidge":   {scale: -9},
+		"smesh":    {},
+		"SMESH:4":  {decimals: 4},
+		"ksmesh":   {scale: 3},
+		"ksmesh:2": {scale: 3, decimals: 2},
+		"smesh:9":  {decimals: 9},
+	} {
+		u, err := parseUnit(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, u, spec)
+		assert.Equal(t, strings.ToLower(spec), u.String())
+	}
+	for _, spec := range []string{"", "coins", "smesh:10", "smidge:1", "smesh:-1", "ksmesh:x"} {
+		_, err := parseUnit(spec)
+		assert.Error(t, err, spec)
+	}
+
+	amount := uint64(1_234_567_891_234_567_891)
+	for spec, expected := range map[string]string{
+		"smidge":   "1,234,567,891,234,567,891",
+		"smesh":    "1,234,567,891",
+		"smesh:4":  "1,234,567,891.2345",
+		"smesh:9":  "1,234,567,891.234567891",
+		"ksmesh":   "1,234,567",
+		"ksmesh:2": "1,234,567.89",
+	} {
+		u, err := parseUnit(spec)
+		require.NoError(t, err)
+		assert.Equal(t, expected, newPrinter(language.Und, u).amount(amount, 0), spec)
+	}
+	u, err := parseUnit("smesh:3")
+	require.NoError(t, err)
+	p := newPrinter(language.Und, u)
+	assert.Equal(t, "      0.001", p.amount(constants.OneSmesh/1000, 11))
+	assert.Equal(t, "0.000", p.amount(constants.OneSmesh/1000-1, 0))
+	assert.Equal(t, "SMESH to 3 decimals (rounded down)", u.description())
+
+	// percentages are rounded exactly, with ties to even
+	p = defaultPrinter()
+	assert.Equal(t, "   6.25%", p.percent(1, 16, 8))
+	assert.Equal(t, "  33.33%", p.percent(1, 3, 8))
+	assert.Equal(t, "  66.67%", p.percent(2, 3, 8))
+	assert.Equal(t, "0.12%", p.percent(1, 800, 0))
+	assert.Equal(t, "0.38%", p.percent(3, 800, 0))
+	assert.Equal(t, "0.13%", p.percent(1_000_000_000_000_001, 800_000_000_000_000_000, 0))
+	assert.Equal(t, "100.00%", p.percent(constants.TotalIssuance, constants.TotalIssuance, 0))
+	assert.Equal(t, "n/a", p.percent(1, 0, 0))
+}
+
+func Test_Locale(t *testing.T) {
+	date := time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC)
+	u, err := parseUnit("smesh:2")
+	require.NoError(t, err)
+	for spec, expected := range map[string][3]string{
+		"":      {"2023-07-14", "1,234,567.89", "33.33%"},
+		"en":    {"07/14/2023", "1,234,567.89", "33.33%"},
+		"en-GB": {"14/07/2023", "1,234,567.89", "33.33%"},
+		"de":    {"14.07.2023", "1.234.567,89", "33,33%"},
+		"fr-CA": {"2023-07-14", "1 234 567,89", "33,33%"},
+		"ja":    {"2023/07/14", "1,234,567.89", "33.33%"},
+		"sw":    {"2023-07-14", "1,234,567.89", "33.33%"},
+		"ar":    {"2023-07-14", "١٬٢٣٤٬٥٦٧٫٨٩", "٣٣٫٣٣%"},
+		"fa":    {"2023-07-14", "۱٬۲۳۴٬۵۶۷٫۸۹", "۳۳٫۳۳%"},
+		"bn":    {"2023-07-14", "১২,৩৪,৫৬৭.৮৯", "৩৩.৩৩%"},
+	} {
+		tag, err := parseLocale(spec)
+		require.NoError(t, err)
+		p := newPrinter(tag, u)
+		assert.Equal(t, expected[0], p.date(date), spec)
+		assert.Equal(t, expected[1], p.amount(1_234_567_891_234_567, 0), spec)
+		assert.Equal(t, expected[2], p.percent(1, 3, 0), spec)
+	}
+	// the decimals of an amount are in the digits of the locale, as is the integer part
+	tag, err := parseLocale("ar")
+	require.NoError(t, err)
+	assert.Equal(t, "٤٧٧٫٦١", newPrinter(tag, u).amount(477_610_000_000, 0))
+
+	// rates are rounded to nearest and signed as in the locale
+	for spec, expected := range map[string][2]string{
+		"":   {"  6.25%", "-0.50"},
+		"de": {"  6,25%", "-0,50"},
+		"ar": {"  ٦٫٢٥%", "\u061c-٠٫٥٠"},
+	} {
+		tag, err := parseLocale(spec)
+		require.NoError(t, err)
+		p := newPrinter(tag, u)
+		assert.Equal(t, expected[0], p.rate(0.0625, true, 7), spec)
+		assert.Equal(t, expected[1], p.rate(-0.5, false, 0), spec)
+	}
+	assert.Equal(t, "0.00", defaultPrinter().rate(-0.00001, false, 0))
+	assert.Equal(t, "n/a", defaultPrinter().rate(math.Inf(1), true, 0))
+
+	_, err = parseLocale("not a locale")
+	assert.Error(t, err)
+
+	// the table and its notes follow the options; the machine readable formats don't
+	tag, err = parseLocale("de")
+	require.NoError(t, err)
+	opts := renderOptions{unit: u, locale: tag}
+	result := simulation.Result{Ticks: []simulation.Snapshot{{
+		Layer:            8064,
+		Epoch:            2,
+		Date:             date,
+		SubsidyPerLayer:  477_192_540_173,
+		SubsidyNew:       477_192_540_173,
+		SubsidyTotal:     477_192_540_173,
+		CirculatingTotal: 477_192_540_173,
+		VaultTotal:       vesting.Mainnet.TotalVaulted,
+		IssuanceTotal:    vesting.Mainnet.TotalVaulted + 477_192_540_173,
+	}}}
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatTable, opts, result))
+	assert.Contains(t, buf.String(), "| 14.07.2023 |")
+	assert.Contains(t, buf.String(), "| 150.000.000,00 |")
+	assert.Contains(t, buf.String(), "|          477,19 |")
+	assert.Contains(t, buf.String(), "|  100,00% |")
+	assert.Contains(t, buf.String(), "- All figures in SMESH to 2 decimals (rounded down)")
+	buf.Reset()
+	require.NoError(t, render(&buf, formatCSV, opts, result))
+	assert.Contains(t, buf.String(), "8064,2,2023-07-14,0,0,0,150000000000000000,477192540173,")
+}
@@ -0,0 +1,354 @@
+// Package formula evaluates arithmetic expressions over named values with exact decimal arithmetic, for columns derived
+// from the simulation output, e.g., "circulatingTotal - vaultTotalVest" or "round(smesh(subsidyPerLayer * 4032), 4)".
+//
+// Expressions are made of decimal numbers, variables, the operators + - * / with the usual precedence, parentheses,
+// and the functions:
+//
+//   - abs(x): the absolute value of x
+//   - min(x, y, ...) and max(x, y, ...): the least and greatest of their arguments
+//   - round(x, n): x rounded to n decimals, with ties away from zero
+//   - floor(x): the greatest integer not greater than x
+//   - smesh(x): an amount x in smidge converted to SMESH
+//
+// A formula is undefined if any of its variables is undefined, or if it divides by zero.
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spacemeshos/economics/constants"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// Ctx is the context of all arithmetic. Its precision of 34 digits is exact for sums and differences of amounts.
+var Ctx = decimal.Context128
+
+// Vars are the values of the variables of a formula; a variable that's missing, or nil, is undefined.
+type Vars map[string]*decimal.Big
+
+// node is a node of a parsed expression. eval returns false if the value is undefined.
+type node interface {
+	eval(vars Vars) (*decimal.Big, bool)
+}
+
+type number struct {
+	value *decimal.Big
+}
+
+func (n number) eval(Vars) (*decimal.Big, bool) {
+	return n.value, true
+}
+
+type variable struct {
+	name string
+}
+
+func (v variable) eval(vars Vars) (*decimal.Big, bool) {
+	value := vars[v.name]
+	return value, value != nil
+}
+
+type negation struct {
+	x node
+}
+
+func (n negation) eval(vars Vars) (*decimal.Big, bool) {
+	x, ok := n.x.eval(vars)
+	if !ok {
+		return nil, false
+	}
+	return decimal.WithContext(Ctx).Neg(x), true
+}
+
+type binary struct {
+	op   byte
+	x, y node
+}
+
+func (b binary) eval(vars Vars) (*decimal.Big, bool) {
+	x, ok := b.x.eval(vars)
+	if !ok {
+		return nil, false
+	}
+	y, ok := b.y.eval(vars)
+	if !ok {
+		return nil, false
+	}
+	z := decimal.WithContext(Ctx)
+	switch b.op {
+	case '+':
+		return Ctx.Add(z, x, y), true
+	case '-':
+		return Ctx.Sub(z, x, y), true
+	case '*':
+		return Ctx.Mul(z, x, y), true
+	default:
+		if y.Sign() == 0 {
+			return nil, false
+		}
+		return Ctx.Quo(z, x, y), true
+	}
+}
+
+// function is a built-in function, with the number of arguments it takes (or -1 for at least one).
+type function struct {
+	args int
+	eval func(args []*decimal.Big) (*decimal.Big, bool)
+}
+
+var oneSmesh = decimal.WithContext(Ctx).SetUint64(constants.OneSmesh)
+
+var functions = map[string]function{
+	"abs": {1, func(args []*decimal.Big) (*decimal.Big, bool) {
+		return decimal.WithContext(Ctx).Abs(args[0]), true
+	}},
+	"min": {-1, func(args []*decimal.Big) (*decimal.Big, bool) {
+		least := args[0]
+		for _, x := range args[1:] {
+			if x.Cmp(least) < 0 {
+				least = x
+			}
+		}
+		return least, true
+	}},
+	"max": {-1, func(args []*decimal.Big) (*decimal.Big, bool) {
+		greatest := args[0]
+		for _, x := range args[1:] {
+			if x.Cmp(greatest) > 0 {
+				greatest = x
+			}
+		}
+		return greatest, true
+	}},
+	"round": {2, func(args []*decimal.Big) (*decimal.Big, bool) {
+		n, ok := args[1].Int64()
+		if !ok || !args[1].IsInt() || n < 0 || n > 18 {
+			return nil, false
+		}
+		ctx := Ctx
+		ctx.RoundingMode = decimal.ToNearestAway
+		return ctx.Quantize(decimal.WithContext(ctx).Copy(args[0]), int(n)), true
+	}},
+	"floor": {1, func(args []*decimal.Big) (*decimal.Big, bool) {
+		return Ctx.Floor(decimal.WithContext(Ctx), args[0]), true
+	}},
+	"smesh": {1, func(args []*decimal.Big) (*decimal.Big, bool) {
+		return Ctx.Quo(decimal.WithContext(Ctx), args[0], oneSmesh), true
+	}},
+}
+
+type call struct {
+	name string
+	args []node
+}
+
+func (c call) eval(vars Vars) (*decimal.Big, bool) {
+	args := make([]*decimal.Big, len(c.args))
+	for i, arg := range c.args {
+		value, ok := arg.eval(vars)
+		if !ok {
+			return nil, false
+		}
+		args[i] = value
+	}
+	return functions[c.name].eval(args)
+}
+
+// Formula is a parsed expression.
+type Formula struct {
+	source string
+	root   node
+	vars   []string
+}
+
+func (f *Formula) String() string {
+	return f.source
+}
+
+// Vars returns the names of the variables the formula refers to, in order of first appearance.
+func (f *Formula) Vars() []string {
+	return f.vars
+}
+
+// Eval returns the value of the formula, or false if it's undefined.
+func (f *Formula) Eval(vars Vars) (*decimal.Big, bool) {
+	return f.root.eval(vars)
+}
+
+// parser is a recursive descent parser over the source of a formula.
+type parser struct {
+	source string
+	pos    int
+	vars   []string
+}
+
+// Parse parses a formula.
+func Parse(source string) (*Formula, error) {
+	p := &parser{source: source}
+	root, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.skipSpace(); p.pos < len(p.source) {
+		return nil, p.errorf("unexpected %q", p.source[p.pos])
+	}
+	return &Formula{source: source, root: root, vars: p.vars}, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("formula %q at offset %d: %s", p.source, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.source) {
+		r, size := utf8.DecodeRuneInString(p.source[p.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		p.pos += size
+	}
+}
+
+// peek returns the next character, or zero at the end.
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos == len(p.source) {
+		return 0
+	}
+	return p.source[p.pos]
+}
+
+// expression := term { ("+" | "-") term }
+func (p *parser) expression() (node, error) {
+	x, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		y, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		x = binary{op, x, y}
+	}
+	return x, nil
+}
+
+// term := unary { ("*" | "/") unary }
+func (p *parser) term() (node, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = binary{op, x, y}
+	}
+	return x, nil
+}
+
+// unary := "-" unary | primary
+func (p *parser) unary() (node, error) {
+	if p.peek() == '-' {
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return negation{x}, nil
+	}
+	return p.primary()
+}
+
+func isIdentifier(c byte, first bool) bool {
+	return c == '_' || unicode.IsLetter(rune(c)) || !first && unicode.IsDigit(rune(c))
+}
+
+// primary := number | variable | function "(" expression { "," expression } ")" | "(" expression ")"
+func (p *parser) primary() (node, error) {
+	c := p.peek()
+	start := p.pos
+	switch {
+	case c == 0:
+		return nil, p.errorf("unexpected end")
+	case c == '(':
+		p.pos++
+		x, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected )")
+		}
+		p.pos++
+		return x, nil
+	case c == '.' || unicode.IsDigit(rune(c)):
+		for p.pos < len(p.source) && (p.source[p.pos] == '.' || p.source[p.pos] == '_' ||
+			unicode.IsDigit(rune(p.source[p.pos]))) {
+			p.pos++
+		}
+		literal := p.source[start:p.pos]
+		value, ok := decimal.WithContext(Ctx).SetString(strings.ReplaceAll(literal, "_", ""))
+		if !ok || strings.Count(literal, ".") > 1 {
+			p.pos = start
+			return nil, p.errorf("invalid number %q", literal)
+		}
+		return number{value}, nil
+	case isIdentifier(c, true):
+		for p.pos < len(p.source) && isIdentifier(p.source[p.pos], false) {
+			p.pos++
+		}
+		name := p.source[start:p.pos]
+		if p.peek() != '(' {
+			p.addVar(name)
+			return variable{name}, nil
+		}
+		f, ok := functions[name]
+		if !ok {
+			p.pos = start
+			return nil, p.errorf("unknown function %q", name)
+		}
+		p.pos++
+		var args []node
+		for {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+		if p.peek() != ')' {
+			return nil, p.errorf("expected , or )")
+		}
+		p.pos++
+		if f.args >= 0 && len(args) != f.args {
+			p.pos = start
+			return nil, p.errorf("%s takes %d arguments, got %d", name, f.args, len(args))
+		}
+		return call{name, args}, nil
+	default:
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *parser) addVar(name string) {
+	for _, v := range p.vars {
+		if v == name {
+			return
+		}
+	}
+	p.vars = append(p.vars, name)
+}
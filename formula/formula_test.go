@@ -0,0 +1,91 @@
+package formula
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func eval(t *testing.T, source string, vars Vars) (string, bool) {
+	f, err := Parse(source)
+	require.NoError(t, err, source)
+	value, ok := f.Eval(vars)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("%f", value), true
+}
+
+func Test_Eval(t *testing.T) {
+	vars := Vars{
+		"circulatingTotal": decimal.WithContext(Ctx).SetUint64(600_000_000_000_000_000),
+		"vaultTotalVest":   decimal.WithContext(Ctx).SetUint64(150_000_000_000_000_000),
+		"subsidyPerLayer":  decimal.WithContext(Ctx).SetUint64(382094799184),
+		"pct":              decimal.WithContext(Ctx).SetFloat64(12.5),
+	}
+	for source, expected := range map[string]string{
+		"1 + 2 * 3":                         "7",
+		"(1 + 2) * 3":                       "9",
+		"10 - 4 - 3":                        "3",
+		"2 * -3":                            "-6",
+		"--2":                               "2",
+		"1 / 4":                             "0.25",
+		"1 / 3":                             "0.3333333333333333333333333333333333",
+		"1_000 * 2.5":                       "2500.0",
+		"circulatingTotal - vaultTotalVest": "450000000000000000",
+		"smesh(circulatingTotal - vaultTotalVest)": "450000000",
+		"round(smesh(subsidyPerLayer * 4032), 4)":  "1540606.2303",
+		"round(smesh(subsidyPerLayer), 0)":         "382",
+		"round(2.5, 0) + round(-2.5, 0)":           "0",
+		"round(0.125, 2)":                          "0.13",
+		"floor(smesh(subsidyPerLayer))":            "382",
+		"floor(-0.5)":                              "-1",
+		"abs(vaultTotalVest - circulatingTotal)":   "450000000000000000",
+		"min(3, 1, 2) + max(3, 1, 2)":              "4",
+		"pct / 100":                                "0.125",
+		"circulatingTotal * 2 + 1":                 "1200000000000000001",
+		"  smesh ( circulatingTotal ) / 1000000  ": "600",
+	} {
+		value, ok := eval(t, source, vars)
+		assert.True(t, ok, source)
+		assert.Equal(t, expected, value, source)
+	}
+
+	// undefined variables and division by zero
+	for _, source := range []string{"missing + 1", "1 / (pct - pct)", "round(1, -1)", "round(1, 0.5)", "min(1, missing)"} {
+		_, ok := eval(t, source, vars)
+		assert.False(t, ok, source)
+	}
+	_, ok := eval(t, "pct", Vars{"pct": nil})
+	assert.False(t, ok)
+}
+
+func Test_Parse(t *testing.T) {
+	f, err := Parse("a + b * a - round(c, 2)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, f.Vars())
+	assert.Equal(t, "a + b * a - round(c, 2)", f.String())
+	f, err = Parse("\ta +\n\u00a0b ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, f.Vars())
+
+	for source, msg := range map[string]string{
+		"":             "unexpected end",
+		"1 +":          "unexpected end",
+		"(1":           "expected )",
+		"1 2":          `unexpected '2'`,
+		"1.2.3":        `invalid number "1.2.3"`,
+		"sqrt(2)":      `unknown function "sqrt"`,
+		"abs(1, 2)":    "abs takes 1 arguments, got 2",
+		"round(1":      "expected , or )",
+		"a % b":        `unexpected '%'`,
+		"max()":        `unexpected ')'`,
+		"circulating#": `unexpected '#'`,
+	} {
+		_, err := Parse(source)
+		assert.ErrorContains(t, err, msg, source)
+	}
+}
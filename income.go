@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/income"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// incomeCommand projects a smesher's expected rewards per epoch.
+func incomeCommand(args []string) {
+	fs := flag.NewFlagSet("income", flag.ExitOnError)
+	config := incomeFlags(fs, 26)
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	_ = fs.Parse(args)
+
+	projection, err := income.Project(config())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := renderIncome(os.Stdout, *format, projection); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// incomeFlags registers the flags describing a smesher, the network and the horizon of a projection, and returns a
+// function that builds the projection config once the flags are parsed.
+func incomeFlags(fs *flag.FlagSet, defaultEpochs uint64) func() income.Config {
+	units := fs.Uint64("units", 0, "smesher's space units (required)")
+	network := fs.String("network", "", "total network space scenario (required): constant:<space units>, "+
+		"linear:<space units>:<space units per epoch>, exponential:<space units>:<annual growth> or file:<csv path>")
+	feeScenario := fs.String("fees", "", "fee scenario, as for the simulator")
+	firstEpoch := fs.Uint64("from", uint64(types.Layer(effectiveGenesis).Epoch()), "first epoch to project")
+	epochs := fs.Uint64("epochs", defaultEpochs, "number of epochs to project (26 epochs is about one year)")
+
+	return func() income.Config {
+		cfg := income.Config{
+			Genesis:          defaultGenesisDate,
+			EffectiveGenesis: effectiveGenesis,
+			SpaceUnits:       *units,
+			FirstEpoch:       types.Epoch(*firstEpoch),
+			Epochs:           *epochs,
+		}
+		if *network == "" {
+			log.Fatal("missing network space scenario")
+		}
+		var err error
+		if cfg.Network, err = income.Parse(*network); err != nil {
+			log.Fatal(err)
+		}
+		if *feeScenario != "" {
+			if cfg.Fees, err = fees.Parse(*feeScenario); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return cfg
+	}
+}
+
+func incomeColumns() []column[income.Epoch] {
+	return []column[income.Epoch]{
+		{
+			name:  "epoch",
+			cell:  func(_ *printer, e income.Epoch) any { return uint64(e.Epoch) },
+			value: func(e income.Epoch) any { return uint64(e.Epoch) },
+		},
+		dateColumn("date", func(e income.Epoch) time.Time { return e.Date }),
+		{
+			name:  "networkUnits",
+			align: text.AlignRight,
+			cell:  func(p *printer, e income.Epoch) any { return p.Sprintf("%d", e.NetworkUnits) },
+			value: func(e income.Epoch) any { return e.NetworkUnits },
+		},
+		{
+			// a single smesher's share is tiny, so show more digits than for other percentages
+			name:  "share",
+			align: text.AlignRight,
+			cell:  func(p *printer, e income.Epoch) any { return p.Sprintf("%.6f%%", 100*e.Share) },
+			value: func(e income.Epoch) any { return e.Share },
+		},
+		amountColumn("subsidy", 7, text.AlignRight, func(e income.Epoch) uint64 { return e.Subsidy }),
+		amountColumn("fees", 7, text.AlignRight, func(e income.Epoch) uint64 { return e.Fees }),
+		amountColumn("reward", 7, text.AlignRight, income.Epoch.Total),
+		amountColumn("cumulative", 11, text.AlignRight, func(e income.Epoch) uint64 { return e.Cumulative }),
+	}
+}
+
+func renderIncome(w io.Writer, format string, projection []income.Epoch) error {
+	columns := incomeColumns()
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, projection, "Please note:\n"+
+			"- All figures in SMESH (rounded down)\n"+
+			"- Rewards are expected values: actual rewards vary with eligibility and empty layers\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, projection)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[income.Epoch]{{"epochs", columns, projection}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
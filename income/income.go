@@ -0,0 +1,293 @@
+// Package income projects the expected rewards of a smesher over time, given the smesher's space units and a model of
+// the total space committed to the network. A smesher's expected share of each layer's reward is its share of the
+// total space, so that, in expectation, rewards are proportional to space.
+package income
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+)
+
+// NetworkSpace returns the total number of space units committed to the network in an epoch.
+type NetworkSpace interface {
+	SpaceUnitsAtEpoch(epoch types.Epoch) uint64
+}
+
+// Constant is a network of fixed size.
+type Constant uint64
+
+func (c Constant) SpaceUnitsAtEpoch(types.Epoch) uint64 { return uint64(c) }
+
+// Linear is a network of Start space units in epoch zero that grows by Increase space units every epoch. A negative
+// increase is allowed, and the network bottoms out at zero.
+type Linear struct {
+	Start    uint64
+	Increase float64
+}
+
+func (l Linear) SpaceUnitsAtEpoch(epoch types.Epoch) uint64 {
+	return clamp(float64(l.Start) + l.Increase*float64(epoch))
+}
+
+// Exponential is a network of Start space units in epoch zero that grows continuously at the annual rate Growth.
+type Exponential struct {
+	Start  uint64
+	Growth float64
+}
+
+func (e Exponential) SpaceUnitsAtEpoch(epoch types.Epoch) uint64 {
+	years := float64(epoch) * constants.OneEpoch / constants.OneYear
+	return clamp(float64(e.Start) * math.Pow(1+e.Growth, years))
+}
+
+// clamp rounds down a number of space units and bounds it to the representable range.
+func clamp(units float64) uint64 {
+	switch {
+	case units <= 0 || math.IsNaN(units):
+		return 0
+	case units >= math.MaxUint64:
+		return math.MaxUint64
+	default:
+		return uint64(units)
+	}
+}
+
+// Record is the size of the network as of an epoch.
+type Record struct {
+	Epoch      types.Epoch
+	SpaceUnits uint64
+}
+
+// Table is a network whose size is known as of certain epochs. The size in any epoch is that of the latest record at
+// or before it, and zero before the first record.
+type Table struct {
+	records []Record
+}
+
+// NewTable returns a table of the given records. Each epoch may appear at most once.
+func NewTable(records []Record) (*Table, error) {
+	sorted, err := keyed.Sort(records, func(a, b Record) bool { return a.Epoch < b.Epoch }, func(r Record) error {
+		return fmt.Errorf("duplicate network space record for epoch %d", r.Epoch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Table{records: sorted}, nil
+}
+
+func (t *Table) SpaceUnitsAtEpoch(epoch types.Epoch) uint64 {
+	i := sort.Search(len(t.records), func(i int) bool { return t.records[i].Epoch > epoch })
+	if i == 0 {
+		return 0
+	}
+	return t.records[i-1].SpaceUnits
+}
+
+// ReadCSV reads a table of network sizes from CSV with a header row and the columns epoch and spaceUnits.
+func ReadCSV(r io.Reader) (*Table, error) {
+	records, err := keyed.ReadCSV(r, keyed.Epoch, keyed.Column[uint64]{Name: "spaceUnits", Parse: keyed.Uint},
+		func(epoch types.Epoch, units uint64) Record { return Record{Epoch: epoch, SpaceUnits: units} })
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(records)
+}
+
+// Parse returns the network space model described by a scenario, one of:
+//
+//	constant:<space units>
+//	linear:<space units in epoch zero>:<space units added per epoch>
+//	exponential:<space units in epoch zero>:<annual growth rate, e.g., 0.1>
+//	file:<path to CSV of network space per epoch>
+func Parse(scenario string) (NetworkSpace, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	params := strings.Split(args, ":")
+	switch kind {
+	case "constant":
+		if len(params) != 1 {
+			return nil, errors.New("usage: constant:<space units>")
+		}
+		units, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid space units: %w", err)
+		}
+		return Constant(units), nil
+	case "linear", "exponential":
+		if len(params) != 2 {
+			return nil, fmt.Errorf("usage: %s:<space units in epoch zero>:<rate>", kind)
+		}
+		start, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid starting space units: %w", err)
+		}
+		rate, err := strconv.ParseFloat(params[1], 64)
+		if err != nil || math.IsNaN(rate) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("invalid rate %q", params[1])
+		}
+		if kind == "linear" {
+			return Linear{Start: start, Increase: rate}, nil
+		}
+		if rate <= -1 {
+			return nil, errors.New("annual growth rate must be greater than -1")
+		}
+		return Exponential{Start: start, Growth: rate}, nil
+	case "file":
+		table, err := keyed.Open(args, ReadCSV)
+		if err != nil {
+			return nil, err
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("unknown network space scenario %q", scenario)
+	}
+}
+
+// MaxEpochs bounds the horizon of a projection, to about a century.
+const MaxEpochs = 100 * constants.OneYear / constants.OneEpoch
+
+// ErrOverflow is returned when the fees in an epoch, or the rewards up to it, don't fit in a uint64.
+var ErrOverflow = errors.New("rewards overflow")
+
+// add returns the sum of two amounts, or ErrOverflow.
+func add(a, b uint64) (uint64, error) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+type Config struct {
+	// Genesis is the date of the genesis layer
+	Genesis time.Time
+
+	// EffectiveGenesis is the first layer, post-genesis, in which subsidy is issued
+	EffectiveGenesis types.Layer
+
+	// SpaceUnits is the smesher's committed space
+	SpaceUnits uint64
+
+	// Network is the model of the total space committed to the network, including the smesher's own
+	Network NetworkSpace
+
+	// Fees is the fee model; if nil, only the subsidy is counted
+	Fees fees.Model
+
+	// FirstEpoch and Epochs are the first epoch projected and the number of epochs, at most MaxEpochs
+	FirstEpoch types.Epoch
+	Epochs     uint64
+}
+
+// Epoch is the projected income of the smesher in one epoch. Amounts are denominated in smidge and rounded down.
+type Epoch struct {
+	Epoch types.Epoch
+	Date  time.Time
+
+	// NetworkUnits is the total space committed to the network. If it's smaller than the smesher's own space, the
+	// smesher is taken to be the whole network.
+	NetworkUnits uint64
+
+	// Share is the smesher's expected fraction of the rewards
+	Share float64
+
+	// Subsidy and Fees are the smesher's expected rewards in the epoch, and Cumulative the sum of all rewards up to and
+	// including this epoch
+	Subsidy    uint64
+	Fees       uint64
+	Cumulative uint64
+}
+
+// Total returns the smesher's expected rewards in the epoch. Project checks that it fits, as does the cumulative sum.
+func (e Epoch) Total() uint64 {
+	return e.Subsidy + e.Fees
+}
+
+// accumulatedSubsidy returns the accumulated subsidy as of the layer before the given one, post-genesis.
+func accumulatedSubsidy(effectiveGenesis, layerID types.Layer) uint64 {
+	if layerID == 0 {
+		return 0
+	}
+	if effectiveLayer, ok := (layerID - 1).Effective(effectiveGenesis); ok {
+		return rewards.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+	}
+	return 0
+}
+
+// share returns floor(amount * units / network).
+func share(amount, units, network uint64) uint64 {
+	hi, lo := bits.Mul64(amount, units)
+	q, _ := bits.Div64(hi, lo, network)
+	return q
+}
+
+// Project returns the smesher's expected income in each epoch of the configured horizon.
+func Project(cfg Config) ([]Epoch, error) {
+	if cfg.SpaceUnits == 0 {
+		return nil, errors.New("smesher space units must be positive")
+	}
+	if cfg.Network == nil {
+		return nil, errors.New("missing network space model")
+	}
+	if cfg.Epochs > MaxEpochs {
+		return nil, fmt.Errorf("cannot project more than %d epochs", MaxEpochs)
+	}
+	projection := make([]Epoch, 0, cfg.Epochs)
+	var cumulative uint64
+	for i := uint64(0); i < cfg.Epochs; i++ {
+		epoch := cfg.FirstEpoch + types.Epoch(i)
+		firstLayer, ok := epoch.FirstLayer()
+		nextLayer, okNext := (epoch + 1).FirstLayer()
+		if !ok || !okNext {
+			return nil, fmt.Errorf("epoch %d is out of range", epoch)
+		}
+
+		network := cfg.Network.SpaceUnitsAtEpoch(epoch)
+		if network < cfg.SpaceUnits {
+			network = cfg.SpaceUnits
+		}
+		subsidy := accumulatedSubsidy(cfg.EffectiveGenesis, nextLayer) -
+			accumulatedSubsidy(cfg.EffectiveGenesis, firstLayer)
+		var feeTotal uint64
+		if cfg.Fees != nil {
+			for layerID := firstLayer; layerID < nextLayer; layerID++ {
+				var err error
+				if feeTotal, err = add(feeTotal, cfg.Fees.FeesAtLayer(layerID)); err != nil {
+					return nil, fmt.Errorf("epoch %d: fees: %w", epoch, err)
+				}
+			}
+		}
+
+		e := Epoch{
+			Epoch:        epoch,
+			Date:         simulation.LayerTime(cfg.Genesis, firstLayer),
+			NetworkUnits: network,
+			Share:        float64(cfg.SpaceUnits) / float64(network),
+			Subsidy:      share(subsidy, cfg.SpaceUnits, network),
+			Fees:         share(feeTotal, cfg.SpaceUnits, network),
+		}
+		total, err := add(e.Subsidy, e.Fees)
+		if err != nil {
+			return nil, fmt.Errorf("epoch %d: reward: %w", epoch, err)
+		}
+		if cumulative, err = add(cumulative, total); err != nil {
+			return nil, fmt.Errorf("epoch %d: cumulative reward: %w", epoch, err)
+		}
+		e.Cumulative = cumulative
+		projection = append(projection, e)
+	}
+	return projection, nil
+}
@@ -0,0 +1,134 @@
+package income
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var genesis = time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC)
+
+func Test_NetworkSpace(t *testing.T) {
+	assert.Equal(t, uint64(7), Constant(7).SpaceUnitsAtEpoch(100))
+	assert.Equal(t, uint64(1050), Linear{Start: 1000, Increase: 10}.SpaceUnitsAtEpoch(5))
+	assert.Equal(t, uint64(0), Linear{Start: 1000, Increase: -10}.SpaceUnitsAtEpoch(200))
+
+	// one year is 26 epochs plus a few layers
+	exponential := Exponential{Start: 1000, Growth: 1}
+	assert.Equal(t, uint64(1000), exponential.SpaceUnitsAtEpoch(0))
+	assert.InDelta(t, 2000, exponential.SpaceUnitsAtEpoch(26), 5)
+
+	table, err := ReadCSV(strings.NewReader("epoch,spaceUnits\n10,500\n2,100\n"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), table.SpaceUnitsAtEpoch(1))
+	assert.Equal(t, uint64(100), table.SpaceUnitsAtEpoch(2))
+	assert.Equal(t, uint64(100), table.SpaceUnitsAtEpoch(9), "carried forward until the next record")
+	assert.Equal(t, uint64(500), table.SpaceUnitsAtEpoch(1000))
+
+	for name, input := range map[string]string{
+		"bad header": "epoch,units\n1,2\n",
+		"bad epoch":  "epoch,spaceUnits\nx,2\n",
+		"bad units":  "epoch,spaceUnits\n1,-2\n",
+		"duplicate":  "epoch,spaceUnits\n1,2\n1,3\n",
+	} {
+		_, err := ReadCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "network.csv")
+	require.NoError(t, os.WriteFile(path, []byte("epoch,spaceUnits\n3,30\n"), 0o600))
+
+	for scenario, expected := range map[string]NetworkSpace{
+		"constant:5":        Constant(5),
+		"linear:5:-0.5":     Linear{Start: 5, Increase: -0.5},
+		"exponential:5:0.1": Exponential{Start: 5, Growth: 0.1},
+		"file:" + path:      &Table{records: []Record{{Epoch: 3, SpaceUnits: 30}}},
+	} {
+		model, err := Parse(scenario)
+		require.NoError(t, err, scenario)
+		assert.Equal(t, expected, model, scenario)
+	}
+	for _, scenario := range []string{"", "none", "constant:x", "linear:1", "exponential:1:-1", "file:"} {
+		_, err := Parse(scenario)
+		assert.Error(t, err, scenario)
+	}
+}
+
+func Test_Project(t *testing.T) {
+	cfg := Config{
+		Genesis:          genesis,
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		SpaceUnits:       4,
+		Network:          Constant(4),
+		FirstEpoch:       1,
+		Epochs:           3,
+	}
+
+	// the whole network earns the whole subsidy
+	projection, err := Project(cfg)
+	require.NoError(t, err)
+	require.Len(t, projection, 3)
+	assert.Equal(t, Epoch{Epoch: 1, Date: genesis.AddDate(0, 0, 14), NetworkUnits: 4, Share: 1}, projection[0])
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(constants.OneEpoch-1), projection[1].Subsidy)
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(2*constants.OneEpoch-1)-projection[1].Subsidy,
+		projection[2].Subsidy)
+	assert.Equal(t, projection[1].Subsidy+projection[2].Subsidy, projection[2].Cumulative)
+
+	// a network smaller than the smesher is taken to be the smesher alone
+	cfg.Network = Constant(1)
+	smaller, err := Project(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, projection, smaller)
+
+	// a quarter of the network earns a quarter of subsidy and fees, rounded down
+	cfg.SpaceUnits = 1
+	cfg.Network = Constant(4)
+	cfg.Fees = fees.Constant(3)
+	quarter, err := Project(cfg)
+	require.NoError(t, err)
+	for i, e := range quarter {
+		assert.Equal(t, 0.25, e.Share)
+		assert.Equal(t, projection[i].Subsidy/4, e.Subsidy)
+		assert.Equal(t, uint64(3*constants.OneEpoch/4), e.Fees)
+	}
+
+	cfg.SpaceUnits = 0
+	_, err = Project(cfg)
+	assert.Error(t, err)
+	cfg.SpaceUnits = 1
+	cfg.Network = nil
+	_, err = Project(cfg)
+	assert.Error(t, err)
+
+	cfg.Network = Constant(1)
+	cfg.FirstEpoch = types.Epoch(1 << 62)
+	_, err = Project(cfg)
+	assert.Error(t, err)
+
+	// the horizon is bounded before anything is allocated
+	cfg.FirstEpoch = 1
+	cfg.Epochs = math.MaxUint64
+	_, err = Project(cfg)
+	assert.ErrorContains(t, err, "cannot project more than")
+
+	// fees that don't fit are an error rather than wrapping around
+	cfg.Epochs = 3
+	cfg.Fees = fees.Constant(math.MaxUint64)
+	_, err = Project(cfg)
+	assert.ErrorIs(t, err, ErrOverflow)
+	cfg.Fees = fees.Constant(math.MaxUint64 / constants.OneEpoch)
+	_, err = Project(cfg)
+	assert.ErrorIs(t, err, ErrOverflow)
+}
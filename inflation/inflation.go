@@ -0,0 +1,55 @@
+// Package inflation contains metrics describing the rate at which new coins enter the supply. All rates are
+// fractions (e.g., 0.05 is 5%) and are annualized nominally, i.e., the rate observed over a period is scaled linearly
+// to the length of one year (constants.OneYear layers) without compounding.
+package inflation
+
+import (
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// Annualized returns the annualized rate at which a stock grew, given the stock at the start of a period, the amount
+// added over the period and the length of the period in layers. It returns false if the rate is undefined, i.e., if
+// the starting stock or the period is zero.
+func Annualized(start, added, layers uint64) (float64, bool) {
+	if start == 0 || layers == 0 {
+		return 0, false
+	}
+	return float64(added) / float64(start) * constants.OneYear / float64(layers), true
+}
+
+// Net returns the annualized net rate at which a stock grew, given the stock at the start of a period, and the amounts
+// added to and removed from it over the period. The rate is negative if more was removed than added. It returns false
+// if the rate is undefined, i.e., if the starting stock or the period is zero.
+func Net(start, added, removed, layers uint64) (float64, bool) {
+	if start == 0 || layers == 0 {
+		return 0, false
+	}
+	return (float64(added) - float64(removed)) / float64(start) * constants.OneYear / float64(layers), true
+}
+
+// StockToFlow returns the ratio of the given stock to the annualized flow, given the amount added over a period of the
+// given number of layers. The result is the number of years it would take to produce the stock at the current rate.
+// It returns false if the ratio is undefined, i.e., if there was no flow over the period.
+func StockToFlow(stock, added, layers uint64) (float64, bool) {
+	if added == 0 || layers == 0 {
+		return 0, false
+	}
+	return float64(stock) / (float64(added) * constants.OneYear / float64(layers)), true
+}
+
+// Instantaneous returns the instantaneous annualized rate of issuance relative to the given total issuance, derived
+// from the emission rate of the subsidy curve at the given layer. It returns false if total issuance is zero.
+func Instantaneous(layersAfterEffectiveGenesis types.EffectiveLayer, issuanceTotal uint64) (float64, bool) {
+	if issuanceTotal == 0 {
+		return 0, false
+	}
+	ctx := rewards.Ctx
+	perYear := ctx.Mul(decimal.WithContext(ctx), rewards.EmissionRateAtLayer(layersAfterEffectiveGenesis),
+		decimal.WithContext(ctx).SetUint64(constants.OneYear))
+	rate, _ := ctx.Quo(decimal.WithContext(ctx), perYear, decimal.WithContext(ctx).SetUint64(issuanceTotal)).Float64()
+	return rate, true
+}
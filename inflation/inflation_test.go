@@ -0,0 +1,76 @@
+package inflation
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Annualized(t *testing.T) {
+	// 10% growth over one year is 10% annualized
+	rate, ok := Annualized(1000, 100, constants.OneYear)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.1, rate, 1e-12)
+
+	// 1% growth over one epoch is scaled linearly (not compounded)
+	rate, ok = Annualized(1000, 10, constants.OneEpoch)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.01*constants.OneYear/constants.OneEpoch, rate, 1e-12)
+
+	// undefined with no starting stock or an empty period
+	_, ok = Annualized(0, 10, constants.OneEpoch)
+	assert.False(t, ok)
+	_, ok = Annualized(1000, 10, 0)
+	assert.False(t, ok)
+}
+
+func Test_Net(t *testing.T) {
+	// without removals, the net rate is the annualized rate
+	net, ok := Net(1000, 100, 0, constants.OneEpoch)
+	assert.True(t, ok)
+	rate, _ := Annualized(1000, 100, constants.OneEpoch)
+	assert.InDelta(t, rate, net, 1e-12)
+
+	// removing more than was added shrinks the stock
+	net, ok = Net(1000, 50, 150, constants.OneYear)
+	assert.True(t, ok)
+	assert.InDelta(t, -0.1, net, 1e-12)
+
+	_, ok = Net(0, 10, 0, constants.OneEpoch)
+	assert.False(t, ok)
+	_, ok = Net(1000, 10, 0, 0)
+	assert.False(t, ok)
+}
+
+func Test_StockToFlow(t *testing.T) {
+	// producing 100 per year against a stock of 1000 takes ten years
+	ratio, ok := StockToFlow(1000, 100, constants.OneYear)
+	assert.True(t, ok)
+	assert.InDelta(t, 10, ratio, 1e-12)
+
+	// the ratio is the reciprocal of the annualized rate
+	rate, _ := Annualized(1000, 10, constants.OneEpoch)
+	ratio, ok = StockToFlow(1000, 10, constants.OneEpoch)
+	assert.True(t, ok)
+	assert.InDelta(t, 1/rate, ratio, 1e-12)
+
+	// undefined with no flow
+	_, ok = StockToFlow(1000, 0, constants.OneEpoch)
+	assert.False(t, ok)
+}
+
+func Test_Instantaneous(t *testing.T) {
+	_, ok := Instantaneous(0, 0)
+	assert.False(t, ok)
+
+	// the instantaneous rate should closely track the observed rate over a single layer
+	rate, ok := Instantaneous(0, constants.TotalVaulted)
+	assert.True(t, ok)
+	assert.InDelta(t, 477.6183976*constants.OneYear/150000000, rate, 1e-6)
+
+	// and it should decline as issuance slows and the supply grows
+	later, ok := Instantaneous(constants.OneYear, constants.TenYearTarget)
+	assert.True(t, ok)
+	assert.Less(t, later, rate)
+}
@@ -0,0 +1,107 @@
+// Package keyed reads the series that drive scenarios, such as per-layer fees or per-epoch network sizes, from CSV
+// with a header row and two columns: a key, e.g., a layer, and the value as of that key.
+package keyed
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/types"
+)
+
+// Column is a column of a series: its name in the header, and how to parse its values.
+type Column[T any] struct {
+	Name  string
+	Parse func(s string) (T, error)
+}
+
+// Uint parses an unsigned integer, e.g., an amount in smidge.
+func Uint(s string) (uint64, error) {
+	return strconv.ParseUint(s, 10, 64)
+}
+
+var (
+	// Layer is a key column of layers.
+	Layer = Column[types.Layer]{Name: "layer", Parse: func(s string) (types.Layer, error) {
+		n, err := Uint(s)
+		return types.Layer(n), err
+	}}
+
+	// Epoch is a key column of epochs.
+	Epoch = Column[types.Epoch]{Name: "epoch", Parse: func(s string) (types.Epoch, error) {
+		n, err := Uint(s)
+		return types.Epoch(n), err
+	}}
+)
+
+// ReadCSV reads a series from CSV with a header row naming the key and the value column, in that order, and returns the
+// records made of each row. Errors in a row name its line.
+func ReadCSV[K, V, R any](r io.Reader, key Column[K], value Column[V], record func(K, V) R) ([]R, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if header[0] != key.Name || header[1] != value.Name {
+		return nil, fmt.Errorf("expected header \"%s,%s\", got %q", key.Name, value.Name, strings.Join(header, ","))
+	}
+	var records []R
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		k, err := key.Parse(row[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s: %w", line, key.Name, err)
+		}
+		v, err := value.Parse(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s: %w", line, value.Name, err)
+		}
+		records = append(records, record(k, v))
+	}
+	return records, nil
+}
+
+// Sort returns a copy of the records in order of key, as given by less. Keys must be unique: the error for a key that
+// appears more than once is that returned by duplicate for its second record.
+func Sort[R any](records []R, less func(a, b R) bool, duplicate func(R) error) ([]R, error) {
+	sorted := append([]R(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	for i := 1; i < len(sorted); i++ {
+		if !less(sorted[i-1], sorted[i]) {
+			return nil, duplicate(sorted[i])
+		}
+	}
+	return sorted, nil
+}
+
+// Open reads the file of a file:<path> scenario with read. Errors name the file.
+func Open[T any](path string, read func(io.Reader) (T, error)) (T, error) {
+	var zero T
+	if path == "" {
+		return zero, errors.New("usage: file:<path>")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return zero, err
+	}
+	defer f.Close()
+	v, err := read(f)
+	if err != nil {
+		return zero, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
@@ -0,0 +1,74 @@
+package keyed
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	layer  types.Layer
+	amount uint64
+}
+
+func readRecords(r io.Reader) ([]record, error) {
+	return ReadCSV(r, Layer, Column[uint64]{Name: "amount", Parse: Uint},
+		func(layerID types.Layer, amount uint64) record { return record{layerID, amount} })
+}
+
+func Test_ReadCSV(t *testing.T) {
+	records, err := readRecords(strings.NewReader("layer,amount\n5,10\n 3, 20\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []record{{5, 10}, {3, 20}}, records)
+
+	records, err = readRecords(strings.NewReader("layer,amount\n"))
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	for name, tc := range map[string]struct{ csv, err string }{
+		"empty":         {"", "reading header"},
+		"wrong header":  {"epoch,amount\n1,2\n", `expected header "layer,amount"`},
+		"bad key":       {"layer,amount\n1,2\nx,2\n", "line 3: invalid layer"},
+		"bad value":     {"layer,amount\n1,-2\n", "line 2: invalid amount"},
+		"wrong columns": {"layer,amount\n1,2,3\n", "wrong number of fields"},
+	} {
+		_, err := readRecords(strings.NewReader(tc.csv))
+		assert.ErrorContains(t, err, tc.err, name)
+	}
+}
+
+func Test_Sort(t *testing.T) {
+	less := func(a, b record) bool { return a.layer < b.layer }
+	duplicate := func(r record) error { return fmt.Errorf("duplicate layer %d", r.layer) }
+	records := []record{{5, 10}, {3, 20}, {4, 30}}
+	sorted, err := Sort(records, less, duplicate)
+	require.NoError(t, err)
+	assert.Equal(t, []record{{3, 20}, {4, 30}, {5, 10}}, sorted)
+	assert.Equal(t, record{5, 10}, records[0], "the records are copied")
+
+	_, err = Sort([]record{{5, 10}, {3, 20}, {5, 30}}, less, duplicate)
+	assert.EqualError(t, err, "duplicate layer 5")
+}
+
+func Test_Open(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amounts.csv")
+	require.NoError(t, os.WriteFile(path, []byte("layer,amount\n1,2\n"), 0o600))
+	records, err := Open(path, readRecords)
+	require.NoError(t, err)
+	assert.Equal(t, []record{{1, 2}}, records)
+
+	_, err = Open("", func(io.Reader) (int, error) { return 0, nil })
+	assert.EqualError(t, err, "usage: file:<path>")
+	_, err = Open(filepath.Join(t.TempDir(), "missing.csv"), func(io.Reader) (int, error) { return 0, nil })
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = Open(path, func(io.Reader) (int, error) { return 0, errors.New("bad") })
+	assert.EqualError(t, err, path+": bad")
+}
@@ -0,0 +1,120 @@
+// Package malfeasance models identities proven malicious. Such identities lose their eligibility to rewards, but are
+// still counted when the rewards of a layer are divided, so their share of the subsidy is forfeited rather than paid
+// to anyone else.
+package malfeasance
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Model returns the fraction of eligibility weight, between 0 and 1, held by malfeasant identities in an epoch.
+type Model interface {
+	FractionAtEpoch(epoch types.Epoch) float64
+}
+
+// Constant is the same fraction in every epoch.
+type Constant float64
+
+func (c Constant) FractionAtEpoch(types.Epoch) float64 { return float64(c) }
+
+// Record is the malfeasant fraction as of an epoch.
+type Record struct {
+	Epoch    types.Epoch
+	Fraction float64
+}
+
+// Table is a malfeasant fraction known as of certain epochs. The fraction in any epoch is that of the latest record
+// at or before it, and zero before the first record.
+type Table struct {
+	records []Record
+}
+
+// NewTable returns a table of the given records. Each epoch may appear at most once.
+func NewTable(records []Record) (*Table, error) {
+	for _, r := range records {
+		if !validFraction(r.Fraction) {
+			return nil, fmt.Errorf("invalid fraction %v for epoch %d", r.Fraction, r.Epoch)
+		}
+	}
+	sorted, err := keyed.Sort(records, func(a, b Record) bool { return a.Epoch < b.Epoch }, func(r Record) error {
+		return fmt.Errorf("duplicate malfeasance record for epoch %d", r.Epoch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Table{records: sorted}, nil
+}
+
+func (t *Table) FractionAtEpoch(epoch types.Epoch) float64 {
+	i := sort.Search(len(t.records), func(i int) bool { return t.records[i].Epoch > epoch })
+	if i == 0 {
+		return 0
+	}
+	return t.records[i-1].Fraction
+}
+
+func validFraction(f float64) bool {
+	return f >= 0 && f <= 1
+}
+
+// Forfeited returns the part of a layer's subsidy forfeited by malfeasant identities holding the given fraction of
+// eligibility weight, rounded down to the nearest smidge.
+func Forfeited(subsidy uint64, fraction float64) uint64 {
+	if fraction <= 0 {
+		return 0
+	}
+	if fraction >= 1 {
+		return subsidy
+	}
+	return uint64(float64(subsidy) * fraction)
+}
+
+// ReadCSV reads a table of malfeasant fractions from CSV with a header row and the columns epoch and fraction.
+func ReadCSV(r io.Reader) (*Table, error) {
+	records, err := keyed.ReadCSV(r, keyed.Epoch, keyed.Column[float64]{Name: "fraction", Parse: parseFraction},
+		func(epoch types.Epoch, fraction float64) Record { return Record{Epoch: epoch, Fraction: fraction} })
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(records)
+}
+
+// parseFraction parses a fraction of eligibility weight.
+func parseFraction(s string) (float64, error) {
+	fraction, err := strconv.ParseFloat(s, 64)
+	if err != nil || !validFraction(fraction) {
+		return 0, fmt.Errorf("%q is not between 0 and 1", s)
+	}
+	return fraction, nil
+}
+
+// Parse returns the model described by a scenario, one of:
+//
+//	constant:<fraction of eligibility weight>
+//	file:<path to CSV of fractions per epoch>
+func Parse(scenario string) (Model, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	switch kind {
+	case "constant":
+		fraction, err := parseFraction(args)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fraction: %w", err)
+		}
+		return Constant(fraction), nil
+	case "file":
+		table, err := keyed.Open(args, ReadCSV)
+		if err != nil {
+			return nil, err
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("unknown malfeasance scenario %q", scenario)
+	}
+}
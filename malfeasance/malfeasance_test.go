@@ -0,0 +1,63 @@
+package malfeasance
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Model(t *testing.T) {
+	assert.Equal(t, 0.1, Constant(0.1).FractionAtEpoch(7))
+
+	table, err := ReadCSV(strings.NewReader("epoch,fraction\n10,0.2\n5,0.1\n20,0\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, table.FractionAtEpoch(4))
+	assert.Equal(t, 0.1, table.FractionAtEpoch(5))
+	assert.Equal(t, 0.1, table.FractionAtEpoch(9))
+	assert.Equal(t, 0.2, table.FractionAtEpoch(19))
+	assert.Equal(t, 0.0, table.FractionAtEpoch(1000))
+
+	for name, input := range map[string]string{
+		"bad header":   "epoch,rate\n1,0.1\n",
+		"bad epoch":    "epoch,fraction\n-1,0.1\n",
+		"bad fraction": "epoch,fraction\n1,x\n",
+		"too large":    "epoch,fraction\n1,1.5\n",
+		"NaN":          "epoch,fraction\n1,NaN\n",
+		"duplicate":    "epoch,fraction\n1,0.1\n1,0.2\n",
+	} {
+		_, err := ReadCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Forfeited(t *testing.T) {
+	assert.Equal(t, uint64(0), Forfeited(1000, 0))
+	assert.Equal(t, uint64(250), Forfeited(1000, 0.25))
+	assert.Equal(t, uint64(333), Forfeited(1000, 1.0/3), "rounded down")
+	assert.Equal(t, uint64(1000), Forfeited(1000, 1))
+	assert.Equal(t, uint64(math.MaxUint64), Forfeited(math.MaxUint64, 1))
+}
+
+func Test_Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "malfeasance.csv")
+	require.NoError(t, os.WriteFile(path, []byte("epoch,fraction\n3,0.5\n"), 0o600))
+
+	for scenario, expected := range map[string]Model{
+		"constant:0":    Constant(0),
+		"constant:0.05": Constant(0.05),
+		"file:" + path:  &Table{records: []Record{{Epoch: 3, Fraction: 0.5}}},
+	} {
+		model, err := Parse(scenario)
+		require.NoError(t, err, scenario)
+		assert.Equal(t, expected, model, scenario)
+	}
+	for _, scenario := range []string{"", "constant", "constant:-0.1", "constant:2", "rate:0.1", "file:"} {
+		_, err := Parse(scenario)
+		assert.Error(t, err, scenario)
+	}
+}
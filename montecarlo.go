@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/montecarlo"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// montecarloCommand runs many simulations with randomized scenarios and prints percentiles of the outcomes per tick.
+func montecarloCommand(args []string) {
+	fs := flag.NewFlagSet("montecarlo", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "quiet mode (don't render progress)")
+	runs := fs.Int("runs", 100, "number of simulations")
+	seed := fs.Int64("seed", 1, "random seed; the same seed always produces the same result")
+	workers := fs.Int("workers", 0, "number of worker goroutines (default one per CPU core)")
+	tickInterval := fs.Uint64("tick", defaultTickInterval, "number of layers between ticks")
+	endLayer := fs.Uint64("end", defaultEndLayer, "last layer (post-genesis) to simulate")
+	emptyRate := fs.String("emptyRate", "0:0.05", "range of the probability that a layer is empty, as min:max")
+	feesRange := fs.String("fees", "0:0", "range of fees per layer in smidge, as min:max")
+	malfeasance := fs.String("malfeasance", "0:0.01",
+		"range of the fraction of eligibility weight held by malicious identities, as min:max")
+	emptyPolicy := fs.String("emptyPolicy", emptylayers.Forfeit.String(),
+		"what happens to the subsidy for empty layers: forfeit or redistribute")
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	_ = fs.Parse(args)
+
+	cfg := montecarlo.Config{
+		Base: simulation.Config{
+			Genesis:          defaultGenesisDate,
+			EffectiveGenesis: effectiveGenesis,
+			TickInterval:     *tickInterval,
+			EndLayer:         types.Layer(*endLayer),
+		},
+		Runs:    *runs,
+		Seed:    *seed,
+		Workers: *workers,
+	}
+	var err error
+	if cfg.Base.EmptyPolicy, err = emptylayers.ParsePolicy(*emptyPolicy); err != nil {
+		log.Fatal(err)
+	}
+	if cfg.EmptyRate, err = parseRange(*emptyRate, 0, 1); err != nil {
+		log.Fatalf("invalid empty layer rate: %v", err)
+	}
+	if cfg.Fees, err = parseRange(*feesRange, 0, 1<<63); err != nil {
+		log.Fatalf("invalid fees: %v", err)
+	}
+	if cfg.MalfeasantFraction, err = parseRange(*malfeasance, 0, 1); err != nil {
+		log.Fatalf("invalid malfeasance rate: %v", err)
+	}
+	if cfg.Runs < 1 || cfg.Base.TickInterval == 0 {
+		log.Fatal("number of runs and tick interval must be positive")
+	}
+	switch *format {
+	case formatTable, formatCSV, formatJSON:
+	default:
+		log.Fatalf("unknown output format %q", *format)
+	}
+	log.Printf("running %d simulations to layer %d with seed %d\n", cfg.Runs, cfg.Base.EndLayer, cfg.Seed)
+
+	pw := progress.NewWriter()
+	pw.SetUpdateFrequency(time.Millisecond * 100)
+	if !*quiet {
+		go pw.Render()
+	}
+	tracker := progress.Tracker{Total: int64(cfg.Runs), Units: progress.Units{
+		Formatter:        progress.FormatNumber,
+		Notation:         " runs",
+		NotationPosition: progress.UnitsNotationPositionAfter,
+	}}
+	pw.AppendTracker(&tracker)
+
+	start := time.Now()
+	ticks, err := montecarlo.Run(cfg, func() { tracker.Increment(1) })
+	tracker.MarkAsDone()
+	if !*quiet {
+		pw.Stop()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("completed %d simulations in %s\n", cfg.Runs, time.Since(start).Round(time.Second))
+
+	if err := renderMonteCarlo(os.Stdout, *format, ticks); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseRange parses a range given as min:max, or a single value for a fixed parameter, and checks that it lies within
+// the given bounds.
+func parseRange(s string, lower, upper float64) (montecarlo.Range, error) {
+	minStr, maxStr, isRange := strings.Cut(s, ":")
+	if !isRange {
+		maxStr = minStr
+	}
+	lo, err := strconv.ParseFloat(minStr, 64)
+	if err != nil {
+		return montecarlo.Range{}, err
+	}
+	hi, err := strconv.ParseFloat(maxStr, 64)
+	if err != nil {
+		return montecarlo.Range{}, err
+	}
+	if !(lower <= lo && lo <= hi && hi <= upper) {
+		return montecarlo.Range{}, fmt.Errorf("range %q must satisfy %g <= min <= max <= %g", s, lower, upper)
+	}
+	return montecarlo.Range{Min: lo, Max: hi}, nil
+}
+
+func percentileColumns(name string, amount func(t montecarlo.Tick) montecarlo.Percentiles) []column[montecarlo.Tick] {
+	return []column[montecarlo.Tick]{
+		amountColumn(name+"P5", 11, text.AlignRight, func(t montecarlo.Tick) uint64 { return amount(t).P5 }),
+		amountColumn(name+"P50", 11, text.AlignRight, func(t montecarlo.Tick) uint64 { return amount(t).P50 }),
+		amountColumn(name+"P95", 11, text.AlignRight, func(t montecarlo.Tick) uint64 { return amount(t).P95 }),
+	}
+}
+
+func monteCarloColumns() []column[montecarlo.Tick] {
+	columns := []column[montecarlo.Tick]{
+		{
+			name:  "layer",
+			cell:  func(_ *printer, t montecarlo.Tick) any { return uint64(t.Layer) },
+			value: func(t montecarlo.Tick) any { return uint64(t.Layer) },
+		},
+		{
+			name:  "epoch",
+			cell:  func(_ *printer, t montecarlo.Tick) any { return uint64(t.Epoch) },
+			value: func(t montecarlo.Tick) any { return uint64(t.Epoch) },
+		},
+		dateColumn("date", func(t montecarlo.Tick) time.Time { return t.Date }),
+	}
+	columns = append(columns, percentileColumns("circulating",
+		func(t montecarlo.Tick) montecarlo.Percentiles { return t.Circulating })...)
+	columns = append(columns, percentileColumns("subsidy",
+		func(t montecarlo.Tick) montecarlo.Percentiles { return t.Subsidy })...)
+	return append(columns, percentileColumns("fees",
+		func(t montecarlo.Tick) montecarlo.Percentiles { return t.Fees })...)
+}
+
+func renderMonteCarlo(w io.Writer, format string, ticks []montecarlo.Tick) error {
+	columns := monteCarloColumns()
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, ticks, "Please note:\n"+
+			"- All figures in SMESH (rounded down)\n"+
+			"- Percentiles are taken independently for each column and tick\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, ticks)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[montecarlo.Tick]{{"ticks", columns, ticks}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,229 @@
+// Package montecarlo runs many simulations with randomized empty layer rates, fee volumes and malfeasance rates, and
+// summarizes the spread of outcomes at each tick as percentiles.
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Range is an interval from which a parameter is drawn uniformly at random for each run. Min and Max may be equal to
+// fix the parameter.
+type Range struct {
+	Min, Max float64
+}
+
+func (r Range) draw(rng *rand.Rand) float64 {
+	return r.Min + (r.Max-r.Min)*rng.Float64()
+}
+
+type Config struct {
+	// Base is the configuration shared by every run. Its fee model, empty layer model and malfeasance model are
+	// replaced by randomized ones in each run.
+	Base simulation.Config
+
+	// Runs is the number of simulations
+	Runs int
+
+	// Seed determines the parameters of every run, so that the same seed always produces the same result
+	Seed int64
+
+	// Workers is the number of simulations run concurrently; if zero, one per CPU core
+	Workers int
+
+	// EmptyRate is the range of the probability that a layer is empty
+	EmptyRate Range
+
+	// Fees is the range of the fees paid per layer, in smidge
+	Fees Range
+
+	// MalfeasantFraction is the range of the fraction of eligibility weight held by malicious identities
+	MalfeasantFraction Range
+}
+
+// Percentiles summarizes the distribution of an amount, in smidge, over all runs.
+type Percentiles struct {
+	P5, P50, P95 uint64
+}
+
+// Tick contains the distribution of outcomes as of one tick of the simulation.
+type Tick struct {
+	Layer types.Layer
+	Epoch types.Epoch
+	Date  time.Time
+
+	// Circulating and Subsidy are the circulating supply and total subsidy issued
+	Circulating Percentiles
+	Subsidy     Percentiles
+
+	// Fees are the total fees paid
+	Fees Percentiles
+}
+
+// table is a precomputed subsidy schedule, shared by all runs so that the expensive calculation of the accumulated
+// subsidy is done only once per layer.
+type table struct {
+	schedule    simulation.SubsidySchedule
+	accumulated []uint64
+}
+
+func (t *table) TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	if uint64(layersAfterEffectiveGenesis) < uint64(len(t.accumulated)) {
+		return t.accumulated[layersAfterEffectiveGenesis]
+	}
+	return t.schedule.TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis)
+}
+
+// maxTableLayers bounds the size of the table; the accumulated subsidy as of later layers is calculated as needed.
+const maxTableLayers = 20 * constants.OneYear
+
+// newTable calculates the accumulated subsidy as of each layer up to and including the given one, or the first
+// maxTableLayers layers, using the given number of workers.
+func newTable(schedule simulation.SubsidySchedule, last types.EffectiveLayer, workers int) *table {
+	if last >= maxTableLayers {
+		last = maxTableLayers - 1
+	}
+	t := make([]uint64, uint64(last)+1)
+	chunk := (len(t) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < len(t); start += chunk {
+		end := start + chunk
+		if end > len(t) {
+			end = len(t)
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			for i := start; i < end; i++ {
+				t[i] = schedule.TotalAccumulatedSubsidyAtLayer(types.EffectiveLayer(i))
+			}
+		}(start, end)
+	}
+	wg.Wait()
+	return &table{schedule: schedule, accumulated: t}
+}
+
+// MaxEndLayer returns the last layer worth simulating, the one after the final smidge of subsidy is issued: nothing
+// changes past it.
+func MaxEndLayer(effectiveGenesis types.Layer) types.Layer {
+	finalLayer, ok := rewards.FinalLayer.Uint64()
+	if !ok {
+		panic("final layer out of range")
+	}
+	last, ok := types.EffectiveLayer(finalLayer + 1).Layer(effectiveGenesis)
+	if !ok {
+		return types.MaxLayer
+	}
+	return last
+}
+
+// Run executes the simulations and returns the percentiles at each tick. If onRun is non-nil it's called after each
+// run completes, e.g., to report progress; it may be called concurrently. The end layer may be at most MaxEndLayer.
+func Run(cfg Config, onRun func()) ([]Tick, error) {
+	if maxEnd := MaxEndLayer(cfg.Base.EffectiveGenesis); cfg.Base.EndLayer > maxEnd {
+		return nil, fmt.Errorf("end layer %d is past layer %d, after which the subsidy is fully issued",
+			cfg.Base.EndLayer, maxEnd)
+	}
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	base := cfg.Base
+	if last, ok := base.EndLayer.Effective(base.EffectiveGenesis); ok {
+		schedule := base.Subsidy
+		if schedule == nil {
+			schedule = rewards.Mainnet
+		}
+		base.Subsidy = newTable(schedule, last, workers)
+	}
+
+	// draw the parameters of each run up front, so they don't depend on the order in which runs are scheduled
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	configs := make([]simulation.Config, cfg.Runs)
+	for i := range configs {
+		configs[i] = base
+		configs[i].EmptyLayers = emptylayers.Random{Probability: cfg.EmptyRate.draw(rng), Seed: rng.Uint64()}
+		configs[i].Fees = fees.Constant(uint64(cfg.Fees.draw(rng)))
+		configs[i].Malfeasance = malfeasance.Constant(cfg.MalfeasantFraction.draw(rng))
+	}
+
+	results := make([][]simulation.Snapshot, cfg.Runs)
+	runs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range runs {
+				results[i] = simulation.Run(configs[i], nil).Ticks
+				if onRun != nil {
+					onRun()
+				}
+			}
+		}()
+	}
+	for i := range configs {
+		runs <- i
+	}
+	close(runs)
+	wg.Wait()
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	// every run has the same ticks
+	ticks := make([]Tick, len(results[0]))
+	circulating := make([]uint64, len(results))
+	subsidy := make([]uint64, len(results))
+	fees := make([]uint64, len(results))
+	for i := range ticks {
+		for run, snapshots := range results {
+			circulating[run] = snapshots[i].CirculatingTotal
+			subsidy[run] = snapshots[i].SubsidyTotal
+			fees[run] = snapshots[i].FeesTotal
+		}
+		s := results[0][i]
+		ticks[i] = Tick{
+			Layer:       s.Layer,
+			Epoch:       s.Epoch,
+			Date:        s.Date,
+			Circulating: percentiles(circulating),
+			Subsidy:     percentiles(subsidy),
+			Fees:        percentiles(fees),
+		}
+	}
+	return ticks, nil
+}
+
+// percentiles returns the 5th, 50th and 95th percentiles of the values by the nearest rank method. It sorts the
+// values in place.
+func percentiles(values []uint64) Percentiles {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	return Percentiles{
+		P5:  percentile(values, 0.05),
+		P50: percentile(values, 0.5),
+		P95: percentile(values, 0.95),
+	}
+}
+
+func percentile(sorted []uint64, p float64) uint64 {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
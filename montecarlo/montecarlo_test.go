@@ -0,0 +1,116 @@
+package montecarlo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var base = simulation.Config{
+	Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+	EffectiveGenesis: 2 * constants.OneEpoch,
+	TickInterval:     constants.OneEpoch,
+	EndLayer:         4*constants.OneEpoch + 100,
+}
+
+func Test_Deterministic(t *testing.T) {
+	cfg := Config{
+		Base:               base,
+		Runs:               20,
+		Seed:               42,
+		Workers:            1,
+		EmptyRate:          Range{0, 0.1},
+		Fees:               Range{100, 1000},
+		MalfeasantFraction: Range{0, 0.05},
+	}
+	runs := 0
+	ticks, err := Run(cfg, func() { runs++ })
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Runs, runs)
+	require.Len(t, ticks, 6)
+
+	// the result doesn't depend on the number of workers
+	cfg.Workers = 3
+	other, err := Run(cfg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ticks, other)
+
+	cfg.Seed = 43
+	other, err = Run(cfg, nil)
+	require.NoError(t, err)
+	assert.NotEqual(t, ticks, other)
+
+	for _, tick := range ticks {
+		for _, p := range []Percentiles{tick.Circulating, tick.Subsidy, tick.Fees} {
+			assert.LessOrEqual(t, p.P5, p.P50)
+			assert.LessOrEqual(t, p.P50, p.P95)
+		}
+	}
+	last := ticks[len(ticks)-1]
+	assert.Less(t, last.Subsidy.P5, last.Subsidy.P95, "expected a spread of outcomes")
+	assert.GreaterOrEqual(t, last.Fees.P5, uint64(100*(base.EndLayer+1)))
+	assert.LessOrEqual(t, last.Fees.P95, uint64(1000*(base.EndLayer+1)))
+}
+
+func Test_Fixed(t *testing.T) {
+	// with no randomness every run matches the plain simulation
+	ticks, err := Run(Config{Base: base, Runs: 3, Fees: Range{5, 5}}, nil)
+	require.NoError(t, err)
+	expected := simulation.Run(base, nil).Ticks
+	require.Len(t, ticks, len(expected))
+	for i, s := range expected {
+		assert.Equal(t, s.Layer, ticks[i].Layer)
+		assert.Equal(t, s.Date, ticks[i].Date)
+		assert.Equal(t, Percentiles{s.CirculatingTotal, s.CirculatingTotal, s.CirculatingTotal}, ticks[i].Circulating)
+		assert.Equal(t, Percentiles{s.SubsidyTotal, s.SubsidyTotal, s.SubsidyTotal}, ticks[i].Subsidy)
+		assert.Equal(t, uint64(5*(s.Layer+1)), ticks[i].Fees.P50)
+	}
+
+	ticks, err = Run(Config{Base: base}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, ticks)
+
+	// an end layer past the final subsidy is rejected before anything is allocated
+	far := base
+	far.EndLayer = types.MaxLayer
+	_, err = Run(Config{Base: far, Runs: 1}, nil)
+	assert.ErrorContains(t, err, "after which the subsidy is fully issued")
+	far.EndLayer = MaxEndLayer(base.EffectiveGenesis)
+	assert.Greater(t, far.EndLayer, types.Layer(100*constants.OneYear))
+}
+
+// linear is a schedule that issues one smidge per layer, which is quick to calculate.
+type linear struct{}
+
+func (linear) TotalAccumulatedSubsidyAtLayer(layerID types.EffectiveLayer) uint64 {
+	return uint64(layerID)
+}
+
+func Test_Table(t *testing.T) {
+	tbl := newTable(rewards.Mainnet, 1000, 3)
+	require.Len(t, tbl.accumulated, 1001)
+	for _, layerID := range []types.EffectiveLayer{0, 1, 333, 334, 1000, 1001, 5000} {
+		assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(layerID), tbl.TotalAccumulatedSubsidyAtLayer(layerID))
+	}
+
+	// the table is bounded, and later layers are calculated as needed
+	tbl = newTable(linear{}, types.EffectiveLayer(types.MaxLayer), 3)
+	require.Len(t, tbl.accumulated, maxTableLayers)
+	assert.Equal(t, uint64(maxTableLayers+1), tbl.TotalAccumulatedSubsidyAtLayer(maxTableLayers+1))
+}
+
+func Test_Percentiles(t *testing.T) {
+	values := make([]uint64, 100)
+	for i := range values {
+		values[i] = uint64(100 - i)
+	}
+	assert.Equal(t, Percentiles{P5: 5, P50: 50, P95: 95}, percentiles(values))
+	assert.Equal(t, Percentiles{P5: 7, P50: 7, P95: 7}, percentiles([]uint64{7}))
+	assert.Equal(t, Percentiles{P5: 1, P50: 2, P95: 3}, percentiles([]uint64{3, 1, 2}))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spacemeshos/economics/montecarlo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRange(t *testing.T) {
+	r, err := parseRange("0.1:0.2", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, montecarlo.Range{Min: 0.1, Max: 0.2}, r)
+	r, err = parseRange("0.5", 0, 1)
+	require.NoError(t, err)
+	assert.Equal(t, montecarlo.Range{Min: 0.5, Max: 0.5}, r)
+
+	for _, s := range []string{"", "x", "0.2:0.1", "-1:0", "0:2", "0:x", "NaN"} {
+		_, err := parseRange(s, 0, 1)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/inflation"
+	"github.com/spacemeshos/economics/simulation"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/text/language"
+)
+
+// Output formats
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+// column describes one column of output: how it's shown in the table, and its exact value for the machine readable
+// formats. Each row of output is a value of type T, e.g., a simulation snapshot.
+type column[T any] struct {
+	name  string
+	align text.Align
+
+	// cell returns the value shown in the table, with amounts in the unit of the printer
+	cell func(p *printer, row T) any
+
+	// value returns the exact value, with amounts in smidge, or nil if the value is undefined
+	value func(row T) any
+
+	// amount returns the amount in smidge, for columns of amounts; nil otherwise
+	amount func(row T) uint64
+
+	// date returns the date, for columns of dates; nil otherwise
+	date func(row T) time.Time
+
+	// percent is the value of 100% for columns of percentages: 100 if values are percentages, or 1 if they're
+	// fractions; zero otherwise
+	percent float64
+}
+
+// snapshotColumn is a column of simulation output.
+type snapshotColumn = column[simulation.Snapshot]
+
+func amountColumn[T any](name string, width int, align text.Align, amount func(row T) uint64) column[T] {
+	return column[T]{
+		name:  name,
+		align: align,
+		cell: func(p *printer, row T) any {
+			return p.amount(amount(row), width)
+		},
+		value:  func(row T) any { return amount(row) },
+		amount: amount,
+	}
+}
+
+// dateColumn returns a column of dates, shown in the format of the printer and as yyyy-mm-dd in the machine readable
+// formats.
+func dateColumn[T any](name string, date func(row T) time.Time) column[T] {
+	return column[T]{
+		name:  name,
+		cell:  func(p *printer, row T) any { return p.date(date(row)) },
+		value: func(row T) any { return date(row).Format("2006-01-02") },
+		date:  date,
+	}
+}
+
+// pctColumn returns a column for a ratio of amounts. The table shows the percentage rounded exactly; the machine
+// readable formats have the nearest floating point number.
+func pctColumn[T any](name string, align text.Align, num, denom func(row T) uint64) column[T] {
+	pct := func(row T) float64 { return 100 * float64(num(row)) / float64(denom(row)) }
+	return column[T]{
+		name:  name,
+		align: align,
+		cell: func(p *printer, row T) any {
+			return p.percent(num(row), denom(row), 8)
+		},
+		value:   func(row T) any { return pct(row) },
+		percent: 100,
+	}
+}
+
+// rateColumn returns a column for a metric that may be undefined. Rates are shown in the table as percentages but are
+// exact fractions in the machine readable formats.
+func rateColumn[T any](name string, isPct bool, rate func(row T) (float64, bool)) column[T] {
+	c := column[T]{
+		name:  name,
+		align: text.AlignRight,
+		cell: func(p *printer, row T) any {
+			r, ok := rate(row)
+			switch {
+			case !ok:
+				return "n/a"
+			case isPct:
+				return p.rate(r, true, 8)
+			default:
+				return p.rate(r, false, 7)
+			}
+		},
+		value: func(row T) any {
+			if r, ok := rate(row); ok {
+				return r
+			}
+			return nil
+		},
+	}
+	if isPct {
+		c.percent = 1
+	}
+	return c
+}
+
+func inflationColumns() []snapshotColumn {
+	return []snapshotColumn{
+		rateColumn("inflation", true, func(s simulation.Snapshot) (float64, bool) {
+			return inflation.Annualized(s.IssuanceStart(), s.SubsidyNew, s.Layers)
+		}),
+		rateColumn("inflationCirc", true, func(s simulation.Snapshot) (float64, bool) {
+			return inflation.Annualized(s.CirculatingStart(), s.SubsidyNew+s.VaultNewVest, s.Layers)
+		}),
+		rateColumn("stockToFlow", false, func(s simulation.Snapshot) (float64, bool) {
+			return inflation.StockToFlow(s.IssuanceTotal, s.SubsidyNew, s.Layers)
+		}),
+	}
+}
+
+func issuanceTotal(s simulation.Snapshot) uint64 { return s.IssuanceTotal }
+
+// renderOptions selects the optional parts of the simulation output.
+type renderOptions struct {
+	// inflation adds inflation metrics per tick and a summary per calendar year
+	inflation bool
+
+	// fees adds fee revenue per tick
+	fees bool
+
+	// empty adds the number of empty layers and the resulting subsidy shortfall per tick
+	empty bool
+
+	// malfeasance adds the subsidy forfeited by malfeasant identities, and issuance and circulating supply had the
+	// full scheduled subsidy been paid
+	malfeasance bool
+
+	// burn adds the fees burned, net issuance and the net inflation rate
+	burn bool
+
+	// drift adds the difference between the observed and the nominal time of each tick
+	drift bool
+
+	// columns, if any, are the names of the tick columns to show, in order, including derived columns
+	columns []string
+
+	// derived are columns calculated from the others, shown after them unless columns are named
+	derived []derivedColumn
+
+	// unit is the unit of amounts and locale the format of numbers and dates in the table
+	unit   unit
+	locale language.Tag
+}
+
+func feeColumns() []snapshotColumn {
+	return []snapshotColumn{
+		amountColumn("feesNew", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.FeesNew }),
+		amountColumn("rewardNew", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyNew + s.FeesNew }),
+		rateColumn("pctFees", true, func(s simulation.Snapshot) (float64, bool) {
+			if s.SubsidyNew+s.FeesNew == 0 {
+				return 0, false
+			}
+			return float64(s.FeesNew) / float64(s.SubsidyNew+s.FeesNew), true
+		}),
+	}
+}
+
+func emptyColumns() []snapshotColumn {
+	return []snapshotColumn{
+		{
+			name:  "emptyLayers",
+			align: text.AlignRight,
+			cell:  func(_ *printer, s simulation.Snapshot) any { return s.EmptyNew },
+			value: func(s simulation.Snapshot) any { return s.EmptyNew },
+		},
+		amountColumn("subsidyShortfall", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyShortfall }),
+		rateColumn("pctShortfall", true, func(s simulation.Snapshot) (float64, bool) {
+			scheduled := s.SubsidyTotal + s.SubsidyShortfall
+			if scheduled == 0 {
+				return 0, false
+			}
+			return float64(s.SubsidyShortfall) / float64(scheduled), true
+		}),
+	}
+}
+
+func malfeasanceColumns() []snapshotColumn {
+	return []snapshotColumn{
+		amountColumn("forfeitedNew", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.ForfeitedNew }),
+		amountColumn("forfeitedTotal", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.ForfeitedTotal }),
+		amountColumn("circulatingMax", 11, text.AlignRight, simulation.Snapshot.CirculatingMax),
+		amountColumn("issuanceMax", 11, text.AlignRight, simulation.Snapshot.IssuanceMax),
+	}
+}
+
+func burnColumns() []snapshotColumn {
+	return []snapshotColumn{
+		amountColumn("burnedNew", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.BurnedNew }),
+		amountColumn("burnedTotal", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.BurnedTotal }),
+		amountColumn("netIssuance", 11, text.AlignRight, simulation.Snapshot.NetIssuance),
+		rateColumn("netInflation", true, func(s simulation.Snapshot) (float64, bool) {
+			return inflation.Net(s.NetIssuance()+s.BurnedNew-s.SubsidyNew, s.SubsidyNew, s.BurnedNew, s.Layers)
+		}),
+	}
+}
+
+// tickColumns returns the columns of the main simulation output.
+func tickColumns(opts renderOptions) []snapshotColumn {
+	columns := []snapshotColumn{
+		{
+			name:  "layer",
+			cell:  func(_ *printer, s simulation.Snapshot) any { return uint64(s.Layer) },
+			value: func(s simulation.Snapshot) any { return uint64(s.Layer) },
+		},
+		{
+			name:  "epoch",
+			cell:  func(_ *printer, s simulation.Snapshot) any { return uint64(s.Epoch) },
+			value: func(s simulation.Snapshot) any { return uint64(s.Epoch) },
+		},
+		dateColumn("date", func(s simulation.Snapshot) time.Time { return s.Date }),
+		amountColumn("vaultNewVest", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.VaultNewVest }),
+		amountColumn("vaultTotalVest", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.VaultTotalVest }),
+		pctColumn("vaultPctVest", text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.VaultTotalVest },
+			func(s simulation.Snapshot) uint64 { return s.VaultTotal }),
+		amountColumn("vaultTotal", 0, text.AlignDefault,
+			func(s simulation.Snapshot) uint64 { return s.VaultTotal }),
+		amountColumn("subsidyPerLayer", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyPerLayer }),
+		amountColumn("subsidyNew", 7, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyNew }),
+		amountColumn("subsidyTotal", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyTotal }),
+		amountColumn("circulatingTotal", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.CirculatingTotal }),
+		amountColumn("issuanceTotal", 11, text.AlignRight, issuanceTotal),
+		pctColumn("pctVault", text.AlignDefault,
+			func(s simulation.Snapshot) uint64 { return s.VaultTotal }, issuanceTotal),
+		pctColumn("pctCirculating", text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.CirculatingTotal }, issuanceTotal),
+		pctColumn("pctFinalIssuance", text.AlignRight,
+			issuanceTotal, func(simulation.Snapshot) uint64 { return constants.TotalIssuance }),
+	}
+	if opts.drift {
+		columns = append(columns, snapshotColumn{
+			name:  "drift",
+			align: text.AlignRight,
+			cell:  func(_ *printer, s simulation.Snapshot) any { return s.Drift.Round(time.Second).String() },
+			value: func(s simulation.Snapshot) any { return s.Drift.Seconds() },
+		})
+	}
+	if opts.fees {
+		columns = append(columns, feeColumns()...)
+	}
+	if opts.empty {
+		columns = append(columns, emptyColumns()...)
+	}
+	if opts.malfeasance {
+		columns = append(columns, malfeasanceColumns()...)
+	}
+	if opts.burn {
+		columns = append(columns, burnColumns()...)
+	}
+	if opts.inflation {
+		columns = append(columns, inflationColumns()...)
+		columns = append(columns, rateColumn("emissionRate", true, func(s simulation.Snapshot) (float64, bool) {
+			effectiveLayer, ok := s.Layer.Effective(effectiveGenesis)
+			if !ok {
+				return 0, false
+			}
+			return inflation.Instantaneous(effectiveLayer, s.IssuanceTotal)
+		}))
+	}
+	return columns
+}
+
+// yearColumns returns the columns of the summary per calendar year.
+func yearColumns() []snapshotColumn {
+	columns := []snapshotColumn{
+		{
+			name:  "year",
+			cell:  func(_ *printer, s simulation.Snapshot) any { return s.Date.Year() },
+			value: func(s simulation.Snapshot) any { return s.Date.Year() },
+		},
+		{
+			name:  "lastLayer",
+			cell:  func(_ *printer, s simulation.Snapshot) any { return uint64(s.Layer) },
+			value: func(s simulation.Snapshot) any { return uint64(s.Layer) },
+		},
+		{
+			name:  "layers",
+			cell:  func(_ *printer, s simulation.Snapshot) any { return s.Layers },
+			value: func(s simulation.Snapshot) any { return s.Layers },
+		},
+		amountColumn("vaultNewVest", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.VaultNewVest }),
+		amountColumn("subsidyNew", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.SubsidyNew }),
+		amountColumn("circulatingTotal", 11, text.AlignRight,
+			func(s simulation.Snapshot) uint64 { return s.CirculatingTotal }),
+		amountColumn("issuanceTotal", 11, text.AlignRight, issuanceTotal),
+	}
+	return append(columns, inflationColumns()...)
+}
+
+// selectTickColumns returns the tick columns selected by the options.
+func selectTickColumns(opts renderOptions) ([]snapshotColumn, error) {
+	return selectColumns(tickColumns(opts), opts.columns, opts.derived)
+}
+
+// tickCaption returns the notes shown below the table of ticks.
+func tickCaption(opts renderOptions) string {
+	caption := "Please note:\n" +
+		"- All figures in " + opts.unit.description() + "\n" +
+		"- No coins are issued in the first two epochs\n"
+	if opts.empty {
+		caption += "- Empty layers pay no rewards; subsidyShortfall is the subsidy not (yet) issued as a result\n"
+	} else {
+		caption += "- Figures represent maximum issuance (and do not account for empty layers)\n"
+	}
+	if opts.drift {
+		caption += "- Dates follow observed layer times, extrapolated with the nominal layer duration; drift is " +
+			"relative to the nominal time\n"
+	}
+	if opts.malfeasance {
+		caption += "- Malfeasant identities forfeit their share of the subsidy; circulatingMax and issuanceMax assume " +
+			"the full scheduled subsidy is paid\n"
+	}
+	if opts.fees {
+		caption += "- Fees are transferred from circulating supply and do not count towards issuance\n"
+	}
+	if opts.burn {
+		caption += "- Burned fees are removed from circulation; netIssuance is issuance less the amount burned, and " +
+			"netInflation its annualized rate of change\n"
+	}
+	if opts.inflation {
+		caption += "- Inflation is annualized over each tick; emissionRate is the instantaneous annualized rate\n"
+	}
+	return caption
+}
+
+// render writes the simulation result in the given format. The summary per calendar year is included only if
+// inflation metrics are selected.
+func render(w io.Writer, format string, opts renderOptions, result simulation.Result) error {
+	ticks, err := selectTickColumns(opts)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case formatTable:
+		p := newPrinter(opts.locale, opts.unit)
+		renderTable(w, p, ticks, result.Ticks, tickCaption(opts))
+		if opts.inflation {
+			renderTable(w, p, yearColumns(), result.Years, "Please note:\n"+
+				"- All figures in "+opts.unit.description()+"\n"+
+				"- Inflation is annualized over the layers simulated in each calendar year\n")
+		}
+		return nil
+	case formatCSV:
+		return renderCSV(w, ticks, result.Ticks)
+	case formatJSON:
+		sections := []jsonSection[simulation.Snapshot]{{"ticks", ticks, result.Ticks}}
+		if opts.inflation {
+			sections = append(sections, jsonSection[simulation.Snapshot]{"years", yearColumns(), result.Years})
+		}
+		return renderJSON(w, sections)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func renderTable[T any](w io.Writer, p *printer, columns []column[T], rows []T, caption string) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	header := make(table.Row, len(columns))
+	var configs []table.ColumnConfig
+	for i, c := range columns {
+		header[i] = c.name
+		if c.align != text.AlignDefault {
+			configs = append(configs, table.ColumnConfig{Number: i + 1, Align: c.align})
+		}
+	}
+	t.AppendHeader(header)
+	t.SetColumnConfigs(configs)
+	for _, r := range rows {
+		row := make(table.Row, len(columns))
+		for i, c := range columns {
+			row[i] = c.cell(p, r)
+		}
+		t.AppendRow(row)
+	}
+	t.SetCaption(caption)
+	t.Render()
+}
+
+// formatValue formats an exact value for CSV output. Undefined values are left empty.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func renderCSV[T any](w io.Writer, columns []column[T], rows []T) error {
+	cw := csv.NewWriter(w)
+	record := make([]string, len(columns))
+	for i, c := range columns {
+		record[i] = c.name
+	}
+	if err := cw.Write(record); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		for i, c := range columns {
+			record[i] = formatValue(c.value(r))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonSection[T any] struct {
+	name    string
+	columns []column[T]
+	rows    []T
+}
+
+// appendJSON appends the section as a key and an array of objects, one per line, with keys in column order.
+func (s jsonSection[T]) appendJSON(buf []byte) ([]byte, error) {
+	buf = appendJSONKey(append(buf, "  "...), s.name)
+	buf = append(buf, "[\n"...)
+	for j, r := range s.rows {
+		buf = append(buf, "    {"...)
+		for k, c := range s.columns {
+			value, err := json.Marshal(c.value(r))
+			if err != nil {
+				return nil, err
+			}
+			if k > 0 {
+				buf = append(buf, ", "...)
+			}
+			buf = append(appendJSONKey(buf, c.name), value...)
+		}
+		buf = append(buf, '}')
+		if j < len(s.rows)-1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '\n')
+	}
+	return append(buf, "  ]"...), nil
+}
+
+// appendJSONKey appends a key of a JSON object and its colon, the key quoted as encoding/json does.
+func appendJSONKey(buf []byte, key string) []byte {
+	quoted, _ := json.Marshal(key) // a string always marshals
+	return append(append(buf, quoted...), ": "...)
+}
+
+// jsonAppender is a section of JSON output, whatever the type of its rows.
+type jsonAppender interface {
+	appendJSON(buf []byte) ([]byte, error)
+}
+
+// renderJSON writes each section as an array of objects, one per line, with keys in column order.
+func renderJSON[T any](w io.Writer, sections []jsonSection[T]) error {
+	appenders := make([]jsonAppender, len(sections))
+	for i, section := range sections {
+		appenders[i] = section
+	}
+	return renderJSONSections(w, appenders...)
+}
+
+// renderJSONSections writes sections with rows of different types, as renderJSON.
+func renderJSONSections(w io.Writer, sections ...jsonAppender) error {
+	var buf []byte
+	buf = append(buf, "{\n"...)
+	for i, section := range sections {
+		var err error
+		if buf, err = section.appendJSON(buf); err != nil {
+			return err
+		}
+		if i < len(sections)-1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '\n')
+	}
+	buf = append(buf, "}\n"...)
+	_, err := w.Write(buf)
+	return err
+}
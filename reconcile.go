@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spacemeshos/economics/reconcile"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// reconcileCommand audits reward records exported from a node against the subsidy schedule. It exits with a nonzero
+// status if any layer doesn't match.
+func reconcileCommand(args []string) {
+	fs := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	input := fs.String("input", "", "format of the export: csv or json (default from the file extension)")
+	fromLayer := fs.Uint64("from", 0, "first layer (post-genesis) to audit (default the first layer recorded)")
+	toLayer := fs.Uint64("to", 0, "last layer (post-genesis) to audit (default the last layer recorded)")
+	format := fs.String("format", formatTable, "output format for discrepancies: table, csv or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s reconcile [flags] <export file>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	path := fs.Arg(0)
+	if *input == "" {
+		*input = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var records []reconcile.Record
+	switch *input {
+	case "csv":
+		records, err = reconcile.ReadCSV(f)
+	case "json":
+		records, err = reconcile.ReadJSON(f)
+	default:
+		err = fmt.Errorf("unknown input format %q", *input)
+	}
+	f.Close()
+	if err != nil {
+		log.Fatalf("%s: %v", path, err)
+	}
+
+	layers, ok := reconcile.LayerRange(records)
+	if !ok {
+		log.Fatalf("%s: no records", path)
+	}
+	cfg := reconcile.Config{EffectiveGenesis: effectiveGenesis, FromLayer: layers.First, ToLayer: layers.Last}
+	var explicitTo bool
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "from":
+			cfg.FromLayer = types.Layer(*fromLayer)
+		case "to":
+			cfg.ToLayer = types.Layer(*toLayer)
+			explicitTo = true
+		}
+	})
+	audited := reconcile.Range{First: cfg.FromLayer, Last: cfg.ToLayer}
+	if !explicitTo && !audited.Plausible(len(records)) {
+		log.Fatalf("%s: %d records span layers %d to %d, implausibly many; use -to to set the last layer",
+			path, len(records), cfg.FromLayer, cfg.ToLayer)
+	}
+	log.Printf("auditing %d records over layers %d to %d\n", len(records), cfg.FromLayer, cfg.ToLayer)
+
+	report, err := reconcile.Run(cfg, records)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(report.Discrepancies) > 0 {
+		if err := renderDiscrepancies(os.Stdout, *format, report.Discrepancies); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Printf("%d layers audited, %d as expected, %d overpaid, %d underpaid, %d missing\n", report.Layers,
+		report.Matched, report.Overpaid, report.Underpaid, report.MissingLayers)
+	if len(report.Missing) > 0 {
+		missing := make([]string, len(report.Missing))
+		for i, r := range report.Missing {
+			missing[i] = r.String()
+		}
+		log.Printf("missing layers: %s\n", strings.Join(missing, ", "))
+	}
+	log.Printf("subsidy expected %d smidge, recorded %d smidge, drift %d smidge; fees recorded %d smidge\n",
+		report.Expected, report.Recorded, report.Drift(), report.Fees)
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+func signedColumn(name string, amount func(l reconcile.Layer) int64) column[reconcile.Layer] {
+	return column[reconcile.Layer]{
+		name:  name,
+		align: text.AlignRight,
+		cell:  func(p *printer, l reconcile.Layer) any { return p.Sprintf("%+d", amount(l)) },
+		value: func(l reconcile.Layer) any { return amount(l) },
+	}
+}
+
+func smidgeColumn(name string, amount func(l reconcile.Layer) uint64) column[reconcile.Layer] {
+	return column[reconcile.Layer]{
+		name:  name,
+		align: text.AlignRight,
+		cell:  func(p *printer, l reconcile.Layer) any { return p.Sprintf("%d", amount(l)) },
+		value: func(l reconcile.Layer) any { return amount(l) },
+	}
+}
+
+// discrepancyColumns shows amounts in smidge even in the table, since discrepancies may be tiny.
+func discrepancyColumns() []column[reconcile.Layer] {
+	return []column[reconcile.Layer]{
+		{
+			name:  "layer",
+			cell:  func(_ *printer, l reconcile.Layer) any { return uint64(l.Layer) },
+			value: func(l reconcile.Layer) any { return uint64(l.Layer) },
+		},
+		smidgeColumn("expected", func(l reconcile.Layer) uint64 { return l.Expected }),
+		smidgeColumn("recorded", func(l reconcile.Layer) uint64 { return l.Recorded }),
+		signedColumn("diff", reconcile.Layer.Diff),
+		signedColumn("drift", func(l reconcile.Layer) int64 { return l.Drift }),
+	}
+}
+
+func renderDiscrepancies(w io.Writer, format string, discrepancies []reconcile.Layer) error {
+	columns := discrepancyColumns()
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, discrepancies, "Please note:\n"+
+			"- All figures in smidge\n"+
+			"- Drift is the cumulative difference between recorded and expected subsidy, including missing layers\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, discrepancies)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[reconcile.Layer]{{"discrepancies", columns, discrepancies}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,312 @@
+// Package reconcile audits reward records exported from a node against the subsidy schedule. The subsidy recorded in
+// each layer, summed over all coinbases, should equal the subsidy scheduled for that layer.
+package reconcile
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Record is one reward paid to a coinbase in a layer. LayerReward is the subsidy part of the reward, and TotalReward
+// includes fees.
+type Record struct {
+	Layer       types.Layer
+	Coinbase    string
+	TotalReward uint64
+	LayerReward uint64
+}
+
+// csvColumns are the columns of a CSV export, which may appear in any order.
+var csvColumns = []string{"layer", "coinbase", "totalReward", "layerReward"}
+
+// ReadCSV reads reward records from CSV with a header row naming the columns layer, coinbase, totalReward and
+// layerReward. Other columns are ignored.
+func ReadCSV(r io.Reader) ([]Record, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range csvColumns {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("missing column %q in header %q", name, strings.Join(header, ","))
+		}
+	}
+
+	var records []Record
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		var values [3]uint64
+		for i, name := range []string{"layer", "totalReward", "layerReward"} {
+			if values[i], err = strconv.ParseUint(row[index[name]], 10, 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid %s: %w", line, name, err)
+			}
+		}
+		records = append(records, Record{
+			Layer:       types.Layer(values[0]),
+			Coinbase:    row[index["coinbase"]],
+			TotalReward: values[1],
+			LayerReward: values[2],
+		})
+	}
+	return records, nil
+}
+
+// ReadJSON reads reward records from a JSON array of objects with the keys layer, coinbase, totalReward and
+// layerReward. Amounts may be numbers or strings of digits, since they may exceed the precision of a JSON number in
+// some decoders.
+func ReadJSON(r io.Reader) ([]Record, error) {
+	var raw []struct {
+		Layer       json.Number `json:"layer"`
+		Coinbase    string      `json:"coinbase"`
+		TotalReward json.Number `json:"totalReward"`
+		LayerReward json.Number `json:"layerReward"`
+	}
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	records := make([]Record, len(raw))
+	for i, rr := range raw {
+		var values [3]uint64
+		for j, field := range []struct {
+			name  string
+			value json.Number
+		}{{"layer", rr.Layer}, {"totalReward", rr.TotalReward}, {"layerReward", rr.LayerReward}} {
+			var err error
+			if values[j], err = strconv.ParseUint(field.value.String(), 10, 64); err != nil {
+				return nil, fmt.Errorf("record %d: invalid %s: %w", i, field.name, err)
+			}
+		}
+		records[i] = Record{
+			Layer:       types.Layer(values[0]),
+			Coinbase:    rr.Coinbase,
+			TotalReward: values[1],
+			LayerReward: values[2],
+		}
+	}
+	return records, nil
+}
+
+// ErrOverflow is returned when a total of the records, or its difference from the total expected, doesn't fit.
+var ErrOverflow = errors.New("total overflows")
+
+// add returns the sum of two amounts, or ErrOverflow.
+func add(a, b uint64) (uint64, error) {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+// fitsInt64 returns true if the difference between the recorded and the expected amount fits in an int64. The expected
+// amount is at most the total subsidy, so only a difference in favor of the recorded amount may not fit.
+func fitsInt64(recorded, expected uint64) bool {
+	return recorded <= expected || recorded-expected <= math.MaxInt64
+}
+
+type Config struct {
+	// EffectiveGenesis is the first layer, post-genesis, in which subsidy is issued
+	EffectiveGenesis types.Layer
+
+	// FromLayer and ToLayer are the range of layers audited (inclusive). Records outside the range are ignored.
+	FromLayer types.Layer
+	ToLayer   types.Layer
+}
+
+// Layer compares the subsidy recorded in a layer with the subsidy scheduled for it. Amounts are denominated in smidge.
+type Layer struct {
+	Layer    types.Layer
+	Expected uint64
+	Recorded uint64
+
+	// Fees is the part of the recorded rewards that isn't subsidy
+	Fees uint64
+
+	// Drift is the cumulative difference between the recorded and the expected subsidy as of this layer
+	Drift int64
+}
+
+// Diff returns the difference between the recorded and the expected subsidy.
+func (l Layer) Diff() int64 {
+	return int64(l.Recorded - l.Expected)
+}
+
+// Range is a range of layers (inclusive).
+type Range struct {
+	First, Last types.Layer
+}
+
+func (r Range) String() string {
+	if r.First == r.Last {
+		return strconv.FormatUint(uint64(r.First), 10)
+	}
+	return fmt.Sprintf("%d-%d", r.First, r.Last)
+}
+
+// MaxLayersPerRecord is the most layers per record that a range of layers inferred from the records may span. Most
+// layers should have records, so a far wider range is likely due to a corrupt layer, and would take long to audit.
+const MaxLayersPerRecord = 100
+
+// Plausible returns true if the range isn't implausibly wide for the number of records in it.
+func (r Range) Plausible(records int) bool {
+	return r.First > r.Last || uint64(r.Last-r.First)/MaxLayersPerRecord < uint64(records)
+}
+
+// Report summarizes the audit.
+type Report struct {
+	// Layers is the number of layers audited, and Matched the number in which the recorded subsidy is as expected
+	Layers  uint64
+	Matched uint64
+
+	// Discrepancies lists the layers with records in which too much or too little subsidy was recorded, in order
+	Discrepancies []Layer
+	Overpaid      uint64
+	Underpaid     uint64
+
+	// Missing lists the ranges of layers in which subsidy is expected but nothing was recorded
+	Missing       []Range
+	MissingLayers uint64
+
+	// Expected and Recorded are the total subsidy expected and recorded over all layers audited, and Fees the total
+	// fees recorded
+	Expected uint64
+	Recorded uint64
+	Fees     uint64
+}
+
+// Drift returns the difference between the total subsidy recorded and expected.
+func (r Report) Drift() int64 {
+	return int64(r.Recorded - r.Expected)
+}
+
+// OK returns true if every layer is as expected.
+func (r Report) OK() bool {
+	return r.Matched == r.Layers
+}
+
+// Run audits the records over the configured range of layers.
+func Run(cfg Config, records []Record) (Report, error) {
+	if cfg.FromLayer > cfg.ToLayer {
+		return Report{}, fmt.Errorf("invalid layer range %d-%d", cfg.FromLayer, cfg.ToLayer)
+	}
+	type totals struct{ subsidy, fees uint64 }
+	recorded := map[types.Layer]*totals{}
+	for i, r := range records {
+		if r.Layer < cfg.FromLayer || r.Layer > cfg.ToLayer {
+			continue
+		}
+		if r.LayerReward > r.TotalReward {
+			return Report{}, fmt.Errorf("record %d: layer reward %d exceeds total reward %d", i, r.LayerReward,
+				r.TotalReward)
+		}
+		t, ok := recorded[r.Layer]
+		if !ok {
+			t = &totals{}
+			recorded[r.Layer] = t
+		}
+		var err error
+		if t.subsidy, err = add(t.subsidy, r.LayerReward); err != nil {
+			return Report{}, fmt.Errorf("record %d: subsidy recorded in layer %d: %w", i, r.Layer, err)
+		}
+		if t.fees, err = add(t.fees, r.TotalReward-r.LayerReward); err != nil {
+			return Report{}, fmt.Errorf("record %d: fees recorded in layer %d: %w", i, r.Layer, err)
+		}
+	}
+
+	var report Report
+	var prevAccumulated uint64
+	if layerID, ok := cfg.FromLayer.Effective(cfg.EffectiveGenesis); ok && layerID > 0 {
+		prevAccumulated = rewards.TotalAccumulatedSubsidyAtLayer(layerID - 1)
+	}
+	for layerID := cfg.FromLayer; ; layerID++ {
+		// one evaluation of the schedule per layer, since we step through layers in order
+		l := Layer{Layer: layerID}
+		if effectiveLayer, ok := layerID.Effective(cfg.EffectiveGenesis); ok {
+			accumulated := rewards.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+			l.Expected = accumulated - prevAccumulated
+			prevAccumulated = accumulated
+		}
+		t, ok := recorded[layerID]
+		if ok {
+			l.Recorded = t.subsidy
+			l.Fees = t.fees
+		}
+		report.Layers++
+		var err error
+		if report.Recorded, err = add(report.Recorded, l.Recorded); err != nil {
+			return Report{}, fmt.Errorf("layer %d: subsidy recorded: %w", layerID, err)
+		}
+		if report.Fees, err = add(report.Fees, l.Fees); err != nil {
+			return Report{}, fmt.Errorf("layer %d: fees recorded: %w", layerID, err)
+		}
+		// the expected total is at most the total subsidy, so it can't overflow
+		report.Expected += l.Expected
+		if !fitsInt64(l.Recorded, l.Expected) || !fitsInt64(report.Recorded, report.Expected) {
+			return Report{}, fmt.Errorf("layer %d: drift: %w", layerID, ErrOverflow)
+		}
+		l.Drift = report.Drift()
+
+		switch {
+		case l.Recorded == l.Expected:
+			report.Matched++
+		case !ok:
+			report.MissingLayers++
+			if n := len(report.Missing); n > 0 && report.Missing[n-1].Last == layerID-1 {
+				report.Missing[n-1].Last = layerID
+			} else {
+				report.Missing = append(report.Missing, Range{First: layerID, Last: layerID})
+			}
+		case l.Recorded > l.Expected:
+			report.Overpaid++
+			report.Discrepancies = append(report.Discrepancies, l)
+		default:
+			report.Underpaid++
+			report.Discrepancies = append(report.Discrepancies, l)
+		}
+
+		// check here rather than in the loop condition in case the last layer is the last representable one
+		if layerID == cfg.ToLayer {
+			break
+		}
+	}
+	return report, nil
+}
+
+// LayerRange returns the range of layers covered by the records.
+func LayerRange(records []Record) (Range, bool) {
+	if len(records) == 0 {
+		return Range{}, false
+	}
+	r := Range{First: records[0].Layer, Last: records[0].Layer}
+	for _, record := range records[1:] {
+		if record.Layer < r.First {
+			r.First = record.Layer
+		}
+		if record.Layer > r.Last {
+			r.Last = record.Layer
+		}
+	}
+	return r, true
+}
@@ -0,0 +1,171 @@
+package reconcile
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const effectiveGenesis = 2 * constants.OneEpoch
+
+// expected returns the subsidy scheduled for a layer post-genesis.
+func expected(layerID types.Layer) uint64 {
+	if effectiveLayer, ok := layerID.Effective(effectiveGenesis); ok {
+		return rewards.TotalSubsidyAtLayer(effectiveLayer)
+	}
+	return 0
+}
+
+// records returns records paying the scheduled subsidy in each layer in the range, split between two coinbases, plus
+// some fees.
+func records(first, last types.Layer) []Record {
+	var rs []Record
+	for layerID := first; layerID <= last; layerID++ {
+		subsidy := expected(layerID)
+		rs = append(rs,
+			Record{Layer: layerID, Coinbase: "a", TotalReward: subsidy/3 + 10, LayerReward: subsidy / 3},
+			Record{Layer: layerID, Coinbase: "b", TotalReward: subsidy - subsidy/3, LayerReward: subsidy - subsidy/3},
+		)
+	}
+	return rs
+}
+
+func Test_Matched(t *testing.T) {
+	cfg := Config{EffectiveGenesis: effectiveGenesis, FromLayer: effectiveGenesis - 2, ToLayer: effectiveGenesis + 100}
+	report, err := Run(cfg, records(cfg.FromLayer, cfg.ToLayer))
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, uint64(103), report.Layers)
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(100), report.Expected)
+	assert.Equal(t, report.Expected, report.Recorded)
+	assert.Equal(t, uint64(103*10), report.Fees)
+	assert.Zero(t, report.Drift())
+	assert.Empty(t, report.Missing)
+}
+
+func Test_Discrepancies(t *testing.T) {
+	cfg := Config{EffectiveGenesis: effectiveGenesis, FromLayer: effectiveGenesis, ToLayer: effectiveGenesis + 20}
+	rs := records(cfg.FromLayer, cfg.ToLayer)
+
+	// layer +2 overpaid by 5, layer +4 underpaid by 7, layers +10 to +12 and +20 missing, and a record before the
+	// effective genesis that's out of range
+	rs[4].LayerReward += 5
+	rs[4].TotalReward += 5
+	rs[8].LayerReward -= 7
+	var filtered []Record
+	for _, r := range rs {
+		if offset := r.Layer - effectiveGenesis; (offset < 10 || offset > 12) && offset != 20 {
+			filtered = append(filtered, r)
+		}
+	}
+	filtered = append(filtered, Record{Layer: 1, TotalReward: 100, LayerReward: 100})
+
+	report, err := Run(cfg, filtered)
+	require.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Equal(t, uint64(21), report.Layers)
+	assert.Equal(t, uint64(15), report.Matched)
+	assert.Equal(t, uint64(1), report.Overpaid)
+	assert.Equal(t, uint64(1), report.Underpaid)
+	assert.Equal(t, uint64(4), report.MissingLayers)
+	assert.Equal(t, []Range{{effectiveGenesis + 10, effectiveGenesis + 12}, {effectiveGenesis + 20, effectiveGenesis + 20}},
+		report.Missing)
+	assert.Equal(t, "8074-8076", report.Missing[0].String())
+	assert.Equal(t, "8084", report.Missing[1].String())
+
+	require.Len(t, report.Discrepancies, 2)
+	assert.Equal(t, int64(5), report.Discrepancies[0].Diff())
+	assert.Equal(t, int64(5), report.Discrepancies[0].Drift)
+	assert.Equal(t, int64(-7), report.Discrepancies[1].Diff())
+	assert.Equal(t, int64(-2), report.Discrepancies[1].Drift)
+
+	var missing uint64
+	for _, offset := range []types.Layer{10, 11, 12, 20} {
+		missing += expected(effectiveGenesis + offset)
+	}
+	assert.Equal(t, -2-int64(missing), report.Drift())
+
+	// before effective genesis nothing is expected, so anything recorded is overpaid
+	cfg.FromLayer = 0
+	report, err = Run(cfg, filtered)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), report.Overpaid)
+	assert.Equal(t, types.Layer(1), report.Discrepancies[0].Layer)
+	assert.Equal(t, Range{First: 1, Last: effectiveGenesis + 19}, must(LayerRange(filtered)))
+
+	// a corrupt layer makes the range implausibly wide for the records
+	recorded := records(effectiveGenesis, effectiveGenesis+9)
+	assert.True(t, must(LayerRange(recorded)).Plausible(len(recorded)))
+	corrupt := append(recorded, Record{Layer: math.MaxUint64})
+	assert.False(t, must(LayerRange(corrupt)).Plausible(len(corrupt)))
+	assert.True(t, Range{First: 5, Last: 5 + MaxLayersPerRecord - 1}.Plausible(1))
+	assert.False(t, Range{First: 5, Last: 5 + MaxLayersPerRecord}.Plausible(1))
+
+	_, err = Run(Config{FromLayer: 2, ToLayer: 1}, nil)
+	assert.Error(t, err)
+	_, err = Run(cfg, []Record{{Layer: 5, TotalReward: 1, LayerReward: 2}})
+	assert.Error(t, err)
+
+	// totals that don't fit
+	huge := Record{Layer: 5, TotalReward: math.MaxUint64, LayerReward: math.MaxUint64 / 2}
+	_, err = Run(cfg, []Record{huge, huge, huge})
+	assert.ErrorIs(t, err, ErrOverflow)
+	half := Record{Layer: 5, TotalReward: math.MaxUint64 / 2, LayerReward: math.MaxUint64 / 2}
+	_, err = Run(cfg, []Record{half, {Layer: 6, TotalReward: math.MaxUint64 / 2, LayerReward: math.MaxUint64 / 2}})
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = Run(cfg, []Record{{Layer: 5, TotalReward: math.MaxUint64, LayerReward: math.MaxUint64}})
+	assert.ErrorIs(t, err, ErrOverflow)
+}
+
+func must(r Range, ok bool) Range {
+	if !ok {
+		panic("no records")
+	}
+	return r
+}
+
+func Test_Read(t *testing.T) {
+	expected := []Record{
+		{Layer: 8064, Coinbase: "sm1qqq", TotalReward: 476800000000, LayerReward: 476000000000},
+		{Layer: 8065, Coinbase: "sm1rrr", TotalReward: 18446744073709551615, LayerReward: 1},
+	}
+
+	rs, err := ReadCSV(strings.NewReader("coinbase,layer,layerReward,totalReward,extra\n" +
+		"sm1qqq,8064,476000000000,476800000000,x\n" +
+		"sm1rrr,8065,1,18446744073709551615,y\n"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, rs)
+
+	rs, err = ReadJSON(strings.NewReader(`[
+		{"layer": 8064, "coinbase": "sm1qqq", "totalReward": 476800000000, "layerReward": 476000000000},
+		{"layer": "8065", "coinbase": "sm1rrr", "totalReward": "18446744073709551615", "layerReward": "1"}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, expected, rs)
+
+	for name, input := range map[string]string{
+		"missing column": "layer,coinbase,totalReward\n1,a,2\n",
+		"bad layer":      "layer,coinbase,totalReward,layerReward\nx,a,2,1\n",
+		"bad reward":     "layer,coinbase,totalReward,layerReward\n1,a,-2,1\n",
+	} {
+		_, err := ReadCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+	for name, input := range map[string]string{
+		"not an array": `{}`,
+		"bad reward":   `[{"layer": 1, "totalReward": 1.5, "layerReward": 1}]`,
+		"missing":      `[{"layer": 1}]`,
+	} {
+		_, err := ReadJSON(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+
+	_, ok := LayerRange(nil)
+	assert.False(t, ok)
+}
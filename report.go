@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// setting is a parameter of a simulation run, as shown in the report. The value is a string, a date, or one of the
+// types below, so that the spreadsheet has it as a number or date rather than as text.
+type setting struct {
+	name  string
+	value any
+}
+
+// layerSetting is a layer, shown with its date.
+type layerSetting struct {
+	layer types.Layer
+	date  time.Time
+}
+
+// layersSetting is a number of layers.
+type layersSetting uint64
+
+// amountSetting is an amount in smidge, shown in whole SMESH.
+type amountSetting uint64
+
+// text returns the value of the setting as shown in the report, with dates as yyyy-mm-dd.
+func (s setting) text(p *message.Printer) string {
+	switch v := s.value.(type) {
+	case layerSetting:
+		return p.Sprintf("%d (%s)", v.layer, v.date.Format("2006-01-02"))
+	case layersSetting:
+		return p.Sprintf("%d layers", uint64(v))
+	case amountSetting:
+		return p.Sprintf("%d SMESH", uint64(v)/constants.OneSmesh)
+	case time.Time:
+		return v.Format("2006-01-02")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// simulationSettings lists the parameters of a simulation run, followed by the scenarios it was run with, as given on
+// the command line.
+func simulationSettings(cfg simulation.Config, scenarios []setting) []setting {
+	vest := vesting.Mainnet
+	if cfg.Vesting != nil {
+		vest = *cfg.Vesting
+	}
+	clock := cfg.LayerClock()
+	layer := func(layer types.Layer) layerSetting {
+		return layerSetting{layer, clock.LayerTime(layer)}
+	}
+	settings := []setting{
+		{"genesis", cfg.Genesis},
+		{"effectiveGenesis", layer(cfg.EffectiveGenesis)},
+		{"tickInterval", layersSetting(cfg.TickInterval)},
+		{"endLayer", layer(cfg.EndLayer)},
+		{"totalIssuance", amountSetting(constants.TotalIssuance)},
+		{"tenYearTarget", amountSetting(constants.TenYearTarget)},
+		{"totalVaulted", amountSetting(vest.TotalVaulted)},
+		{"vestedAtCliff", amountSetting(vest.VestedAtCliff)},
+		{"vestStart", layer(vest.VestStart)},
+		{"vestEnd", layer(vest.VestEnd)},
+	}
+	for _, s := range scenarios {
+		if s.value == "" {
+			s.value = "none"
+		}
+		settings = append(settings, s)
+	}
+	return settings
+}
+
+func settingColumns() []column[setting] {
+	p := message.NewPrinter(language.English)
+	return []column[setting]{
+		{
+			name:  "parameter",
+			cell:  func(_ *printer, s setting) any { return s.name },
+			value: func(s setting) any { return s.name },
+		},
+		{
+			name:  "value",
+			cell:  func(_ *printer, s setting) any { return s.text(p) },
+			value: func(s setting) any { return s.text(p) },
+		},
+	}
+}
+
+// settingSheetColumns are the columns of the parameters in the spreadsheet: the value as in the report, followed by
+// whichever of the number of layers (or the layer), the amount in SMESH and the date it has, as cells of their type.
+func settingSheetColumns() []column[setting] {
+	amount := func(s setting) uint64 {
+		a, _ := s.value.(amountSetting)
+		return uint64(a)
+	}
+	date := func(s setting) time.Time {
+		switch v := s.value.(type) {
+		case layerSetting:
+			return v.date
+		case time.Time:
+			return v
+		}
+		return time.Time{}
+	}
+	return append(settingColumns(),
+		column[setting]{
+			name: "layers",
+			value: func(s setting) any {
+				switch v := s.value.(type) {
+				case layerSetting:
+					return uint64(v.layer)
+				case layersSetting:
+					return uint64(v)
+				}
+				return nil
+			},
+		},
+		column[setting]{
+			name:   "smesh",
+			amount: amount,
+			value: func(s setting) any {
+				if _, ok := s.value.(amountSetting); !ok {
+					return nil
+				}
+				return amount(s)
+			},
+		},
+		column[setting]{
+			name: "date",
+			date: date,
+			value: func(s setting) any {
+				if date(s).IsZero() {
+					return nil
+				}
+				return date(s).Format("2006-01-02")
+			},
+		},
+	)
+}
+
+// milestone is a notable layer of the emission schedule.
+type milestone struct {
+	name  string
+	layer types.Layer
+	date  time.Time
+}
+
+// issuanceMilestones are the percentages of final issuance reported as milestones.
+var issuanceMilestones = []uint64{10, 25, 50, 75, 90}
+
+// simulationMilestones returns the milestones reached in a simulation run, in order of layer. Milestones of the
+// schedule are exact; percentages of final issuance are as of the first tick at which they're reached, so that they
+// agree with the table of ticks.
+func simulationMilestones(cfg simulation.Config, result simulation.Result) []milestone {
+	clock := cfg.LayerClock()
+	vest := vesting.Mainnet
+	if cfg.Vesting != nil {
+		vest = *cfg.Vesting
+	}
+	var milestones []milestone
+	add := func(name string, layer types.Layer) {
+		if layer <= cfg.EndLayer {
+			milestones = append(milestones, milestone{name, layer, clock.LayerTime(layer)})
+		}
+	}
+	add("genesis", 0)
+	add("issuance begins (effective genesis)", cfg.EffectiveGenesis)
+	add("vesting cliff", vest.VestStart)
+	add("vault fully vested", vest.VestEnd)
+	if result.BurnExceedsSubsidy {
+		add("burn first exceeds subsidy", result.BurnExceedsSubsidyLayer)
+	}
+	next := 0
+	for _, s := range result.Ticks {
+		for next < len(issuanceMilestones) && s.IssuanceTotal >= issuanceMilestones[next]*(constants.TotalIssuance/100) {
+			milestones = append(milestones, milestone{
+				name:  fmt.Sprintf("%d%% of final issuance", issuanceMilestones[next]),
+				layer: s.Layer,
+				date:  s.Date,
+			})
+			next++
+		}
+	}
+	sort.SliceStable(milestones, func(i, j int) bool { return milestones[i].layer < milestones[j].layer })
+	return milestones
+}
+
+func milestoneColumns() []column[milestone] {
+	return []column[milestone]{
+		{
+			name:  "milestone",
+			cell:  func(_ *printer, m milestone) any { return m.name },
+			value: func(m milestone) any { return m.name },
+		},
+		{
+			name:  "layer",
+			align: text.AlignRight,
+			cell:  func(_ *printer, m milestone) any { return uint64(m.layer) },
+			value: func(m milestone) any { return uint64(m.layer) },
+		},
+		{
+			name:  "epoch",
+			align: text.AlignRight,
+			cell:  func(_ *printer, m milestone) any { return uint64(m.layer.Epoch()) },
+			value: func(m milestone) any { return uint64(m.layer.Epoch()) },
+		},
+		dateColumn("date", func(m milestone) time.Time { return m.date }),
+	}
+}
+
+type htmlCell struct {
+	Text  string
+	Right bool
+}
+
+// htmlTable is a table of the report. Cells are formatted as in the table output format, so figures agree with it.
+type htmlTable struct {
+	Title  string
+	Header []htmlCell
+	Rows   [][]htmlCell
+	Notes  []string
+}
+
+// newHTMLTable returns a table of the given rows. The caption is a list of notes, one per line, as shown below a table
+// in the table output format.
+func newHTMLTable[T any](title string, p *printer, columns []column[T], rows []T, caption string) htmlTable {
+	t := htmlTable{Title: title, Header: make([]htmlCell, len(columns)), Rows: make([][]htmlCell, len(rows))}
+	for i, c := range columns {
+		t.Header[i] = htmlCell{c.name, c.align == text.AlignRight}
+	}
+	for i, r := range rows {
+		t.Rows[i] = make([]htmlCell, len(columns))
+		for j, c := range columns {
+			t.Rows[i][j] = htmlCell{strings.TrimSpace(fmt.Sprint(c.cell(p, r))), c.align == text.AlignRight}
+		}
+	}
+	for _, line := range strings.Split(caption, "\n") {
+		if note, ok := strings.CutPrefix(line, "- "); ok {
+			t.Notes = append(t.Notes, note)
+		}
+	}
+	return t
+}
+
+// htmlChart is a chart of the report, as inline SVG.
+type htmlChart struct {
+	Title string
+	SVG   template.HTML
+}
+
+// report is a self-contained HTML page describing a simulation run.
+type report struct {
+	Title      string
+	Parameters htmlTable
+	Milestones htmlTable
+	Charts     []htmlChart
+	Years      htmlTable
+	Ticks      htmlTable
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`{{define "table"}}<h2>{{.Title}}</h2>
+<div class="scroll">
+<table>
+<thead>
+<tr>{{range .Header}}<th{{if .Right}} class="right"{{end}}>{{.Text}}</th>{{end}}</tr>
+</thead>
+<tbody>
+{{range .Rows}}<tr>{{range .}}<td{{if .Right}} class="right"{{end}}>{{.Text}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</div>
+{{with .Notes}}<ul class="notes">
+{{range .}}<li>{{.}}</li>
+{{end}}</ul>
+{{end}}{{end}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; color: #333333; margin: 2em auto; max-width: 1200px; padding: 0 1em; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.25em; margin-top: 2em; border-bottom: 1px solid #dddddd; }
+table { border-collapse: collapse; }
+th, td { padding: 0.2em 0.6em; border-bottom: 1px solid #eeeeee; white-space: nowrap; text-align: left; }
+th { background: #f5f5f5; }
+.right { text-align: right; font-variant-numeric: tabular-nums; }
+.scroll { overflow-x: auto; }
+.notes { color: #666666; font-size: 0.9em; }
+.chart svg { max-width: 100%; height: auto; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{template "table" .Parameters}}
+{{template "table" .Milestones}}
+<h2>Charts</h2>
+{{range .Charts}}<div class="chart" title="{{.Title}}">
+{{.SVG}}</div>
+{{else}}<p>A chart takes at least two ticks.</p>
+{{end}}
+{{template "table" .Years}}
+{{template "table" .Ticks}}
+</body>
+</html>
+`))
+
+// writeReport writes a simulation run as a single HTML page: the parameters, the milestones reached, charts, the
+// summary per calendar year and the table of ticks. Tables are those of the table output format, and charts are those
+// written with -charts.
+func writeReport(w io.Writer, settings []setting, milestones []milestone, opts renderOptions,
+	result simulation.Result,
+) error {
+	ticks, err := selectTickColumns(opts)
+	if err != nil {
+		return err
+	}
+	p := newPrinter(opts.locale, opts.unit)
+	r := report{
+		Title:      "Spacemesh emission schedule",
+		Parameters: newHTMLTable("Parameters", p, settingColumns(), settings, ""),
+		Milestones: newHTMLTable("Milestones", p, milestoneColumns(), milestones, "Please note:\n"+
+			"- Percentages of final issuance are as of the first tick at which they're reached\n"),
+		Years: newHTMLTable("Summary per calendar year", p, yearColumns(), result.Years, "Please note:\n"+
+			"- All figures in "+opts.unit.description()+"\n"+
+			"- Inflation is annualized over the layers simulated in each calendar year\n"),
+		Ticks: newHTMLTable("Ticks", p, ticks, result.Ticks, tickCaption(opts)),
+	}
+	var charts []namedChart
+	if len(result.Ticks) >= minChartTicks {
+		charts = supplyCharts(result.Ticks)
+	}
+	for _, c := range charts {
+		var buf bytes.Buffer
+		if err := c.chart.SVG(&buf); err != nil {
+			return fmt.Errorf("chart %s: %w", c.name, err)
+		}
+		// the SVG is generated by us, with text escaped
+		r.Charts = append(r.Charts, htmlChart{Title: c.chart.Title, SVG: template.HTML(buf.String())})
+	}
+	return reportTemplate.Execute(w, r)
+}
+
+// writeReportFile writes the report to a file.
+func writeReportFile(path string, settings []setting, milestones []milestone, opts renderOptions,
+	result simulation.Result,
+) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, settings, milestones, opts, result); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
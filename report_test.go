@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+func Test_Report(t *testing.T) {
+	cfg := testConfig(constants.VestStart)
+	result := simulation.Run(cfg, nil)
+	milestones := simulationMilestones(cfg, result)
+	require.Len(t, milestones, 3)
+	assert.Equal(t, "issuance begins (effective genesis)", milestones[1].name)
+	assert.Equal(t, "vesting cliff", milestones[2].name)
+	assert.Equal(t, types.Layer(constants.VestStart), milestones[2].layer)
+	assert.Equal(t, simulation.LayerTime(defaultGenesisDate, constants.VestStart), milestones[2].date)
+
+	settings := simulationSettings(cfg, []setting{{"fees", ""}, {"burn", "fraction:<0.5>"}})
+	assert.Equal(t, setting{"fees", "none"}, settings[len(settings)-2])
+
+	// layers are dated by the clock of the run, as are the milestones
+	late := simulation.LayerTime(defaultGenesisDate, effectiveGenesis).AddDate(0, 0, 30)
+	observed, err := simulation.NewObservedClock(defaultGenesisDate,
+		[]simulation.Observation{{Layer: effectiveGenesis, Time: late}})
+	require.NoError(t, err)
+	observedCfg := cfg
+	observedCfg.Clock = observed
+	assert.Contains(t, simulationSettings(observedCfg, nil), setting{"effectiveGenesis", layerSetting{8064, late}})
+	english := message.NewPrinter(language.English)
+	assert.Equal(t, fmt.Sprintf("8,064 (%s)", late.Format("2006-01-02")),
+		setting{"effectiveGenesis", layerSetting{8064, late}}.text(english))
+	assert.Equal(t, "600,000,000 SMESH", setting{"tenYearTarget", amountSetting(constants.TenYearTarget)}.text(english))
+
+	var buf bytes.Buffer
+	require.NoError(t, writeReport(&buf, settings, milestones, renderOptions{}, result))
+	html := buf.String()
+	for _, title := range []string{"Parameters", "Milestones", "Charts", "Summary per calendar year", "Ticks"} {
+		assert.Contains(t, html, "<h2>"+title+"</h2>")
+	}
+	assert.Equal(t, 4, strings.Count(html, "<svg "))
+	assert.Contains(t, html, "fraction:&lt;0.5&gt;")
+	assert.Contains(t, html, `<td class="right">105120</td><td class="right">26</td><td>2024-07-13</td>`)
+	// one row per milestone, parameter, year and tick
+	assert.Equal(t, len(milestones)+len(settings)+len(result.Years)+len(result.Ticks), strings.Count(html, "<tr><td"))
+
+	// the figures are as in the table output format
+	buf.Reset()
+	require.NoError(t, render(&buf, formatTable, renderOptions{}, result))
+	last := result.Ticks[len(result.Ticks)-1]
+	circulating := message.NewPrinter(language.English).Sprintf("%d", last.CirculatingTotal/constants.OneSmesh)
+	assert.Contains(t, buf.String(), circulating)
+	assert.Contains(t, html, `<td class="right">`+circulating+"</td>")
+
+	// a single tick has no charts
+	cfg.EndLayer = 0
+	result = simulation.Run(cfg, nil)
+	require.Len(t, result.Ticks, 1)
+	buf.Reset()
+	require.NoError(t, writeReport(&buf, settings, simulationMilestones(cfg, result), renderOptions{}, result))
+	assert.NotContains(t, buf.String(), "<svg ")
+	assert.Contains(t, buf.String(), "A chart takes at least two ticks.")
+}
@@ -0,0 +1,129 @@
+package rewards
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkLayer asserts the invariants that should hold for a schedule at any layer.
+func checkLayer(t *testing.T, s *Schedule, layerID types.EffectiveLayer) {
+	total, ok := s.TotalSubsidy.Uint64()
+	require.True(t, ok)
+
+	accumulated := s.TotalAccumulatedSubsidyAtLayer(layerID)
+	subsidy := s.TotalSubsidyAtLayer(layerID)
+	require.LessOrEqual(t, accumulated, total, "expected accumulated subsidy at layer %d not to exceed total", layerID)
+	require.LessOrEqual(t, subsidy, accumulated)
+
+	// round down: the rounded figure is the integer part of the unrounded one
+	unrounded := s.getUnroundedAccumulatedSubsidy(layerID)
+	require.GreaterOrEqual(t, unrounded.Cmp(decimal.WithContext(Ctx).SetUint64(accumulated)), 0)
+	require.Equal(t, -1, unrounded.Cmp(decimal.WithContext(Ctx).SetUint64(accumulated+1)),
+		"expected layer %d subsidy %d to be rounded down from %f", layerID, accumulated, unrounded)
+
+	if layerID > 0 {
+		// monotone and additive
+		prev := s.TotalAccumulatedSubsidyAtLayer(layerID - 1)
+		require.LessOrEqual(t, prev, accumulated, "expected accumulated subsidy to be monotone at layer %d", layerID)
+		require.Equal(t, accumulated-prev, subsidy, "expected layer %d subsidy to be the accumulated difference", layerID)
+
+		// non-increasing, to within rounding of the accumulated figures
+		require.LessOrEqual(t, subsidy, s.TotalSubsidyAtLayer(layerID-1)+1,
+			"expected layer %d subsidy to be non-increasing", layerID)
+	}
+}
+
+func FuzzMainnetSubsidy(f *testing.F) {
+	finalLayer, _ := FinalLayer.Uint64()
+	for _, layerID := range []uint64{
+		0, 1, 10*constants.OneYear - 1, 10 * constants.OneYear, finalLayer, finalLayer + 1,
+		math.MaxUint32 - 1, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64 - 1, math.MaxUint64,
+	} {
+		f.Add(layerID, uint8(4))
+	}
+	f.Fuzz(func(t *testing.T, layer uint64, span uint8) {
+		layerID := types.EffectiveLayer(layer)
+		checkLayer(t, Mainnet, layerID)
+
+		// the sum of per-layer subsidy over a span equals the difference in accumulated subsidy
+		end := layerID + types.EffectiveLayer(span%32)
+		if end < layerID {
+			end = math.MaxUint64
+		}
+		var sum uint64
+		for l := layerID; ; l++ {
+			sum += TotalSubsidyAtLayer(l)
+			if l == end {
+				break
+			}
+		}
+		var prev uint64
+		if layerID > 0 {
+			prev = TotalAccumulatedSubsidyAtLayer(layerID - 1)
+		}
+		require.Equal(t, TotalAccumulatedSubsidyAtLayer(end)-prev, sum,
+			"expected subsidy over layers %d-%d to be additive", layerID, end)
+	})
+}
+
+func FuzzSchedule(f *testing.F) {
+	f.Add(uint64(constants.TotalSubsidy), uint64(constants.TenYearTarget-constants.TotalVaulted), uint64(0))
+	f.Add(uint64(constants.TotalSubsidy), uint64(1), uint64(math.MaxUint32))
+	f.Add(uint64(constants.TotalSubsidy), uint64(1), uint64(math.MaxUint64))
+	f.Add(uint64(constants.TotalSubsidy), uint64(constants.TotalSubsidy-1), uint64(1))
+	f.Add(uint64(math.MaxUint64), uint64(math.MaxUint64/2), uint64(10*constants.OneYear))
+	f.Add(uint64(2), uint64(1), uint64(12345))
+	f.Fuzz(func(t *testing.T, totalSubsidy, tenYearSubsidy, layer uint64) {
+		if tenYearSubsidy == 0 || tenYearSubsidy >= totalSubsidy {
+			t.Skip("invalid schedule")
+		}
+		s := NewSchedule(totalSubsidy, tenYearSubsidy)
+		checkLayer(t, s, types.EffectiveLayer(layer))
+
+		// the ten year target is met to within one smidge of rounding
+		tenYears := s.TotalAccumulatedSubsidyAtLayer(10 * constants.OneYear)
+		assert.LessOrEqual(t, tenYears, tenYearSubsidy)
+		assert.LessOrEqual(t, tenYearSubsidy-tenYears, uint64(1))
+	})
+}
+
+func Test_MainnetSchedule(t *testing.T) {
+	// the generic constructor agrees with the package-level mainnet figures
+	s := NewSchedule(constants.TotalSubsidy, constants.TenYearTarget-constants.TotalVaulted)
+	assert.Equal(t, 0, s.HalfLife.Cmp(HalfLife))
+	assert.Equal(t, 0, s.Lambda.Cmp(Lambda))
+	assert.Equal(t, 0, s.FinalLayer.Cmp(FinalLayer))
+	assert.Equal(t, TotalAccumulatedSubsidyAtLayer(1000), s.TotalAccumulatedSubsidyAtLayer(1000))
+}
+
+func Test_SubsidyProperties(t *testing.T) {
+	err := quick.Check(func(layerID types.EffectiveLayer) bool {
+		checkLayer(t, Mainnet, layerID)
+		return !t.Failed()
+	}, &quick.Config{MaxCount: 200})
+	assert.NoError(t, err)
+
+	// the above mostly samples layers far beyond the final layer, so also sample the first few hundred years
+	err = quick.Check(func(layerID uint32) bool {
+		checkLayer(t, Mainnet, types.EffectiveLayer(layerID>>4))
+		return !t.Failed()
+	}, &quick.Config{MaxCount: 200})
+	assert.NoError(t, err)
+
+	// later layers never accumulate less, nor issue (materially) more per layer
+	err = quick.Check(func(a, b types.EffectiveLayer) bool {
+		if a > b {
+			a, b = b, a
+		}
+		return TotalAccumulatedSubsidyAtLayer(a) <= TotalAccumulatedSubsidyAtLayer(b) &&
+			TotalSubsidyAtLayer(b) <= TotalSubsidyAtLayer(a)+1
+	}, &quick.Config{MaxCount: 200})
+	assert.NoError(t, err)
+}
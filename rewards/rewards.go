@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
 
 	"github.com/ericlagergren/decimal"
 )
@@ -24,21 +25,67 @@ var (
 	TotalSubsidy      = decimal.WithContext(Ctx).SetUint64(constants.TotalSubsidy)
 	FinalIssuanceFrac = Ctx.Quo(decimal.WithContext(Ctx), Ctx.Sub(decimal.WithContext(Ctx), TotalSubsidy, One), TotalSubsidy)
 	FinalLayer        = Ctx.Quo(decimal.WithContext(Ctx), Ctx.Log(decimal.WithContext(Ctx), Ctx.Sub(decimal.WithContext(Ctx), One, FinalIssuanceFrac)), NegLambda)
+
+	// Mainnet is the subsidy schedule defined by the mainnet constants.
+	Mainnet = &Schedule{TotalSubsidy: TotalSubsidy, HalfLife: HalfLife, Lambda: Lambda, FinalLayer: FinalLayer}
 )
 
-func getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis uint32) *decimal.Big {
-	// add one because layers are zero-indexed and we want > 0 issuance in the first effective genesis layer
-	layerCount := decimal.WithContext(Ctx).SetUint64(uint64(layersAfterEffectiveGenesis + 1))
-	expInner := Ctx.Mul(decimal.WithContext(Ctx), NegLambda, layerCount)
+// Schedule is an exponentially decaying subsidy curve. The package-level functions use the Mainnet schedule.
+type Schedule struct {
+	// TotalSubsidy is the total amount of subsidy, in smidge, issued over the life of the curve
+	TotalSubsidy *decimal.Big
+
+	// HalfLife is the number of layers it takes to issue half of the remaining subsidy
+	HalfLife *decimal.Big
+
+	// Lambda is the decay constant, per layer
+	Lambda *decimal.Big
+
+	// FinalLayer is the layer, post-effective genesis, in which the final smidge is issued
+	FinalLayer *decimal.Big
+}
+
+// NewSchedule returns the schedule that issues totalSubsidy smidge in total, of which tenYearSubsidy are issued in the
+// first ten years post-effective genesis. It requires 0 < tenYearSubsidy < totalSubsidy.
+func NewSchedule(totalSubsidy, tenYearSubsidy uint64) *Schedule {
+	if tenYearSubsidy == 0 || tenYearSubsidy >= totalSubsidy {
+		log.Fatal("ten year subsidy must be positive and less than total subsidy")
+	}
+	total := decimal.WithContext(Ctx).SetUint64(totalSubsidy)
+	fraction := Ctx.Quo(decimal.WithContext(Ctx), decimal.WithContext(Ctx).SetUint64(tenYearSubsidy), total)
+	return newScheduleFromHalfLife(total, halfLifeAt(fraction, TenYears))
+}
+
+func newScheduleFromHalfLife(total, halfLife *decimal.Big) *Schedule {
+	lambda := Ctx.Quo(decimal.WithContext(Ctx), LogTwo, halfLife)
+	finalIssuanceFrac := Ctx.Quo(decimal.WithContext(Ctx), Ctx.Sub(decimal.WithContext(Ctx), total, One), total)
+	finalLayer := Ctx.Quo(decimal.WithContext(Ctx),
+		Ctx.Log(decimal.WithContext(Ctx), Ctx.Sub(decimal.WithContext(Ctx), One, finalIssuanceFrac)),
+		decimal.WithContext(Ctx).Neg(lambda))
+	return &Schedule{TotalSubsidy: total, HalfLife: halfLife, Lambda: lambda, FinalLayer: finalLayer}
+}
+
+// layerCount returns the number of layers, post-effective genesis, up to and including the given one. We add one
+// because layers are zero-indexed and we want > 0 issuance in the first effective genesis layer. The addition is done
+// in decimal so that it doesn't wrap at the last representable layer.
+func layerCount(layersAfterEffectiveGenesis types.EffectiveLayer) *decimal.Big {
+	count := decimal.WithContext(Ctx).SetUint64(uint64(layersAfterEffectiveGenesis))
+	return Ctx.Add(count, count, One)
+}
+
+func (s *Schedule) getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis types.EffectiveLayer) *decimal.Big {
+	expInner := Ctx.Mul(decimal.WithContext(Ctx), decimal.WithContext(Ctx).Neg(s.Lambda),
+		layerCount(layersAfterEffectiveGenesis))
 	expOuter := Ctx.Exp(decimal.WithContext(Ctx), expInner)
 	supplyMultiplier := Ctx.Sub(decimal.WithContext(Ctx), One, expOuter)
-	return Ctx.Mul(decimal.WithContext(Ctx), TotalSubsidy, supplyMultiplier)
+	return Ctx.Mul(decimal.WithContext(Ctx), s.TotalSubsidy, supplyMultiplier)
 }
 
-// TotalAccumulatedSubsidyAtLayer returns the total accumulated block subsidy paid by the protocol as of the given
-// layer, denominated in smidge.
-func TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis uint32) uint64 {
-	unroundedSubsidy := getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis)
+// TotalAccumulatedSubsidyAtLayer returns the total accumulated block subsidy paid according to the schedule as of the
+// given layer, denominated in smidge. Far enough beyond the final layer the remaining subsidy falls below the
+// precision of Ctx, and from then on this returns the total subsidy for every layer up to the last representable one.
+func (s *Schedule) TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	unroundedSubsidy := s.getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis)
 	if ret, ok := unroundedSubsidy.Uint64(); !ok {
 		log.Fatal("unable to convert subsidy to uint")
 		return 0
@@ -47,15 +94,45 @@ func TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis uint32) uint64 {
 	}
 }
 
-// TotalSubsidyAtLayer returns the total subsidy issued in the layer
-func TotalSubsidyAtLayer(layersAfterEffectiveGenesis uint32) uint64 {
-	subsidyAtLayer := TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis)
+// TotalSubsidyAtLayer returns the total subsidy issued in the layer according to the schedule
+func (s *Schedule) TotalSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	subsidyAtLayer := s.TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis)
 	var subsidyPrevLayer uint64
 	if layersAfterEffectiveGenesis > 0 {
-		subsidyPrevLayer = TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis - 1)
+		subsidyPrevLayer = s.TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis - 1)
 	}
 
 	// Calculate as the difference between the total issuance as of the previous layer and the total issuance as of the
 	// current layer
 	return subsidyAtLayer - subsidyPrevLayer
 }
+
+// EmissionRateAtLayer returns the instantaneous rate of issuance at the given layer according to the schedule,
+// denominated in smidge per layer. This is the derivative of the (unrounded) accumulated subsidy curve with respect to
+// layers, i.e., TotalSubsidy * Lambda * exp(-Lambda * (layer + 1)).
+func (s *Schedule) EmissionRateAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) *decimal.Big {
+	expInner := Ctx.Mul(decimal.WithContext(Ctx), decimal.WithContext(Ctx).Neg(s.Lambda),
+		layerCount(layersAfterEffectiveGenesis))
+	expOuter := Ctx.Exp(decimal.WithContext(Ctx), expInner)
+	return Ctx.Mul(decimal.WithContext(Ctx), Ctx.Mul(decimal.WithContext(Ctx), s.TotalSubsidy, s.Lambda), expOuter)
+}
+
+func getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis types.EffectiveLayer) *decimal.Big {
+	return Mainnet.getUnroundedAccumulatedSubsidy(layersAfterEffectiveGenesis)
+}
+
+// TotalAccumulatedSubsidyAtLayer returns the total accumulated block subsidy paid by the protocol as of the given
+// layer, denominated in smidge.
+func TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	return Mainnet.TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis)
+}
+
+// TotalSubsidyAtLayer returns the total subsidy issued in the layer
+func TotalSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64 {
+	return Mainnet.TotalSubsidyAtLayer(layersAfterEffectiveGenesis)
+}
+
+// EmissionRateAtLayer returns the instantaneous rate of issuance at the given layer, denominated in smidge per layer.
+func EmissionRateAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) *decimal.Big {
+	return Mainnet.EmissionRateAtLayer(layersAfterEffectiveGenesis)
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/ericlagergren/decimal"
 	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -22,7 +23,7 @@ func Test_Lambda(t *testing.T) {
 
 func Test_Rounding(t *testing.T) {
 	// check that lambda multiplier is nonzero, make sure it rounds down to nearest uint
-	layerID := uint32(99)
+	layerID := types.EffectiveLayer(99)
 	unrounded := getUnroundedAccumulatedSubsidy(layerID)
 	rounded := TotalAccumulatedSubsidyAtLayer(layerID)
 	assert.Equal(t, 1, unrounded.Cmp(new(decimal.Big).SetUint64(rounded)),
@@ -35,7 +36,7 @@ func Test_Rounding(t *testing.T) {
 
 func Test_Accumulation(t *testing.T) {
 	// check that layer to layer values get added to accumulated total
-	layerID := uint32(99)
+	layerID := types.EffectiveLayer(99)
 	totalAtLayer := TotalAccumulatedSubsidyAtLayer(layerID)
 	nextLayerSubsidy := TotalSubsidyAtLayer(layerID + 1)
 	totalNextLayer := TotalAccumulatedSubsidyAtLayer(layerID + 1)
@@ -47,7 +48,7 @@ func Test_Rounddown(t *testing.T) {
 	// note that there's no way to test the accuracy of the rounddown since the issuance at any given layer is
 	// simply defined to be the difference between the total subsidy at the previous layer and the total subsidy
 	// at the layer
-	startLayerID := uint32(9)
+	startLayerID := types.EffectiveLayer(9)
 	endLayerID := startLayerID + 10
 
 	accumulatedRounddown := decimal.WithContext(Ctx)
@@ -87,9 +88,9 @@ func Test_TenYearIssuance(t *testing.T) {
 
 	// subtract one to make up for the effective genesis layer
 	tenYears := constants.OneYear * 10
-	tenYearsU32 := uint32(tenYears)
-	tenYearSubsidyRaw := getUnroundedAccumulatedSubsidy(tenYearsU32)
-	tenYearSubsidy := TotalAccumulatedSubsidyAtLayer(tenYearsU32)
+	tenYearsLayer := types.EffectiveLayer(tenYears)
+	tenYearSubsidyRaw := getUnroundedAccumulatedSubsidy(tenYearsLayer)
+	tenYearSubsidy := TotalAccumulatedSubsidyAtLayer(tenYearsLayer)
 
 	// we expect to be one smidge short due to rounding
 	assert.Equal(t, expectedTenYearSubsidy, tenYearSubsidy,
@@ -100,7 +101,7 @@ func Test_TenYearIssuance(t *testing.T) {
 // test hardcoded subsidy in sampled layers
 func Test_Subsidy(t *testing.T) {
 	testValues := []struct {
-		layerID              uint32
+		layerID              types.EffectiveLayer
 		expectedSubsidyLayer uint64
 		expectedSubsidyTotal uint64
 	}{
@@ -142,22 +143,20 @@ func Test_Halving(t *testing.T) {
 	ctx.RoundingMode = decimal.ToZero
 	lastLayerBeforeHalflife, ok := decimal.WithContext(ctx).Copy(HalfLife).RoundToInt().Uint64()
 	assert.True(t, ok)
-	lastLayerBeforeHalflifeU32 := uint32(lastLayerBeforeHalflife)
-	assert.Equal(t, lastLayerBeforeHalflife, uint64(lastLayerBeforeHalflifeU32))
+	lastLayerBeforeHalflifeLayer := types.EffectiveLayer(lastLayerBeforeHalflife)
 
 	// subtract one to make up for effective genesis layer
 	// in other words, since we shift all layers +1, half life will occur one layer earlier
-	totalBeforeHalfLife := TotalAccumulatedSubsidyAtLayer(lastLayerBeforeHalflifeU32 - 1)
+	totalBeforeHalfLife := TotalAccumulatedSubsidyAtLayer(lastLayerBeforeHalflifeLayer - 1)
 
 	// expect it to be within the margin
 	assert.Less(t, expectedSubsidyAtHalflife-totalBeforeHalfLife, issuanceMargin)
 	assert.Positive(t, expectedSubsidyAtHalflife-totalBeforeHalfLife)
 
-	firstLayerAfterHalfLifeU32 := uint32(lastLayerBeforeHalflife + 1)
-	assert.Equal(t, lastLayerBeforeHalflife+1, uint64(firstLayerAfterHalfLifeU32))
+	firstLayerAfterHalfLifeLayer := types.EffectiveLayer(lastLayerBeforeHalflife + 1)
 
 	// subtract one to make up for effective genesis layer
-	totalAfterHalfLife := TotalAccumulatedSubsidyAtLayer(firstLayerAfterHalfLifeU32 - 1)
+	totalAfterHalfLife := TotalAccumulatedSubsidyAtLayer(firstLayerAfterHalfLifeLayer - 1)
 
 	// expect it to be within the margin
 	assert.Less(t, totalAfterHalfLife-expectedSubsidyAtHalflife, issuanceMargin)
@@ -169,27 +168,66 @@ func Test_FinalLayer(t *testing.T) {
 	expectedFinalLayer := 199069549
 	finalLayer, ok := FinalLayer.Uint64()
 	assert.True(t, ok)
-	finalLayerUint32 := uint32(finalLayer)
-	assert.Equal(t, finalLayer, uint64(finalLayerUint32))
+	finalLayerID := types.EffectiveLayer(finalLayer)
 
 	// check against hardcoded number
-	assert.Equal(t, uint32(expectedFinalLayer), finalLayerUint32,
-		"expected final layer %d to be %d", finalLayerUint32, expectedFinalLayer)
+	assert.Equal(t, types.EffectiveLayer(expectedFinalLayer), finalLayerID,
+		"expected final layer %d to be %d", finalLayerID, expectedFinalLayer)
 
 	// that final smidge will never be issued since, beyond this point, all issuance will be rounded down to zero
 	expectedFinalTotalIssuance := uint64(constants.TotalSubsidy) - 1
-	subsidyLayer := TotalSubsidyAtLayer(finalLayerUint32)
-	subsidyTotal := TotalAccumulatedSubsidyAtLayer(finalLayerUint32)
+	subsidyLayer := TotalSubsidyAtLayer(finalLayerID)
+	subsidyTotal := TotalAccumulatedSubsidyAtLayer(finalLayerID)
 	assert.Equal(t, uint64(1), subsidyLayer,
-		"expected final layer %d subsidy %d to equal %d", finalLayerUint32, subsidyLayer, 1)
+		"expected final layer %d subsidy %d to equal %d", finalLayerID, subsidyLayer, 1)
 	assert.Equal(t, expectedFinalTotalIssuance, subsidyTotal,
-		"expected final layer %d total subsidy %d to equal %d", finalLayerUint32, subsidyTotal, expectedFinalTotalIssuance)
+		"expected final layer %d total subsidy %d to equal %d", finalLayerID, subsidyTotal, expectedFinalTotalIssuance)
 
 	// one layer later we expect issuance to go to zero
-	subsidyLayerBeyond := TotalSubsidyAtLayer(finalLayerUint32 + 1)
-	subsidyTotalBeyond := TotalAccumulatedSubsidyAtLayer(finalLayerUint32 + 1)
+	subsidyLayerBeyond := TotalSubsidyAtLayer(finalLayerID + 1)
+	subsidyTotalBeyond := TotalAccumulatedSubsidyAtLayer(finalLayerID + 1)
 	assert.Equal(t, uint64(0), subsidyLayerBeyond,
-		"expected final layer +1 %d subsidy %d to equal %d", finalLayerUint32+1, subsidyLayerBeyond, 0)
+		"expected final layer +1 %d subsidy %d to equal %d", finalLayerID+1, subsidyLayerBeyond, 0)
 	assert.Equal(t, expectedFinalTotalIssuance, subsidyTotalBeyond,
-		"expected final layer +1 %d total subsidy %d to equal %d", finalLayerUint32+1, subsidyTotalBeyond, expectedFinalTotalIssuance)
+		"expected final layer +1 %d total subsidy %d to equal %d", finalLayerID+1, subsidyTotalBeyond, expectedFinalTotalIssuance)
+}
+
+// test instantaneous emission rate against the discrete per-layer subsidy
+func Test_EmissionRate(t *testing.T) {
+	for _, layerID := range []types.EffectiveLayer{0, 1000, 1000000, 10000000} {
+		rate, ok := EmissionRateAtLayer(layerID).Float64()
+		assert.True(t, ok)
+
+		// the subsidy in a layer is the integral of the rate over the layer, so the instantaneous rate at the end of
+		// the layer should be slightly lower than the subsidy, within rounding
+		subsidy := float64(TotalSubsidyAtLayer(layerID))
+		assert.Less(t, rate, subsidy+1, "expected rate at layer %d to be less than subsidy", layerID)
+		assert.InEpsilon(t, subsidy, rate, 1e-6, "expected rate at layer %d to approximate subsidy", layerID)
+	}
+
+	// the rate declines monotonically
+	assert.Equal(t, 1, EmissionRateAtLayer(100).Cmp(EmissionRateAtLayer(101)))
+}
+
+// test behavior at the extremes of the layer range
+func Test_Extremes(t *testing.T) {
+	// well beyond the final layer the remaining subsidy is below the precision of the calculation, so the final smidge
+	// is eventually issued and the total stays put from then on
+	for _, layerID := range []types.EffectiveLayer{
+		1000000000, math.MaxUint32, math.MaxUint32 + 1, math.MaxUint64 - 1, math.MaxUint64,
+	} {
+		assert.Equal(t, uint64(constants.TotalSubsidy), TotalAccumulatedSubsidyAtLayer(layerID),
+			"expected total subsidy at layer %d", layerID)
+		assert.Zero(t, TotalSubsidyAtLayer(layerID), "expected no subsidy in layer %d", layerID)
+		rate := EmissionRateAtLayer(layerID)
+		assert.GreaterOrEqual(t, rate.Sign(), 0, "expected non-negative emission rate in layer %d", layerID)
+		assert.Equal(t, -1, rate.Cmp(One), "expected negligible emission rate in layer %d", layerID)
+	}
+
+	// layer counts don't wrap at the boundary of 32-bit layers
+	assert.Less(t, TotalAccumulatedSubsidyAtLayer(math.MaxUint32-1), TotalAccumulatedSubsidyAtLayer(math.MaxUint32)+1)
+	assert.Equal(t, 0, layerCount(math.MaxUint32).Cmp(decimal.WithContext(Ctx).SetUint64(math.MaxUint32+1)))
+	maxCount, ok := decimal.WithContext(Ctx).SetString("18446744073709551616")
+	assert.True(t, ok)
+	assert.Equal(t, 0, layerCount(math.MaxUint64).Cmp(maxCount))
 }
@@ -0,0 +1,175 @@
+package rewards
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spacemeshos/economics/constants"
+
+	"github.com/ericlagergren/decimal"
+)
+
+// Target is a policy goal that pins down the rate of decay of the subsidy curve, given the total subsidy.
+type Target interface {
+	// halfLife returns the half life, in layers, of the curve issuing the given total subsidy that meets the goal,
+	// where the vault has already been issued in full
+	halfLife(totalSubsidy, totalVaulted *decimal.Big) (*decimal.Big, error)
+}
+
+// IssuanceTarget is met when total issuance, including the vault, reaches Issuance smidge after the given number of
+// years post-effective genesis. The mainnet schedule is defined by a ten year issuance target.
+type IssuanceTarget struct {
+	Issuance uint64
+	Years    *decimal.Big
+}
+
+func (t IssuanceTarget) halfLife(totalSubsidy, totalVaulted *decimal.Big) (*decimal.Big, error) {
+	issuance := decimal.WithContext(Ctx).SetUint64(t.Issuance)
+	totalIssuance := Ctx.Add(decimal.WithContext(Ctx), totalSubsidy, totalVaulted)
+	if issuance.Cmp(totalVaulted) <= 0 || issuance.Cmp(totalIssuance) >= 0 {
+		return nil, errors.New("issuance target must be greater than the vault and less than total issuance")
+	}
+	subsidy := Ctx.Sub(decimal.WithContext(Ctx), issuance, totalVaulted)
+	return FractionTarget{Fraction: Ctx.Quo(decimal.WithContext(Ctx), subsidy, totalSubsidy), Years: t.Years}.
+		halfLife(totalSubsidy, totalVaulted)
+}
+
+// FractionTarget is met when the given fraction of the total subsidy has been issued after the given number of years
+// post-effective genesis.
+type FractionTarget struct {
+	Fraction *decimal.Big
+	Years    *decimal.Big
+}
+
+func (t FractionTarget) halfLife(_, _ *decimal.Big) (*decimal.Big, error) {
+	if t.Fraction.Sign() <= 0 || t.Fraction.Cmp(One) >= 0 {
+		return nil, errors.New("fraction of subsidy must be between 0 and 1 (exclusive)")
+	}
+	if t.Years.Sign() <= 0 {
+		return nil, errors.New("years must be positive")
+	}
+
+	// as for the mainnet ten year target, count one extra layer for the effective genesis layer
+	layers := Ctx.Add(decimal.WithContext(Ctx),
+		Ctx.Mul(decimal.WithContext(Ctx), t.Years, decimal.WithContext(Ctx).SetUint64(constants.OneYear)), One)
+	return halfLifeAt(t.Fraction, layers), nil
+}
+
+// HalfLifeTarget is met when half the (remaining) subsidy is issued every given number of years.
+type HalfLifeTarget struct {
+	Years *decimal.Big
+}
+
+func (t HalfLifeTarget) halfLife(_, _ *decimal.Big) (*decimal.Big, error) {
+	if t.Years.Sign() <= 0 {
+		return nil, errors.New("half life must be positive")
+	}
+	return Ctx.Mul(decimal.WithContext(Ctx), t.Years, decimal.WithContext(Ctx).SetUint64(constants.OneYear)), nil
+}
+
+// halfLifeAt returns the half life, in layers, of the curve that issues the given fraction of its total subsidy over
+// the given number of layers.
+func halfLifeAt(fraction, layers *decimal.Big) *decimal.Big {
+	remaining := Ctx.Sub(decimal.WithContext(Ctx), One, fraction)
+	return Ctx.Mul(decimal.WithContext(Ctx), decimal.WithContext(Ctx).Neg(layers),
+		Ctx.Quo(decimal.WithContext(Ctx), LogTwo, Ctx.Log(decimal.WithContext(Ctx), remaining)))
+}
+
+// Solution is the schedule that meets a target, along with the figure it implies for the ten year issuance target.
+type Solution struct {
+	*Schedule
+
+	// TotalVaulted is the amount vaulted at genesis, in smidge
+	TotalVaulted *decimal.Big
+
+	// TenYearIssuance is the (unrounded) total issuance, including the vault, ten years post-effective genesis. It's
+	// the figure that, passed to NewSchedule less the vault, reproduces the schedule.
+	TenYearIssuance *decimal.Big
+}
+
+// Solve returns the schedule that meets the target, given total issuance and the amount vaulted at genesis, in smidge.
+// The vault is issued at genesis, so the total subsidy is the difference between the two.
+func Solve(totalIssuance, totalVaulted uint64, target Target) (Solution, error) {
+	if totalVaulted >= totalIssuance {
+		return Solution{}, errors.New("total vaulted must be less than total issuance")
+	}
+	total := decimal.WithContext(Ctx).SetUint64(totalIssuance - totalVaulted)
+	vaulted := decimal.WithContext(Ctx).SetUint64(totalVaulted)
+	halfLife, err := target.halfLife(total, vaulted)
+	if err != nil {
+		return Solution{}, err
+	}
+	s := newScheduleFromHalfLife(total, halfLife)
+	tenYearSubsidy := s.getUnroundedAccumulatedSubsidy(10 * constants.OneYear)
+	return Solution{
+		Schedule:        s,
+		TotalVaulted:    vaulted,
+		TenYearIssuance: Ctx.Add(decimal.WithContext(Ctx), vaulted, tenYearSubsidy),
+	}, nil
+}
+
+// parseDecimal parses a positive decimal number.
+func parseDecimal(s, name string) (*decimal.Big, error) {
+	d, ok := decimal.WithContext(Ctx).SetString(s)
+	if !ok || !d.IsFinite() || d.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", name, s)
+	}
+	return d, nil
+}
+
+// ParseTarget returns the target described by a spec, one of:
+//
+//	tenyear:<total issuance in SMESH, including the vault, ten years post-effective genesis>
+//	issuance:<total issuance in SMESH, including the vault>:<years post-effective genesis>
+//	fraction:<fraction of total subsidy>:<years post-effective genesis>
+//	halflife:<years>
+func ParseTarget(spec string) (Target, error) {
+	kind, args, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "tenyear", "issuance":
+		amount, years := args, "10"
+		if kind == "issuance" {
+			var ok bool
+			if amount, years, ok = strings.Cut(args, ":"); !ok {
+				return nil, errors.New("usage: issuance:<SMESH>:<years>")
+			}
+		}
+		smesh, err := parseDecimal(amount, "issuance")
+		if err != nil {
+			return nil, err
+		}
+		smidge := Ctx.Mul(decimal.WithContext(Ctx), smesh, decimal.WithContext(Ctx).SetUint64(constants.OneSmesh))
+		issuance, ok := smidge.Uint64()
+		if !ok || !smidge.IsInt() {
+			return nil, fmt.Errorf("invalid issuance %q: must be a whole number of smidge", amount)
+		}
+		y, err := parseDecimal(years, "years")
+		if err != nil {
+			return nil, err
+		}
+		return IssuanceTarget{Issuance: issuance, Years: y}, nil
+	case "fraction":
+		fraction, years, ok := strings.Cut(args, ":")
+		if !ok {
+			return nil, errors.New("usage: fraction:<fraction>:<years>")
+		}
+		f, err := parseDecimal(fraction, "fraction")
+		if err != nil {
+			return nil, err
+		}
+		y, err := parseDecimal(years, "years")
+		if err != nil {
+			return nil, err
+		}
+		return FractionTarget{Fraction: f, Years: y}, nil
+	case "halflife":
+		y, err := parseDecimal(args, "half life")
+		if err != nil {
+			return nil, err
+		}
+		return HalfLifeTarget{Years: y}, nil
+	default:
+		return nil, fmt.Errorf("unknown target %q", spec)
+	}
+}
@@ -0,0 +1,123 @@
+package rewards
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SolveMainnet(t *testing.T) {
+	// solving for the mainnet ten year target reproduces the mainnet schedule exactly
+	solution, err := Solve(constants.TotalIssuance, constants.TotalVaulted,
+		IssuanceTarget{Issuance: constants.TenYearTarget, Years: decimal.New(10, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, solution.HalfLife.Cmp(HalfLife))
+	assert.Equal(t, 0, solution.Lambda.Cmp(Lambda))
+	assert.Equal(t, 0, solution.FinalLayer.Cmp(FinalLayer))
+
+	// and the implied ten year figure is the target, to well within a smidge
+	diff := Ctx.Sub(decimal.WithContext(Ctx), solution.TenYearIssuance,
+		decimal.WithContext(Ctx).SetUint64(constants.TenYearTarget))
+	assert.Equal(t, -1, diff.Abs(diff).Cmp(decimal.New(1, 6)))
+}
+
+// roundTrip checks that the ten year figure implied by a solution, rounded to the smidge, reproduces the schedule.
+func roundTrip(t *testing.T, solution Solution) {
+	tenYear := Ctx.RoundToInt(decimal.WithContext(Ctx).Copy(solution.TenYearIssuance))
+	issuance, ok := tenYear.Uint64()
+	require.True(t, ok)
+	vaulted, _ := solution.TotalVaulted.Uint64()
+	total, _ := solution.TotalSubsidy.Uint64()
+	s := NewSchedule(total, issuance-vaulted)
+
+	// rounding the ten year figure moves lambda by on the order of one part in the ten year subsidy
+	diff := Ctx.Sub(decimal.WithContext(Ctx), s.Lambda, solution.Lambda)
+	relative, _ := Ctx.Quo(decimal.WithContext(Ctx), diff.Abs(diff), solution.Lambda).Float64()
+	assert.Less(t, relative, 10/float64(issuance-vaulted))
+	for _, layerID := range []types.EffectiveLayer{0, constants.OneYear, 10 * constants.OneYear, 100 * constants.OneYear} {
+		expected := solution.TotalAccumulatedSubsidyAtLayer(layerID)
+		actual := s.TotalAccumulatedSubsidyAtLayer(layerID)
+		assert.LessOrEqual(t, math.Abs(float64(expected)-float64(actual)), 1.0, "layer %d", layerID)
+	}
+}
+
+func Test_Solve(t *testing.T) {
+	// half of the subsidy in five years is a half life of five years (plus the effective genesis layer)
+	solution, err := Solve(constants.TotalIssuance, constants.TotalVaulted,
+		FractionTarget{Fraction: decimal.New(5, 1), Years: decimal.New(5, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, solution.HalfLife.Cmp(decimal.New(5*constants.OneYear+1, 0)))
+	half := solution.TotalAccumulatedSubsidyAtLayer(5 * constants.OneYear)
+	assert.LessOrEqual(t, constants.TotalSubsidy/2-half, uint64(1))
+	roundTrip(t, solution)
+
+	// a fraction over a fractional number of years
+	solution, err = Solve(constants.TotalIssuance, constants.TotalVaulted,
+		FractionTarget{Fraction: decimal.New(25, 2), Years: decimal.New(25, 1)})
+	require.NoError(t, err)
+	quarter := solution.TotalAccumulatedSubsidyAtLayer(5 * constants.OneYear / 2)
+	assert.LessOrEqual(t, constants.TotalSubsidy/4-quarter, uint64(1))
+	roundTrip(t, solution)
+
+	// an eight year half life
+	solution, err = Solve(constants.TotalIssuance, constants.TotalVaulted, HalfLifeTarget{Years: decimal.New(8, 0)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, solution.HalfLife.Cmp(decimal.New(8*constants.OneYear, 0)))
+	half = solution.TotalAccumulatedSubsidyAtLayer(8*constants.OneYear - 1)
+	assert.LessOrEqual(t, constants.TotalSubsidy/2-half, uint64(1))
+	roundTrip(t, solution)
+
+	// a different vault and issuance target
+	solution, err = Solve(1000*constants.OneSmesh, 100*constants.OneSmesh,
+		IssuanceTarget{Issuance: 400 * constants.OneSmesh, Years: decimal.New(3, 0)})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, 300*constants.OneSmesh-solution.TotalAccumulatedSubsidyAtLayer(3*constants.OneYear), uint64(1))
+	roundTrip(t, solution)
+
+	for _, target := range []Target{
+		IssuanceTarget{Issuance: constants.TotalVaulted, Years: decimal.New(10, 0)},
+		IssuanceTarget{Issuance: constants.TotalIssuance, Years: decimal.New(10, 0)},
+		FractionTarget{Fraction: decimal.New(0, 0), Years: decimal.New(10, 0)},
+		FractionTarget{Fraction: decimal.New(1, 0), Years: decimal.New(10, 0)},
+		FractionTarget{Fraction: decimal.New(5, 1), Years: decimal.New(0, 0)},
+		HalfLifeTarget{Years: decimal.New(-1, 0)},
+	} {
+		_, err := Solve(constants.TotalIssuance, constants.TotalVaulted, target)
+		assert.Error(t, err, "%+v", target)
+	}
+	_, err = Solve(constants.TotalVaulted, constants.TotalVaulted, HalfLifeTarget{Years: decimal.New(1, 0)})
+	assert.Error(t, err)
+}
+
+func Test_ParseTarget(t *testing.T) {
+	target, err := ParseTarget("tenyear:600000000")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(constants.TenYearTarget), target.(IssuanceTarget).Issuance)
+	assert.Equal(t, 0, target.(IssuanceTarget).Years.Cmp(decimal.New(10, 0)))
+
+	target, err = ParseTarget("issuance:400.5:3")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4005*constants.OneSmesh/10), target.(IssuanceTarget).Issuance)
+
+	target, err = ParseTarget("fraction:0.5:7.5")
+	require.NoError(t, err)
+	assert.Equal(t, 0, target.(FractionTarget).Fraction.Cmp(decimal.New(5, 1)))
+	assert.Equal(t, 0, target.(FractionTarget).Years.Cmp(decimal.New(75, 1)))
+
+	target, err = ParseTarget("halflife:8")
+	require.NoError(t, err)
+	assert.Equal(t, 0, target.(HalfLifeTarget).Years.Cmp(decimal.New(8, 0)))
+
+	for _, spec := range []string{
+		"", "bogus:1", "tenyear:", "tenyear:-1", "tenyear:0.0000000001", "issuance:1", "fraction:0.5",
+		"fraction:x:1", "halflife:0", "halflife:inf",
+	} {
+		_, err := ParseTarget(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spacemeshos/economics/income"
+	"github.com/spacemeshos/economics/roi"
+
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// stringList is a flag that may be given more than once.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// roiCommand analyzes the economic viability of smeshing under one or more price scenarios.
+func roiCommand(args []string) {
+	fs := flag.NewFlagSet("roi", flag.ExitOnError)
+	config := incomeFlags(fs, 5*26)
+	var costs roi.Costs
+	fs.Float64Var(&costs.Hardware, "hardware", 0, "upfront cost of hardware")
+	fs.Float64Var(&costs.Initialization, "init", 0, "upfront cost of initializing PoST data")
+	fs.Float64Var(&costs.PowerWatts, "watts", 0, "average power drawn while smeshing, in watts")
+	fs.Float64Var(&costs.ElectricityPrice, "kwh", 0, "price of electricity per kWh")
+	discountRate := fs.Float64("discount", 0.1, "annual discount rate for NPV")
+	var prices stringList
+	fs.Var(&prices, "price", "SMESH price scenario, as [name=]constant:<price>, [name=]exponential:<price>:<annual "+
+		"growth> or [name=]file:<csv path>; may be given more than once")
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	_ = fs.Parse(args)
+
+	cfg := config()
+	if len(prices) == 0 {
+		log.Fatal("missing price scenario")
+	}
+	if err := costs.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	if err := roi.ValidateDiscountRate(*discountRate); err != nil {
+		log.Fatal(err)
+	}
+	projection, err := income.Project(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(projection) == 0 {
+		log.Fatal("empty projection")
+	}
+
+	// parametric prices are given as of the start of the projection
+	since := projection[0].Date
+	results := make([]roi.Result, len(prices))
+	for i, scenario := range prices {
+		name, spec, named := strings.Cut(scenario, "=")
+		if !named {
+			name, spec = scenario, scenario
+		}
+		price, err := roi.Parse(spec, since)
+		if err != nil {
+			log.Fatal(err)
+		}
+		results[i] = roi.Analyze(projection, costs, *discountRate, name, price)
+	}
+	if err := renderROI(os.Stdout, *format, results); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func currencyColumn(name string, amount func(r roi.Result) float64) column[roi.Result] {
+	return column[roi.Result]{
+		name:  name,
+		align: text.AlignRight,
+		cell:  func(p *printer, r roi.Result) any { return p.Sprintf("%.2f", amount(r)) },
+		value: func(r roi.Result) any { return amount(r) },
+	}
+}
+
+func roiColumns() []column[roi.Result] {
+	return []column[roi.Result]{
+		{
+			name:  "scenario",
+			cell:  func(_ *printer, r roi.Result) any { return r.Scenario },
+			value: func(r roi.Result) any { return r.Scenario },
+		},
+		currencyColumn("revenue", func(r roi.Result) float64 { return r.Revenue }),
+		currencyColumn("cost", func(r roi.Result) float64 { return r.Cost }),
+		{
+			name: "breakEven",
+			cell: func(p *printer, r roi.Result) any {
+				if !r.BrokeEven {
+					return "never"
+				}
+				return p.date(r.BreakEven)
+			},
+			value: func(r roi.Result) any {
+				if !r.BrokeEven {
+					return nil
+				}
+				return r.BreakEven.Format("2006-01-02")
+			},
+		},
+		currencyColumn("npv", func(r roi.Result) float64 { return r.NPV }),
+		rateColumn("irr", true, func(r roi.Result) (float64, bool) { return r.IRR, r.IRRDefined }),
+	}
+}
+
+func renderROI(w io.Writer, format string, results []roi.Result) error {
+	columns := roiColumns()
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, results, "Please note:\n"+
+			"- Revenue, cost and NPV are in the currency of the price scenario\n"+
+			"- Rewards are expected values and are sold at the end of each epoch\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, results)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[roi.Result]{{"scenarios", columns, results}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,283 @@
+// Package roi analyzes the economic viability of smeshing: given a smesher's projected income, its costs and a
+// scenario for the price of SMESH, it finds the date at which the smesher breaks even, the net present value (NPV) of
+// smeshing and its internal rate of return (IRR). Prices and costs are denominated in the same (fiat) currency.
+package roi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/income"
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/simulation"
+)
+
+// epochDuration is the nominal duration of an epoch.
+const epochDuration = constants.OneEpoch * simulation.OneLayer
+
+// Price returns the price of one SMESH at a point in time.
+type Price interface {
+	PriceAt(date time.Time) float64
+}
+
+// Constant is a fixed price.
+type Constant float64
+
+func (c Constant) PriceAt(time.Time) float64 { return float64(c) }
+
+// Exponential is a price of Start as of Since that grows continuously at the annual rate Growth. A negative growth is
+// allowed.
+type Exponential struct {
+	Start  float64
+	Growth float64
+	Since  time.Time
+}
+
+func (e Exponential) PriceAt(date time.Time) float64 {
+	years := float64(date.Sub(e.Since)) / float64(constants.OneYear*simulation.OneLayer)
+	return e.Start * math.Pow(1+e.Growth, years)
+}
+
+// Record is the price as of a date.
+type Record struct {
+	Date  time.Time
+	Price float64
+}
+
+// Table is a price known as of certain dates. The price at any time is that of the latest record at or before it, or
+// that of the first record if there's none.
+type Table struct {
+	records []Record
+}
+
+// NewTable returns a table of the given records. There must be at least one, and each date may appear at most once.
+func NewTable(records []Record) (*Table, error) {
+	if len(records) == 0 {
+		return nil, errors.New("no price records")
+	}
+	sorted, err := keyed.Sort(records, func(a, b Record) bool { return a.Date.Before(b.Date) }, func(r Record) error {
+		return fmt.Errorf("duplicate price record for %s", r.Date.Format("2006-01-02"))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Table{records: sorted}, nil
+}
+
+func (t *Table) PriceAt(date time.Time) float64 {
+	i := sort.Search(len(t.records), func(i int) bool { return t.records[i].Date.After(date) })
+	if i == 0 {
+		return t.records[0].Price
+	}
+	return t.records[i-1].Price
+}
+
+// ReadCSV reads a table of prices from CSV with a header row and the columns date (YYYY-MM-DD) and price.
+func ReadCSV(r io.Reader) (*Table, error) {
+	date := keyed.Column[time.Time]{Name: "date", Parse: func(s string) (time.Time, error) {
+		return time.Parse("2006-01-02", s)
+	}}
+	records, err := keyed.ReadCSV(r, date, keyed.Column[float64]{Name: "price", Parse: parsePrice},
+		func(date time.Time, price float64) Record { return Record{Date: date, Price: price} })
+	if err != nil {
+		return nil, err
+	}
+	return NewTable(records)
+}
+
+// parsePrice parses a price, which may be zero but not negative.
+func parsePrice(s string) (float64, error) {
+	price, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(price >= 0) || math.IsInf(price, 0) {
+		return 0, fmt.Errorf("%q is not a non-negative number", s)
+	}
+	return price, nil
+}
+
+// Parse returns the price model described by a scenario, one of:
+//
+//	constant:<price>
+//	exponential:<price as of since>:<annual growth rate, e.g., -0.2>
+//	file:<path to CSV of prices>
+func Parse(scenario string, since time.Time) (Price, error) {
+	kind, args, _ := strings.Cut(scenario, ":")
+	params := strings.Split(args, ":")
+	switch kind {
+	case "constant":
+		if len(params) != 1 {
+			return nil, errors.New("usage: constant:<price>")
+		}
+		price, err := parsePrice(params[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid price: %w", err)
+		}
+		return Constant(price), nil
+	case "exponential":
+		if len(params) != 2 {
+			return nil, errors.New("usage: exponential:<price>:<annual growth rate>")
+		}
+		price, err := parsePrice(params[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid price: %w", err)
+		}
+		growth, err := strconv.ParseFloat(params[1], 64)
+		if err != nil || math.IsNaN(growth) || math.IsInf(growth, 0) || growth <= -1 {
+			return nil, fmt.Errorf("invalid annual growth rate %q", params[1])
+		}
+		return Exponential{Start: price, Growth: growth, Since: since}, nil
+	case "file":
+		table, err := keyed.Open(args, ReadCSV)
+		if err != nil {
+			return nil, err
+		}
+		return table, nil
+	default:
+		return nil, fmt.Errorf("unknown price scenario %q", scenario)
+	}
+}
+
+// Costs are the costs of smeshing.
+type Costs struct {
+	// Hardware is the upfront cost of the storage and any other equipment
+	Hardware float64
+
+	// Initialization is the upfront cost of initializing the PoST data, e.g., GPU time
+	Initialization float64
+
+	// PowerWatts is the average power drawn while smeshing, and ElectricityPrice the price per kWh
+	PowerWatts       float64
+	ElectricityPrice float64
+}
+
+// Validate returns an error if a cost is negative or not a finite number.
+func (c Costs) Validate() error {
+	for _, cost := range []struct {
+		name  string
+		value float64
+	}{
+		{"hardware cost", c.Hardware},
+		{"initialization cost", c.Initialization},
+		{"power", c.PowerWatts},
+		{"electricity price", c.ElectricityPrice},
+	} {
+		if math.IsNaN(cost.value) || math.IsInf(cost.value, 0) || cost.value < 0 {
+			return fmt.Errorf("invalid %s %v: must be a non-negative number", cost.name, cost.value)
+		}
+	}
+	return nil
+}
+
+// ValidateDiscountRate returns an error if an annual discount rate isn't a finite number greater than -1.
+func ValidateDiscountRate(rate float64) error {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= -1 {
+		return fmt.Errorf("invalid discount rate %v: must be greater than -1", rate)
+	}
+	return nil
+}
+
+// Upfront returns the costs incurred before smeshing begins.
+func (c Costs) Upfront() float64 {
+	return c.Hardware + c.Initialization
+}
+
+// PerEpoch returns the running cost of smeshing for one epoch.
+func (c Costs) PerEpoch() float64 {
+	return c.PowerWatts / 1000 * epochDuration.Hours() * c.ElectricityPrice
+}
+
+// Result is the outcome of smeshing under one price scenario.
+type Result struct {
+	Scenario string
+
+	// Revenue and Cost are the totals over the horizon, undiscounted
+	Revenue float64
+	Cost    float64
+
+	// BreakEven is the end of the first epoch as of which cumulative revenue covers cumulative cost, if any
+	BreakEven  time.Time
+	BrokeEven  bool
+	NPV        float64
+	IRR        float64
+	IRRDefined bool
+}
+
+// cashflow is an amount received (or, if negative, paid) a number of years after the start of the horizon.
+type cashflow struct {
+	years  float64
+	amount float64
+}
+
+// presentValue returns the sum of the cashflows discounted at the given annual rate.
+func presentValue(flows []cashflow, rate float64) float64 {
+	var sum float64
+	for _, f := range flows {
+		sum += f.amount / math.Pow(1+rate, f.years)
+	}
+	return sum
+}
+
+// irr returns the annual discount rate at which the present value of the cashflows is zero, found by bisection. It
+// returns false unless the present value changes sign over the range of rates searched.
+func irr(flows []cashflow) (float64, bool) {
+	lo, hi := -0.99, 1e6
+	pvLo, pvHi := presentValue(flows, lo), presentValue(flows, hi)
+	if math.IsNaN(pvLo) || math.IsNaN(pvHi) || (pvLo > 0) == (pvHi > 0) {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (lo + hi) / 2
+		pv := presentValue(flows, mid)
+		if (pv > 0) == (pvLo > 0) {
+			lo, pvLo = mid, pv
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2, true
+}
+
+// cashflows returns the cashflows of smeshing: upfront costs are paid at the start of the first epoch of the
+// projection, and the revenue and running costs of each epoch are accounted for at its end, when revenue is sold at
+// the price of the time.
+func cashflows(projection []income.Epoch, costs Costs, price Price) []cashflow {
+	flows := []cashflow{{0, -costs.Upfront()}}
+	for i, e := range projection {
+		revenue := float64(e.Total()) / constants.OneSmesh * price.PriceAt(e.Date.Add(epochDuration))
+		flows = append(flows, cashflow{
+			years:  float64(i+1) * constants.OneEpoch / constants.OneYear,
+			amount: revenue - costs.PerEpoch(),
+		})
+	}
+	return flows
+}
+
+// Analyze returns the outcome of smeshing under a price scenario, given the smesher's projected income, its costs and
+// the annual rate at which future cashflows are discounted. The costs and the rate must be valid.
+func Analyze(projection []income.Epoch, costs Costs, discountRate float64, name string, price Price) Result {
+	flows := cashflows(projection, costs, price)
+	result := Result{Scenario: name}
+	var cumulative float64
+	for i, f := range flows {
+		cumulative += f.amount
+		if i == 0 {
+			result.Cost += -f.amount
+			continue
+		}
+		result.Revenue += f.amount + costs.PerEpoch()
+		result.Cost += costs.PerEpoch()
+		if !result.BrokeEven && cumulative >= 0 {
+			result.BreakEven = projection[i-1].Date.Add(epochDuration)
+			result.BrokeEven = true
+		}
+	}
+	result.NPV = presentValue(flows, discountRate)
+	result.IRR, result.IRRDefined = irr(flows)
+	return result
+}
@@ -0,0 +1,130 @@
+package roi
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/income"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// projection returns a projection of the given number of epochs with the same reward, in SMESH, in each.
+func projection(epochs int, reward uint64) []income.Epoch {
+	p := make([]income.Epoch, epochs)
+	for i := range p {
+		p[i] = income.Epoch{Date: start.Add(time.Duration(i) * epochDuration), Subsidy: reward * constants.OneSmesh}
+	}
+	return p
+}
+
+func Test_Price(t *testing.T) {
+	assert.Equal(t, 2.5, Constant(2.5).PriceAt(start))
+
+	exponential := Exponential{Start: 1, Growth: 1, Since: start}
+	assert.Equal(t, 1.0, exponential.PriceAt(start))
+	oneYear := start.Add(constants.OneYear * 5 * time.Minute)
+	assert.InDelta(t, 2, exponential.PriceAt(oneYear), 1e-9)
+	assert.InDelta(t, 0.5, exponential.PriceAt(start.Add(-constants.OneYear*5*time.Minute)), 1e-9)
+
+	table, err := ReadCSV(strings.NewReader("date,price\n2024-02-01,2\n2024-01-01,1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, table.PriceAt(start.AddDate(-1, 0, 0)), "first price before the first record")
+	assert.Equal(t, 1.0, table.PriceAt(start.AddDate(0, 0, 30)))
+	assert.Equal(t, 2.0, table.PriceAt(start.AddDate(0, 1, 0)))
+
+	for name, input := range map[string]string{
+		"bad header": "day,price\n2024-01-01,1\n",
+		"bad date":   "date,price\n2024-13-01,1\n",
+		"bad price":  "date,price\n2024-01-01,-1\n",
+		"duplicate":  "date,price\n2024-01-01,1\n2024-01-01,2\n",
+		"empty":      "date,price\n",
+	} {
+		_, err := ReadCSV(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Parse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.csv")
+	require.NoError(t, os.WriteFile(path, []byte("date,price\n2024-01-01,3\n"), 0o600))
+
+	for scenario, expected := range map[string]Price{
+		"constant:0.5":        Constant(0.5),
+		"exponential:2:-0.25": Exponential{Start: 2, Growth: -0.25, Since: start},
+		"file:" + path:        &Table{records: []Record{{Date: start, Price: 3}}},
+	} {
+		price, err := Parse(scenario, start)
+		require.NoError(t, err, scenario)
+		assert.Equal(t, expected, price, scenario)
+	}
+	for _, scenario := range []string{"", "constant:-1", "constant:NaN", "exponential:1", "exponential:1:-1", "file:"} {
+		_, err := Parse(scenario, start)
+		assert.Error(t, err, scenario)
+	}
+}
+
+func Test_Costs(t *testing.T) {
+	costs := Costs{Hardware: 100, Initialization: 20, PowerWatts: 1000, ElectricityPrice: 0.5}
+	assert.Equal(t, 120.0, costs.Upfront())
+	assert.Equal(t, 14*24*0.5, costs.PerEpoch())
+}
+
+func Test_Analyze(t *testing.T) {
+	costs := Costs{Hardware: 100}
+
+	// 10 epochs of 20 SMESH at 1 per SMESH: break even after the fifth epoch
+	result := Analyze(projection(10, 20), costs, 0, "flat", Constant(1))
+	assert.Equal(t, "flat", result.Scenario)
+	assert.Equal(t, 200.0, result.Revenue)
+	assert.Equal(t, 100.0, result.Cost)
+	assert.True(t, result.BrokeEven)
+	assert.Equal(t, start.Add(5*epochDuration), result.BreakEven)
+	assert.InDelta(t, 100, result.NPV, 1e-9, "no discounting at a zero rate")
+	require.True(t, result.IRRDefined)
+	assert.InDelta(t, 0, presentValue(cashflows(projection(10, 20), costs, Constant(1)), result.IRR), 1e-6)
+	assert.Greater(t, result.IRR, 0.0)
+
+	// discounting reduces NPV
+	discounted := Analyze(projection(10, 20), costs, 0.1, "flat", Constant(1))
+	assert.Less(t, discounted.NPV, result.NPV)
+	assert.Equal(t, result.IRR, discounted.IRR)
+
+	// never breaks even, and the IRR is negative
+	result = Analyze(projection(10, 5), costs, 0, "low", Constant(1))
+	assert.False(t, result.BrokeEven)
+	assert.InDelta(t, -50, result.NPV, 1e-9)
+	require.True(t, result.IRRDefined)
+	assert.Less(t, result.IRR, 0.0)
+
+	// no revenue at all means no rate of return
+	result = Analyze(projection(10, 0), costs, 0, "zero", Constant(1))
+	assert.False(t, result.IRRDefined)
+	assert.False(t, math.IsNaN(result.NPV))
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Costs{Hardware: 100, Initialization: 10, PowerWatts: 50, ElectricityPrice: 0.2}.Validate())
+	assert.NoError(t, Costs{}.Validate())
+	for _, costs := range []Costs{
+		{Hardware: -1},
+		{Initialization: math.NaN()},
+		{PowerWatts: math.Inf(1)},
+		{ElectricityPrice: -0.1},
+	} {
+		assert.Error(t, costs.Validate(), "%+v", costs)
+	}
+
+	assert.NoError(t, ValidateDiscountRate(0))
+	assert.NoError(t, ValidateDiscountRate(-0.5))
+	for _, rate := range []float64{-1, -2, math.NaN(), math.Inf(1)} {
+		assert.Error(t, ValidateDiscountRate(rate), rate)
+	}
+}
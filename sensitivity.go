@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/sensitivity"
+	"github.com/spacemeshos/economics/types"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// maxGridPoints bounds the size of the grid, which grows quickly with the number of values of each parameter.
+const maxGridPoints = 10_000_000
+
+// sensitivityCommand calculates circulating supply at fixed horizons over a grid of economic parameters.
+func sensitivityCommand(args []string) {
+	fs := flag.NewFlagSet("sensitivity", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "quiet mode (don't render progress)")
+	vaulted := fs.String("vaulted", strconv.Itoa(constants.TotalVaulted/constants.OneSmesh),
+		"total vaulted in SMESH, as a value, a list (a,b,...) or a range (min:max:step)")
+	target := fs.String("target", strconv.Itoa(constants.TenYearTarget/constants.OneSmesh),
+		"ten year issuance target in SMESH, as for -vaulted")
+	vestStart := fs.String("vestStart", strconv.Itoa(constants.VestStart), "vest start layer, as for -vaulted")
+	vestEnd := fs.String("vestEnd", strconv.Itoa(constants.VestEnd), "vest end layer, as for -vaulted")
+	genesis := fs.String("effectiveGenesis", strconv.Itoa(effectiveGenesis), "effective genesis layer, as for -vaulted")
+	years := fs.String("years", "1,2,5,10", "horizons in years post-genesis, as for -vaulted")
+	workers := fs.Int("workers", 0, "number of worker goroutines (default one per CPU core)")
+	format := fs.String("format", formatCSV, "output format: table, csv or json")
+	_ = fs.Parse(args)
+
+	cfg := sensitivity.Config{TotalIssuance: constants.TotalIssuance, Workers: *workers}
+	// size is the number of points in the grid of the parameters parsed so far, which bounds the number of values of
+	// the next, so that a grid too large is rejected before its values are generated
+	size := 1
+	parse := func(spec, name string, unit uint64) []uint64 {
+		values, err := sensitivity.ParseValues(spec, unit, maxGridPoints/size)
+		if err != nil {
+			log.Fatalf("invalid %s: %v (the grid may have at most %d points)", name, err, maxGridPoints)
+		}
+		size *= len(values)
+		return values
+	}
+	layers := func(spec, name string) []types.Layer {
+		values := parse(spec, name, 1)
+		l := make([]types.Layer, len(values))
+		for i, v := range values {
+			l[i] = types.Layer(v)
+		}
+		return l
+	}
+	cfg.TotalVaulted = parse(*vaulted, "total vaulted", constants.OneSmesh)
+	cfg.TenYearTarget = parse(*target, "ten year target", constants.OneSmesh)
+	cfg.VestStart = layers(*vestStart, "vest start")
+	cfg.VestEnd = layers(*vestEnd, "vest end")
+	cfg.EffectiveGenesis = layers(*genesis, "effective genesis")
+	var err error
+	if cfg.Years, err = sensitivity.ParseValues(*years, 1, maxGridPoints); err != nil {
+		log.Fatalf("invalid years: %v", err)
+	}
+	for _, y := range cfg.Years {
+		if y > uint64(types.MaxLayer)/constants.OneYear {
+			log.Fatalf("horizon of %d years out of range", y)
+		}
+	}
+	switch *format {
+	case formatTable, formatCSV, formatJSON:
+	default:
+		log.Fatalf("unknown output format %q", *format)
+	}
+
+	log.Printf("evaluating %d points at %d horizons\n", size, len(cfg.Years))
+
+	pw := progress.NewWriter()
+	pw.SetUpdateFrequency(time.Millisecond * 100)
+	if !*quiet {
+		go pw.Render()
+	}
+	tracker := progress.Tracker{Total: int64(size), Units: progress.Units{
+		Formatter:        progress.FormatNumber,
+		Notation:         " points",
+		NotationPosition: progress.UnitsNotationPositionAfter,
+	}}
+	pw.AppendTracker(&tracker)
+
+	start := time.Now()
+	points, skipped := sensitivity.Run(cfg, func() { tracker.Increment(1) })
+	tracker.MarkAsDone()
+	if !*quiet {
+		pw.Stop()
+	}
+	log.Printf("evaluated %d points in %s\n", len(points), time.Since(start).Round(time.Millisecond))
+	if skipped > 0 {
+		log.Printf("skipped %d invalid combinations (the vault must be less than the ten year target, the target less "+
+			"than total issuance, and vesting must end after it starts)\n", skipped)
+	}
+
+	if err := renderSensitivity(os.Stdout, *format, cfg.Years, points); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func layerColumn(name string, layer func(p sensitivity.Point) types.Layer) column[sensitivity.Point] {
+	return column[sensitivity.Point]{
+		name:  name,
+		align: text.AlignRight,
+		cell:  func(_ *printer, p sensitivity.Point) any { return uint64(layer(p)) },
+		value: func(p sensitivity.Point) any { return uint64(layer(p)) },
+	}
+}
+
+// sensitivityColumns has one row per point, with the parameters followed by circulating supply at each horizon, so
+// that any two parameters can be pivoted into a heatmap.
+func sensitivityColumns(years []uint64) []column[sensitivity.Point] {
+	columns := []column[sensitivity.Point]{
+		amountColumn("totalVaulted", 0, text.AlignRight,
+			func(p sensitivity.Point) uint64 { return p.TotalVaulted }),
+		amountColumn("tenYearTarget", 0, text.AlignRight,
+			func(p sensitivity.Point) uint64 { return p.TenYearTarget }),
+		layerColumn("vestStart", func(p sensitivity.Point) types.Layer { return p.VestStart }),
+		layerColumn("vestEnd", func(p sensitivity.Point) types.Layer { return p.VestEnd }),
+		layerColumn("effectiveGenesis", func(p sensitivity.Point) types.Layer { return p.EffectiveGenesis }),
+	}
+	for i, y := range years {
+		i := i
+		columns = append(columns, amountColumn(fmt.Sprintf("circulating%dy", y), 11, text.AlignRight,
+			func(p sensitivity.Point) uint64 { return p.Circulating[i] }))
+	}
+	return columns
+}
+
+func renderSensitivity(w io.Writer, format string, years []uint64, points []sensitivity.Point) error {
+	columns := sensitivityColumns(years)
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, points, "Please note:\n"+
+			"- All figures in SMESH (rounded down)\n"+
+			"- Circulating supply is as of the layer the given number of (365 day) years post-genesis\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, points)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[sensitivity.Point]{{"points", columns, points}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
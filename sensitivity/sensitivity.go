@@ -0,0 +1,212 @@
+// Package sensitivity evaluates circulating supply at fixed horizons over a grid of economic parameters, using the
+// closed-form vesting and subsidy schedules rather than stepping through every layer.
+package sensitivity
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+)
+
+// DefaultYears are the horizons, in years post-genesis, at which circulating supply is reported by default.
+var DefaultYears = []uint64{1, 2, 5, 10}
+
+type Config struct {
+	// TotalIssuance is the total issuance, in smidge, which is fixed across the grid; the total subsidy is whatever
+	// isn't vaulted
+	TotalIssuance uint64
+
+	// TotalVaulted and TenYearTarget are the values of the corresponding constants, in smidge
+	TotalVaulted  []uint64
+	TenYearTarget []uint64
+
+	// VestStart, VestEnd and EffectiveGenesis are the values of the corresponding layers post-genesis
+	VestStart        []types.Layer
+	VestEnd          []types.Layer
+	EffectiveGenesis []types.Layer
+
+	// Years are the horizons, in years post-genesis, at which circulating supply is calculated
+	Years []uint64
+
+	// Workers is the number of points evaluated concurrently; if zero, one per CPU core
+	Workers int
+}
+
+// Point is one combination of parameters in the grid, and the resulting circulating supply, in smidge, as of the layer
+// each horizon's number of years post-genesis.
+type Point struct {
+	TotalVaulted     uint64
+	TenYearTarget    uint64
+	VestStart        types.Layer
+	VestEnd          types.Layer
+	EffectiveGenesis types.Layer
+
+	Circulating []uint64
+}
+
+// CirculatingAtLayer returns the circulating supply as of a layer post-genesis, i.e., the amount vested plus the
+// subsidy issued.
+func CirculatingAtLayer(vest vesting.Schedule, subsidy *rewards.Schedule, effectiveGenesis, layerID types.Layer) uint64 {
+	circulating := vest.AccumulatedVestAtLayer(layerID)
+	if effectiveLayer, ok := layerID.Effective(effectiveGenesis); ok {
+		circulating += subsidy.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+	}
+	return circulating
+}
+
+// parallel calls f for each index in [0, n) using the given number of workers.
+func parallel(n, workers int, f func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// schedules are the subsidy schedules for each pair of total vaulted and ten year target, indexed in that order; nil
+// if the pair is invalid.
+func schedules(cfg Config, workers int) []*rewards.Schedule {
+	s := make([]*rewards.Schedule, len(cfg.TotalVaulted)*len(cfg.TenYearTarget))
+	parallel(len(s), workers, func(i int) {
+		vaulted := cfg.TotalVaulted[i/len(cfg.TenYearTarget)]
+		target := cfg.TenYearTarget[i%len(cfg.TenYearTarget)]
+		if vaulted < target && target < cfg.TotalIssuance {
+			s[i] = rewards.NewSchedule(cfg.TotalIssuance-vaulted, target-vaulted)
+		}
+	})
+	return s
+}
+
+// Run evaluates every point in the cartesian product of the parameter values. Points are returned in order, varying
+// the last parameter (effective genesis) fastest. Combinations that don't describe a valid schedule, i.e., where the
+// vault doesn't fall short of the ten year target and the ten year target of total issuance, or vesting doesn't end
+// after it starts, are skipped and counted. If onPoint is non-nil it's called after each point is evaluated; it may be
+// called concurrently.
+func Run(cfg Config, onPoint func()) (points []Point, skipped int) {
+	workers := cfg.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	subsidies := schedules(cfg, workers)
+
+	var candidates []Point
+	var candidateSubsidies []*rewards.Schedule
+	for i, vaulted := range cfg.TotalVaulted {
+		for j, target := range cfg.TenYearTarget {
+			for _, start := range cfg.VestStart {
+				for _, end := range cfg.VestEnd {
+					for _, effectiveGenesis := range cfg.EffectiveGenesis {
+						subsidy := subsidies[i*len(cfg.TenYearTarget)+j]
+						if subsidy == nil || end <= start {
+							skipped++
+							continue
+						}
+						candidates = append(candidates, Point{
+							TotalVaulted:     vaulted,
+							TenYearTarget:    target,
+							VestStart:        start,
+							VestEnd:          end,
+							EffectiveGenesis: effectiveGenesis,
+						})
+						candidateSubsidies = append(candidateSubsidies, subsidy)
+					}
+				}
+			}
+		}
+	}
+
+	parallel(len(candidates), workers, func(i int) {
+		p := &candidates[i]
+		vest := vesting.Schedule{
+			TotalVaulted:  p.TotalVaulted,
+			VestedAtCliff: uint64(constants.CliffRatio * float64(p.TotalVaulted)),
+			VestStart:     p.VestStart,
+			VestEnd:       p.VestEnd,
+		}
+		p.Circulating = make([]uint64, len(cfg.Years))
+		for k, years := range cfg.Years {
+			p.Circulating[k] = CirculatingAtLayer(vest, candidateSubsidies[i], p.EffectiveGenesis,
+				types.Layer(years*constants.OneYear))
+		}
+		if onPoint != nil {
+			onPoint()
+		}
+	})
+	return candidates, skipped
+}
+
+// ParseValues returns the values described by a spec, which is a single value, a comma-separated list of values, or a
+// range with a step, as min:max:step (inclusive of max if it falls on a step). Each value is multiplied by unit. There
+// may be at most limit values, which is checked before any are generated.
+func ParseValues(spec string, unit uint64, limit int) ([]uint64, error) {
+	parse := func(s string) (uint64, error) {
+		v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value %q", s)
+		}
+		if unit != 0 && v > ^uint64(0)/unit {
+			return 0, fmt.Errorf("value %q out of range", s)
+		}
+		return v * unit, nil
+	}
+	if parts := strings.Split(spec, ":"); len(parts) == 3 {
+		var bounds [3]uint64
+		for i, part := range parts {
+			v, err := parse(part)
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = v
+		}
+		first, last, step := bounds[0], bounds[1], bounds[2]
+		if step == 0 || last < first {
+			return nil, fmt.Errorf("invalid range %q: step must be positive and max at least min", spec)
+		}
+		// one more than the number of steps, which can't overflow if checked this way round
+		if (last-first)/step >= uint64(limit) {
+			return nil, fmt.Errorf("range %q has more than %d values", spec, limit)
+		}
+		values := make([]uint64, 0, (last-first)/step+1)
+		for v := first; v <= last; v += step {
+			values = append(values, v)
+			if v > last-step {
+				break
+			}
+		}
+		return values, nil
+	} else if len(parts) != 1 {
+		return nil, errors.New("usage: <value>, <value>,<value>,... or <min>:<max>:<step>")
+	}
+	list := strings.Split(spec, ",")
+	if len(list) > limit {
+		return nil, fmt.Errorf("list has more than %d values", limit)
+	}
+	var values []uint64
+	for _, s := range list {
+		v, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
@@ -0,0 +1,136 @@
+package sensitivity
+
+import (
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mainnetConfig() Config {
+	return Config{
+		TotalIssuance:    constants.TotalIssuance,
+		TotalVaulted:     []uint64{constants.TotalVaulted},
+		TenYearTarget:    []uint64{constants.TenYearTarget},
+		VestStart:        []types.Layer{constants.VestStart},
+		VestEnd:          []types.Layer{constants.VestEnd},
+		EffectiveGenesis: []types.Layer{2 * constants.OneEpoch},
+		Years:            DefaultYears,
+	}
+}
+
+func Test_Mainnet(t *testing.T) {
+	points, skipped := Run(mainnetConfig(), nil)
+	require.Len(t, points, 1)
+	assert.Zero(t, skipped)
+	p := points[0]
+	require.Len(t, p.Circulating, len(DefaultYears))
+
+	// the closed form agrees with the mainnet schedules
+	for i, years := range DefaultYears {
+		layerID := types.Layer(years * constants.OneYear)
+		expected := vesting.AccumulatedVestAtLayer(layerID) +
+			rewards.TotalAccumulatedSubsidyAtLayer(types.EffectiveLayer(layerID-2*constants.OneEpoch))
+		assert.Equal(t, expected, p.Circulating[i], "%d years", years)
+	}
+	assert.Equal(t, uint64(constants.TotalVaulted), p.Circulating[3]-
+		rewards.TotalAccumulatedSubsidyAtLayer(types.EffectiveLayer(10*constants.OneYear-2*constants.OneEpoch)))
+
+	// and with stepping through every layer
+	result := simulation.Run(simulation.Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneYear,
+		EndLayer:         constants.OneYear,
+	}, nil)
+	assert.Equal(t, result.Ticks[len(result.Ticks)-1].CirculatingTotal, p.Circulating[0])
+}
+
+func Test_Grid(t *testing.T) {
+	cfg := mainnetConfig()
+	cfg.TotalVaulted = []uint64{100 * constants.OneSmesh, 200 * constants.OneSmesh, 500 * constants.OneSmesh}
+	cfg.TenYearTarget = []uint64{400 * constants.OneSmesh, 600 * constants.OneSmesh}
+	cfg.TotalIssuance = 1000 * constants.OneSmesh
+	cfg.VestStart = []types.Layer{0, constants.OneYear}
+	cfg.VestEnd = []types.Layer{constants.OneYear, 4 * constants.OneYear}
+	cfg.EffectiveGenesis = []types.Layer{0, constants.OneEpoch}
+	cfg.Years = []uint64{1, 10}
+	cfg.Workers = 3
+
+	var evaluated atomic.Int64
+	points, skipped := Run(cfg, func() { evaluated.Add(1) })
+
+	// a vault of 500 exceeds the first target, and vesting can't end in the layer it starts
+	assert.Equal(t, 1*4*2+5*1*2, skipped)
+	assert.Len(t, points, 3*2*2*2*2-skipped)
+	assert.Equal(t, int64(len(points)), evaluated.Load())
+
+	// points are in grid order, with the last parameter varying fastest
+	assert.Equal(t, Point{
+		TotalVaulted:     100 * constants.OneSmesh,
+		TenYearTarget:    400 * constants.OneSmesh,
+		VestStart:        0,
+		VestEnd:          constants.OneYear,
+		EffectiveGenesis: 0,
+		Circulating:      points[0].Circulating,
+	}, points[0])
+	assert.Equal(t, types.Layer(constants.OneEpoch), points[1].EffectiveGenesis)
+	assert.Equal(t, types.Layer(4*constants.OneYear), points[2].VestEnd)
+
+	for _, p := range points {
+		// everything has vested after ten years, and the ten year target is met to within rounding
+		assert.InDelta(t, float64(p.TenYearTarget), float64(p.Circulating[1]), float64(p.TenYearTarget)*0.01)
+		if p.EffectiveGenesis == 0 {
+			assert.InDelta(t, float64(p.TenYearTarget), float64(p.Circulating[1]), 1)
+		}
+		assert.LessOrEqual(t, p.Circulating[0], p.Circulating[1])
+	}
+
+	// the result doesn't depend on the number of workers
+	cfg.Workers = 1
+	sequential, _ := Run(cfg, nil)
+	assert.Equal(t, sequential, points)
+}
+
+func Test_ParseValues(t *testing.T) {
+	for spec, expected := range map[string][]uint64{
+		"5":      {5},
+		"1,2, 3": {1, 2, 3},
+		"0:10:5": {0, 5, 10},
+		"0:9:5":  {0, 5},
+		"7:7:1":  {7},
+		"18446744073709551614:18446744073709551615:1": {math.MaxUint64 - 1, math.MaxUint64},
+	} {
+		values, err := ParseValues(spec, 1, 10)
+		require.NoError(t, err, spec)
+		assert.Equal(t, expected, values, spec)
+	}
+
+	values, err := ParseValues("100:200:50", constants.OneSmesh, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{100 * constants.OneSmesh, 150 * constants.OneSmesh, 200 * constants.OneSmesh}, values)
+
+	for _, spec := range []string{"", "x", "1,", "1:2", "2:1:1", "1:2:0", "1:2:3:4", "-1"} {
+		_, err := ParseValues(spec, 1, 10)
+		assert.Error(t, err, spec)
+	}
+	_, err = ParseValues("18446744073709551615", constants.OneSmesh, 10)
+	assert.Error(t, err)
+
+	// too many values, without generating them
+	for _, spec := range []string{"100:200:25", "0:18446744073709551615:1", "1,2,3,4,5"} {
+		_, err = ParseValues(spec, 1, 4)
+		assert.ErrorContains(t, err, "more than 4 values", spec)
+	}
+	values, err = ParseValues("100:200:25", 1, 5)
+	require.NoError(t, err)
+	assert.Len(t, values, 5)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/sensitivity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RenderSensitivity(t *testing.T) {
+	points := []sensitivity.Point{{
+		TotalVaulted:     constants.TotalVaulted,
+		TenYearTarget:    constants.TenYearTarget,
+		VestStart:        constants.VestStart,
+		VestEnd:          constants.VestEnd,
+		EffectiveGenesis: effectiveGenesis,
+		Circulating:      []uint64{1, 2},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, renderSensitivity(&buf, formatCSV, []uint64{1, 10}, points))
+	assert.Equal(t, "totalVaulted,tenYearTarget,vestStart,vestEnd,effectiveGenesis,circulating1y,circulating10y\n"+
+		fmt.Sprintf("%d,%d,%d,%d,%d,1,2\n", uint64(constants.TotalVaulted), uint64(constants.TenYearTarget),
+			constants.VestStart, constants.VestEnd, effectiveGenesis), buf.String())
+}
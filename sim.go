@@ -1,86 +1,294 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/spacemeshos/economics/burn"
 	"github.com/spacemeshos/economics/constants"
-	"github.com/spacemeshos/economics/rewards"
-	"github.com/spacemeshos/economics/vesting"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
 
 	"github.com/jedib0t/go-pretty/v6/progress"
-	"github.com/jedib0t/go-pretty/v6/table"
-	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/tcnksm/go-input"
-	"golang.org/x/text/language"
-	"golang.org/x/text/message"
 )
 
-var qFlag = flag.Bool("q", false, "quiet mode (noninteractive)")
+var (
+	qFlag         = flag.Bool("q", false, "quiet mode (noninteractive)")
+	inflationFlag = flag.Bool("inflation", false, "show inflation metrics per tick and per calendar year")
+	formatFlag    = flag.String("format", formatTable, "output format: table, csv or json")
+	feesFlag      = flag.String("fees", "", "fee scenario: none, constant:<smidge>, linear:<smidge>:<smidge per layer>, "+
+		"exponential:<smidge>:<annual growth> or file:<csv path>")
+	emptyFlag = flag.String("empty", "", "empty layer scenario: rate:<fraction>, random:<probability>:<seed> or "+
+		"file:<path to list of layers>")
+	malfeasanceFlag = flag.String("malfeasance", "", "malfeasance scenario: constant:<fraction of eligibility weight> "+
+		"or file:<csv path>")
+	burnFlag = flag.String("burn", "", "burn scenario: fraction:<fraction of fees>, series:<fee scenario> or "+
+		"file:<csv path>")
+	layerTimesFlag   = flag.String("layerTimes", "", "CSV of observed layer times (layer,time) to date layers by")
+	chartsFlag       = flag.String("charts", "", "directory to write charts of the simulation to")
+	chartFormatFlag  = flag.String("chartFormat", chartSVG, "chart format: svg or png")
+	reportFlag       = flag.String("report", "", "path to write an HTML report of the simulation to")
+	xlsxFlag         = flag.String("xlsx", "", "path to write an XLSX spreadsheet of the simulation to")
+	sqliteFlag       = flag.String("sqlite", "", "path of a SQLite database to write the simulation per epoch to")
+	sqliteLayersFlag = flag.String("sqliteLayers", "", "range of layers, as first:last, to also write to the SQLite "+
+		"database per layer")
+	columnsFlag = flag.String("columns", "", "comma-separated tick columns to show, in order (default all)")
+	derivedFlag = derivedColumnsFlag("define", "define a derived column as name=formula (repeatable)")
+	unitFlag    = flag.String("unit", "smesh", "unit of amounts in the table: smidge, smesh or ksmesh (thousands "+
+		"of SMESH), optionally with a number of decimals, e.g., smesh:4")
+	localeFlag = flag.String("locale", "", "locale of numbers and dates in the table, e.g., de or en-GB (default "+
+		"English numbers and ISO 8601 dates)")
+	emptyPolicyFlag = flag.String("emptyPolicy", emptylayers.Forfeit.String(),
+		"what happens to the subsidy for empty layers: forfeit or redistribute")
+)
+
+// commands maps subcommand names to their entry points. Each command parses its own flags. With no subcommand we
+// run the simulation.
+var commands = map[string]func(args []string){
+	"compare":     compareCommand,
+	"explore":     exploreCommand,
+	"income":      incomeCommand,
+	"montecarlo":  montecarloCommand,
+	"reconcile":   reconcileCommand,
+	"roi":         roiCommand,
+	"sensitivity": sensitivityCommand,
+	"solve":       solveCommand,
+	"verify":      verifyCommand,
+}
 
 func main() {
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			command(os.Args[2:])
+			return
+		}
+	}
+
 	// parse flags
 	flag.Parse()
+	switch *formatFlag {
+	case formatTable, formatCSV, formatJSON:
+	default:
+		log.Fatalf("unknown output format %q", *formatFlag)
+	}
+	var feeModel fees.Model
+	if *feesFlag != "" {
+		var err error
+		if feeModel, err = fees.Parse(*feesFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var emptyModel emptylayers.Model
+	if *emptyFlag != "" {
+		var err error
+		if emptyModel, err = emptylayers.Parse(*emptyFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var malfeasanceModel malfeasance.Model
+	if *malfeasanceFlag != "" {
+		var err error
+		if malfeasanceModel, err = malfeasance.Parse(*malfeasanceFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var burnModel burn.Model
+	if *burnFlag != "" {
+		var err error
+		if burnModel, err = burn.Parse(*burnFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := checkBurn(feeModel, burnModel); err != nil {
+		log.Fatalf("-burn: %v", err)
+	}
+	emptyPolicy, err := emptylayers.ParsePolicy(*emptyPolicyFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *chartFormatFlag != chartSVG && *chartFormatFlag != chartPNG {
+		log.Fatalf("unknown chart format %q", *chartFormatFlag)
+	}
 
-	oneLayer := 5 * time.Minute
+	opts := renderOptions{
+		inflation:   *inflationFlag,
+		fees:        feeModel != nil,
+		empty:       emptyModel != nil,
+		malfeasance: malfeasanceModel != nil,
+		burn:        burnModel != nil,
+		drift:       *layerTimesFlag != "",
+		derived:     *derivedFlag,
+	}
+	if *columnsFlag != "" {
+		opts.columns = strings.Split(*columnsFlag, ",")
+	}
+	if opts.unit, err = parseUnit(*unitFlag); err != nil {
+		log.Fatal(err)
+	}
+	if opts.locale, err = parseLocale(*localeFlag); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := selectTickColumns(opts); err != nil {
+		log.Fatal(err)
+	}
+	if *sqliteFlag != "" {
+		if _, err := sqliteSchema(sqliteAmountColumns(opts)); err != nil {
+			log.Fatalf("-sqlite: %v", err)
+		}
+	}
+	var layerRange *simulation.LayerRange
+	if *sqliteLayersFlag != "" {
+		if *sqliteFlag == "" {
+			log.Fatal("-sqliteLayers needs -sqlite")
+		}
+		if layerRange, err = parseLayerRange(*sqliteLayersFlag); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	currentDate, tickInterval, endLayer := getParams()
 	log.Printf("genesis is %s\n", currentDate)
-	log.Printf("effective genesis is/issuance begins %s\n", currentDate.Add(effectiveGenesis*oneLayer))
 	log.Printf("tick interval is %d layers\n", tickInterval)
 	log.Printf("last layer is %d\n", endLayer)
 
-	t := table.NewWriter()
-	t.SetOutputMirror(os.Stdout)
-	t.AppendHeader(table.Row{
-		"layer",
-		"epoch",
-		"date",
-		"vaultNewVest",
-		"vaultTotalVest",
-		"vaultPctVest",
-		"vaultTotal",
-		"subsidyPerLayer",
-		"subsidyNew",
-		"subsidyTotal",
-		"circulatingTotal",
-		"issuanceTotal",
-		"pctVault",
-		"pctCirculating",
-		"pctFinalIssuance",
-	})
-	t.SetColumnConfigs([]table.ColumnConfig{
-		{Number: 4, Align: text.AlignRight},
-		{Number: 5, Align: text.AlignRight},
-		{Number: 6, Align: text.AlignRight},
-		{Number: 8, Align: text.AlignRight},
-		{Number: 9, Align: text.AlignRight},
-		{Number: 10, Align: text.AlignRight},
-		{Number: 11, Align: text.AlignRight},
-		{Number: 12, Align: text.AlignRight},
-		{Number: 14, Align: text.AlignRight},
-		{Number: 15, Align: text.AlignRight},
+	var clock simulation.Clock
+	if *layerTimesFlag != "" {
+		observed, err := readObservedClock(*layerTimesFlag, currentDate)
+		if err != nil {
+			log.Fatal(err)
+		}
+		last := observed.LastObserved()
+		drift := simulation.Drift(observed, currentDate, last.Layer)
+		log.Printf("last observed layer is %d at %s, drift %s from nominal (average layer duration %s)\n", last.Layer,
+			last.Time.Format(time.RFC3339), drift, averageLayerDuration(currentDate, last))
+		clock = observed
+	}
+
+	cfg := simulation.Config{
+		Genesis:          currentDate,
+		EffectiveGenesis: effectiveGenesis,
+		TickInterval:     tickInterval,
+		EndLayer:         endLayer,
+		Clock:            clock,
+		Fees:             feeModel,
+		EmptyLayers:      emptyModel,
+		EmptyPolicy:      emptyPolicy,
+		Burn:             burnModel,
+		Malfeasance:      malfeasanceModel,
+		Layers:           layerRange,
+	}
+	log.Printf("effective genesis is/issuance begins %s\n", cfg.LayerClock().LayerTime(effectiveGenesis))
+	result := runSimulation(cfg, !*qFlag)
+	final := result.Ticks[len(result.Ticks)-1]
+	if emptyModel != nil {
+		log.Printf("total subsidy shortfall due to empty layers is %d smidge (policy %s)\n",
+			final.SubsidyShortfall-final.ForfeitedTotal, emptyPolicy)
+	}
+	if malfeasanceModel != nil {
+		log.Printf("total subsidy forfeited by malfeasant identities is %d smidge\n", final.ForfeitedTotal)
+	}
+	if burnModel != nil {
+		log.Printf("total burned is %d smidge, net issuance %d smidge\n", final.BurnedTotal, final.NetIssuance())
+		if result.BurnExceedsSubsidy {
+			date := simulation.LayerTime(currentDate, result.BurnExceedsSubsidyLayer)
+			if clock != nil {
+				date = clock.LayerTime(result.BurnExceedsSubsidyLayer)
+			}
+			log.Printf("burn first exceeds subsidy in layer %d (%s)\n", result.BurnExceedsSubsidyLayer,
+				date.Format("2006-01-02"))
+		} else {
+			log.Println("burn never exceeds subsidy")
+		}
+	}
+
+	if err := render(os.Stdout, *formatFlag, opts, result); err != nil {
+		log.Fatal(err)
+	}
+	if *chartsFlag != "" && len(result.Ticks) < minChartTicks {
+		log.Printf("not writing charts: a chart takes at least %d ticks, and the run has %d\n", minChartTicks,
+			len(result.Ticks))
+	} else if *chartsFlag != "" {
+		paths, err := writeCharts(*chartsFlag, *chartFormatFlag, supplyCharts(result.Ticks))
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote charts %s\n", strings.Join(paths, ", "))
+	}
+	settings := simulationSettings(cfg, []setting{
+		{"fees", *feesFlag},
+		{"empty", *emptyFlag},
+		{"emptyPolicy", emptyPolicy.String()},
+		{"malfeasance", *malfeasanceFlag},
+		{"burn", *burnFlag},
+		{"layerTimes", *layerTimesFlag},
 	})
-	t.SetCaption("Please note:\n" +
-		"- All figures in SMESH (rounded down)\n" +
-		"- No coins are issued in the first two epochs\n" +
-		"- Figures represent maximum issuance (and do not account for empty layers)\n")
+	if *reportFlag != "" {
+		if err := writeReportFile(*reportFlag, settings, simulationMilestones(cfg, result), opts, result); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote report %s\n", *reportFlag)
+	}
+	if *xlsxFlag != "" {
+		if err := writeSpreadsheetFile(*xlsxFlag, settings, simulationMilestones(cfg, result), opts, result); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote spreadsheet %s\n", *xlsxFlag)
+	}
+	if *sqliteFlag != "" {
+		if err := writeSQLite(*sqliteFlag, settings, opts, result); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("wrote %d epochs and %d layers to %s\n", len(result.Epochs), len(result.Layers), *sqliteFlag)
+	}
+}
 
-	p := message.NewPrinter(language.English)
+// checkBurn returns an error if the burn model burns a fraction of the fees but there's no fee model, in which case
+// nothing would be burned.
+func checkBurn(feeModel fees.Model, burnModel burn.Model) error {
+	if _, ok := burnModel.(burn.Fraction); ok && feeModel == nil {
+		return errors.New("a fraction of fees needs a fee scenario, e.g., -fees constant:<smidge>")
+	}
+	return nil
+}
+
+func readObservedClock(path string, genesis time.Time) (*simulation.ObservedClock, error) {
+	observations, err := keyed.Open(path, simulation.ReadObservations)
+	if err != nil {
+		return nil, err
+	}
+	return simulation.NewObservedClock(genesis, observations)
+}
 
+// averageLayerDuration returns the average duration of the layers from genesis up to an observed layer.
+func averageLayerDuration(genesis time.Time, last simulation.Observation) time.Duration {
+	if last.Layer == 0 {
+		return simulation.OneLayer
+	}
+	return last.Time.Sub(genesis) / time.Duration(last.Layer)
+}
+
+// runSimulation runs the simulation, optionally rendering a progress bar to stdout while it runs.
+func runSimulation(cfg simulation.Config, showProgress bool) simulation.Result {
 	pw := progress.NewWriter()
 	pw.SetUpdateFrequency(time.Millisecond * 100)
 
 	// don't render progress bar in quiet mode
-	if !*qFlag {
+	if showProgress {
 		go pw.Render()
 		defer pw.Stop()
 	}
-	tracker := progress.Tracker{Total: int64(endLayer), Units: progress.Units{
+	tracker := progress.Tracker{Total: int64(cfg.EndLayer), Units: progress.Units{
 		Formatter:        progress.FormatNumber,
 		Notation:         " layers",
 		NotationPosition: progress.UnitsNotationPositionAfter,
@@ -88,69 +296,14 @@ func main() {
 	pw.AppendTracker(&tracker)
 	trackerTickInterval := 1000
 
-	vaultTotal := uint64(constants.TotalVaulted)
-	issuanceTotal := vaultTotal // vaulted amount is issued but not circulating yet
-
-	var vaultVested, subsidyTotal, circulatingTotal, vaultNewVest, subsidyNew uint64
-
-	// note: we could optimize this and just step by tick interval, but we do the simplest possible thing here and get
-	// as close as possible to reality by stepping through every single layer
-	for layerID := uint32(0); layerID <= endLayer; layerID++ {
-		// update vault
-		// vault vesting is calculated on the basis of layers post-genesis
-		vaultVested = vesting.AccumulatedVestAtLayer(layerID)
-		vestThisLayer := vesting.VestAtLayer(layerID)
-		vaultNewVest += vestThisLayer
-		circulatingTotal += vestThisLayer
-
-		// add new issuance
-		// issuance is calculated on the basis of layers post-effective genesis
-		// and no issuance occurs before effective genesis
-		var subsidyTotalNew, subsidyThisLayer uint64
-		if layerID >= effectiveGenesis {
-			// calculate effective layer, i.e., layers post-effective-genesis
-			effectiveLayer := layerID - effectiveGenesis
-			subsidyTotalNew = rewards.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
-			subsidyThisLayer = subsidyTotalNew - subsidyTotal
-		}
-
-		circulatingTotal += subsidyThisLayer
-		issuanceTotal += subsidyThisLayer
-		subsidyNew += subsidyThisLayer
-		subsidyTotal = subsidyTotalNew
-
+	result := simulation.Run(cfg, func(layerID types.Layer) {
 		// increment here in case tick interval is really big
-		if layerID > 0 && layerID%uint32(trackerTickInterval) == 0 {
+		if layerID > 0 && layerID%types.Layer(trackerTickInterval) == 0 {
 			tracker.Increment(int64(trackerTickInterval))
 		}
-
-		if layerID%tickInterval == 0 || layerID == endLayer {
-			t.AppendRow(table.Row{
-				layerID,
-				layerID / constants.OneEpoch,
-				currentDate.Format("2006-01-02"),
-				p.Sprintf("%7d", vaultNewVest/constants.OneSmesh),
-				p.Sprintf("%11d", vaultVested/constants.OneSmesh),
-				p.Sprintf("%7.2f%%", 100*float64(vaultVested)/float64(vaultTotal)),
-				p.Sprintf("%d", vaultTotal/constants.OneSmesh),
-				p.Sprintf("%7d", subsidyThisLayer/constants.OneSmesh),
-				p.Sprintf("%7d", subsidyNew/constants.OneSmesh),
-				p.Sprintf("%11d", subsidyTotal/constants.OneSmesh),
-				p.Sprintf("%11d", circulatingTotal/constants.OneSmesh),
-				p.Sprintf("%11d", issuanceTotal/constants.OneSmesh),
-				p.Sprintf("%7.2f%%", 100*float64(vaultTotal)/float64(issuanceTotal)),
-				p.Sprintf("%7.2f%%", 100*float64(circulatingTotal)/float64(issuanceTotal)),
-				p.Sprintf("%7.2f%%", 100*float64(issuanceTotal)/float64(constants.TotalIssuance)),
-			})
-
-			// reset these
-			vaultNewVest = 0
-			subsidyNew = 0
-		}
-		currentDate = currentDate.Add(oneLayer)
-	}
+	})
 	tracker.MarkAsDone()
-	t.Render()
+	return result
 }
 
 const (
@@ -169,7 +322,7 @@ const (
 
 var defaultGenesisDate, _ = time.Parse("20060102", defaultGenesisDateStr)
 
-func getParams() (time.Time, uint32, uint32) {
+func getParams() (time.Time, uint64, types.Layer) {
 	// short-circuit UI in quiet mode
 	if *qFlag {
 		return defaultGenesisDate, defaultTickInterval, defaultEndLayer
@@ -193,33 +346,37 @@ func getParams() (time.Time, uint32, uint32) {
 	}
 
 	defaultTickIntervalStr := fmt.Sprintf("%d (one epoch/two weeks)", defaultTickInterval)
-	var tickInterval int
+	var tickInterval uint64
 	if tickIntervalStr, err := ui.Ask("layer tick interval", &input.Options{
 		Default:   defaultTickIntervalStr,
 		HideOrder: true,
 		Required:  true,
 		Loop:      true,
-		ValidateFunc: func(s string) (err error) {
-			_, err = strconv.Atoi(s)
-			return
+		ValidateFunc: func(s string) error {
+			if interval, err := strconv.ParseUint(s, 10, 64); err != nil {
+				return err
+			} else if interval == 0 {
+				return errors.New("tick interval must be positive")
+			}
+			return nil
 		},
 	}); err != nil {
 		log.Fatal(err)
 	} else if tickIntervalStr == defaultTickIntervalStr {
 		tickInterval = defaultTickInterval
 	} else {
-		tickInterval, _ = strconv.Atoi(tickIntervalStr)
+		tickInterval, _ = strconv.ParseUint(tickIntervalStr, 10, 64)
 	}
 
 	defaultEndLayerStr := fmt.Sprintf("%d (ten years)", defaultEndLayer)
-	var endLayer int
+	var endLayer uint64
 	if endLayerStr, err := ui.Ask("end layer", &input.Options{
 		Default:   defaultEndLayerStr,
 		HideOrder: true,
 		Required:  true,
 		Loop:      true,
 		ValidateFunc: func(s string) (err error) {
-			_, err = strconv.Atoi(s)
+			_, err = strconv.ParseUint(s, 10, 64)
 			return
 		},
 	}); err != nil {
@@ -227,8 +384,8 @@ func getParams() (time.Time, uint32, uint32) {
 	} else if endLayerStr == defaultEndLayerStr {
 		endLayer = defaultEndLayer
 	} else {
-		endLayer, _ = strconv.Atoi(endLayerStr)
+		endLayer, _ = strconv.ParseUint(endLayerStr, 10, 64)
 	}
 
-	return genesisDate, uint32(tickInterval), uint32(endLayer)
+	return genesisDate, tickInterval, types.Layer(endLayer)
 }
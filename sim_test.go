@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/burn"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateFlag = flag.Bool("update", false, "regenerate golden files")
+
+// testConfig returns the configuration of a run with the default parameters up to the given layer.
+func testConfig(endLayer types.Layer) simulation.Config {
+	return simulation.Config{
+		Genesis:          defaultGenesisDate,
+		EffectiveGenesis: effectiveGenesis,
+		TickInterval:     defaultTickInterval,
+		EndLayer:         endLayer,
+	}
+}
+
+// renderLines renders a run as CSV and returns its lines, starting with the header.
+func renderLines(t *testing.T, opts renderOptions, result simulation.Result) [][]byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatCSV, opts, result))
+	return bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+}
+
+// Test_Golden checks that the simulator output with default parameters matches the published schedule. To regenerate
+// the golden files after an intentional change, run:
+//
+//	go test -run Test_Golden . -update
+func Test_Golden(t *testing.T) {
+	if testing.Short() {
+		t.Skip("simulating ten years of layers takes a while")
+	}
+
+	result := runSimulation(testConfig(defaultEndLayer), false)
+
+	for _, golden := range []struct {
+		file   string
+		format string
+	}{
+		{"tenyears.txt", formatTable},
+		{"tenyears.csv", formatCSV},
+		{"tenyears.json", formatJSON},
+	} {
+		var buf bytes.Buffer
+		require.NoError(t, render(&buf, golden.format, renderOptions{}, result))
+		if *updateFlag {
+			require.NoError(t, os.WriteFile(golden.file, buf.Bytes(), 0o644))
+			continue
+		}
+		expected, err := os.ReadFile(golden.file)
+		require.NoError(t, err)
+
+		// don't dump the whole file on failure
+		if !assert.True(t, bytes.Equal(expected, buf.Bytes()), "output does not match %s", golden.file) {
+			expectedLines := bytes.Split(expected, []byte("\n"))
+			for i, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+				if i >= len(expectedLines) || !bytes.Equal(line, expectedLines[i]) {
+					t.Logf("first difference at line %d:\n%s", i+1, line)
+					break
+				}
+			}
+		}
+	}
+}
+
+func Test_RenderFormats(t *testing.T) {
+	result := simulation.Run(testConfig(effectiveGenesis+10), nil)
+
+	lines := renderLines(t, renderOptions{inflation: true}, result)
+	require.Len(t, lines, 1+len(result.Ticks))
+	assert.Equal(t, "layer,epoch,date,vaultNewVest,vaultTotalVest,vaultPctVest,vaultTotal,subsidyPerLayer,"+
+		"subsidyNew,subsidyTotal,circulatingTotal,issuanceTotal,pctVault,pctCirculating,pctFinalIssuance,"+
+		"inflation,inflationCirc,stockToFlow,emissionRate", string(lines[0]))
+
+	// undefined metrics are left empty
+	assert.Equal(t, "0,0,2023-07-14,0,0,0,150000000000000000,0,0,0,0,150000000000000000,100,0,6.25,0,,,",
+		string(lines[1]))
+
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatJSON, renderOptions{inflation: true}, result))
+	assert.Contains(t, buf.String(), `"ticks": [`)
+	assert.Contains(t, buf.String(), `"years": [`)
+	assert.Contains(t, buf.String(), `"inflationCirc": null`)
+
+	// keys are quoted as JSON rather than Go
+	buf.Reset()
+	require.NoError(t, renderJSON(&buf, []jsonSection[int]{{
+		name:    "a\x7f",
+		columns: []column[int]{{name: "é", value: func(int) any { return 1 }}},
+		rows:    []int{0},
+	}}))
+	var decoded map[string][]map[string]int
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, map[string][]map[string]int{"a\x7f": {{"é": 1}}}, decoded)
+
+	assert.Error(t, render(&buf, "xml", renderOptions{}, result))
+}
+
+func Test_RenderFees(t *testing.T) {
+	cfg := testConfig(effectiveGenesis)
+	cfg.Fees = fees.Constant(1000)
+	result := simulation.Run(cfg, nil)
+
+	lines := renderLines(t, renderOptions{fees: true}, result)
+	require.Len(t, lines, 4)
+	assert.True(t, bytes.HasSuffix(lines[0], []byte(",feesNew,rewardNew,pctFees")))
+
+	// fees are paid from genesis, subsidy only from effective genesis
+	assert.True(t, bytes.HasSuffix(lines[1], []byte(",1000,1000,1")))
+	last := result.Ticks[len(result.Ticks)-1]
+	fees := uint64(constants.OneEpoch * 1000)
+	assert.Equal(t, fees, last.FeesNew)
+	assert.Equal(t, uint64(effectiveGenesis+1)*1000, last.FeesTotal)
+	assert.True(t, bytes.HasSuffix(lines[3], []byte(fmt.Sprintf(",%d,%d,%s", fees, last.SubsidyNew+fees,
+		formatValue(float64(fees)/float64(last.SubsidyNew+fees))))))
+}
+
+func Test_RenderEmptyLayers(t *testing.T) {
+	cfg := testConfig(effectiveGenesis + 1)
+	cfg.EmptyLayers = emptylayers.List{effectiveGenesis: {}}
+	result := simulation.Run(cfg, nil)
+
+	lines := renderLines(t, renderOptions{empty: true}, result)
+	require.Len(t, lines, 5)
+	assert.True(t, bytes.HasSuffix(lines[0], []byte(",emptyLayers,subsidyShortfall,pctShortfall")))
+	assert.True(t, bytes.HasSuffix(lines[1], []byte(",0,0,")), "shortfall is undefined before effective genesis")
+	assert.True(t, bytes.HasSuffix(lines[3], []byte(fmt.Sprintf(",1,%d,1", rewards.TotalSubsidyAtLayer(0)))))
+
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatTable, renderOptions{empty: true}, result))
+	assert.NotContains(t, buf.String(), "do not account for empty layers")
+}
+
+func Test_RenderDrift(t *testing.T) {
+	clock, err := simulation.NewObservedClock(defaultGenesisDate, []simulation.Observation{
+		{Layer: defaultTickInterval, Time: simulation.LayerTime(defaultGenesisDate, defaultTickInterval).Add(90 * time.Second)},
+	})
+	require.NoError(t, err)
+	cfg := testConfig(defaultTickInterval)
+	cfg.Clock = clock
+	result := simulation.Run(cfg, nil)
+
+	lines := renderLines(t, renderOptions{drift: true}, result)
+	require.Len(t, lines, 3)
+	assert.True(t, bytes.HasSuffix(lines[0], []byte(",drift")))
+	assert.True(t, bytes.HasSuffix(lines[2], []byte(",90")))
+
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatTable, renderOptions{drift: true}, result))
+	assert.Contains(t, buf.String(), "1m30s")
+}
+
+func Test_RenderMalfeasance(t *testing.T) {
+	cfg := testConfig(effectiveGenesis)
+	cfg.Malfeasance = malfeasance.Constant(1)
+	result := simulation.Run(cfg, nil)
+
+	lines := renderLines(t, renderOptions{malfeasance: true}, result)
+	require.Len(t, lines, 4)
+	assert.True(t, bytes.HasSuffix(lines[0], []byte(",forfeitedNew,forfeitedTotal,circulatingMax,issuanceMax")))
+	subsidy := rewards.TotalSubsidyAtLayer(0)
+	last := result.Ticks[2]
+	assert.True(t, bytes.HasSuffix(lines[3], []byte(fmt.Sprintf(",%d,%d,%d,%d", subsidy, subsidy,
+		last.CirculatingTotal+subsidy, last.IssuanceTotal+subsidy))))
+}
+
+func Test_RenderBurn(t *testing.T) {
+	cfg := testConfig(effectiveGenesis)
+	cfg.Fees = fees.Constant(1000)
+	cfg.Burn = burn.Fraction(0.5)
+	result := simulation.Run(cfg, nil)
+
+	lines := renderLines(t, renderOptions{burn: true}, result)
+	require.Len(t, lines, 4)
+	assert.True(t, bytes.HasSuffix(lines[0], []byte(",burnedNew,burnedTotal,netIssuance,netInflation")))
+	last := result.Ticks[2]
+	assert.True(t, bytes.HasPrefix(lines[3], []byte(fmt.Sprintf("%d,", effectiveGenesis))))
+	assert.Contains(t, string(lines[3]), fmt.Sprintf(",500,500,%d,", last.IssuanceTotal-500))
+
+	var buf bytes.Buffer
+	require.NoError(t, render(&buf, formatTable, renderOptions{burn: true}, result))
+	assert.Contains(t, buf.String(), "netIssuance is issuance less the amount burned")
+}
+
+func Test_CheckBurn(t *testing.T) {
+	assert.NoError(t, checkBurn(nil, nil))
+	assert.NoError(t, checkBurn(fees.Constant(10), burn.Fraction(0.5)))
+	assert.NoError(t, checkBurn(nil, burn.Series{Volume: fees.Constant(10)}))
+	assert.ErrorContains(t, checkBurn(nil, burn.Fraction(0.5)), "needs a fee scenario")
+
+	// a profile may burn a fraction of the fees set on the command line
+	cfg, err := parseProfile("burn=fraction:0.5", simulation.Config{Fees: fees.Constant(10)})
+	require.NoError(t, err)
+	assert.Equal(t, burn.Fraction(0.5), cfg.Burn)
+}
@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spacemeshos/economics/keyed"
+	"github.com/spacemeshos/economics/types"
+)
+
+// Clock maps layers to time. Layer times never decrease from one layer to the next.
+type Clock interface {
+	// LayerTime returns the start time of the layer.
+	LayerTime(layerID types.Layer) time.Time
+}
+
+// NominalClock starts every layer exactly OneLayer after the previous one, from genesis.
+type NominalClock struct {
+	Genesis time.Time
+}
+
+func (c NominalClock) LayerTime(layerID types.Layer) time.Time {
+	return LayerTime(c.Genesis, layerID)
+}
+
+// Observation is the observed start time of a layer.
+type Observation struct {
+	Layer types.Layer
+	Time  time.Time
+}
+
+// ObservedClock follows observed layer times, e.g., from a node's history. Between two observations layer times are
+// interpolated linearly, and after the last observation they're extrapolated with the nominal layer duration. Genesis
+// counts as an observation of layer zero unless layer zero is observed.
+type ObservedClock struct {
+	observations []Observation
+}
+
+// NewObservedClock returns a clock that follows the given observations. Each layer may be observed at most once, and
+// times must increase with layers.
+func NewObservedClock(genesis time.Time, observations []Observation) (*ObservedClock, error) {
+	sorted, err := keyed.Sort(observations, func(a, b Observation) bool { return a.Layer < b.Layer },
+		func(o Observation) error { return fmt.Errorf("duplicate observation of layer %d", o.Layer) })
+	if err != nil {
+		return nil, err
+	}
+	if len(sorted) == 0 || sorted[0].Layer != 0 {
+		sorted = append([]Observation{{Layer: 0, Time: genesis}}, sorted...)
+	}
+	for i := 1; i < len(sorted); i++ {
+		if !sorted[i].Time.After(sorted[i-1].Time) {
+			return nil, fmt.Errorf("layer %d starts at %s, not after layer %d at %s", sorted[i].Layer,
+				sorted[i].Time.Format(time.RFC3339), sorted[i-1].Layer, sorted[i-1].Time.Format(time.RFC3339))
+		}
+	}
+	return &ObservedClock{observations: sorted}, nil
+}
+
+func (c *ObservedClock) LayerTime(layerID types.Layer) time.Time {
+	// the last observation at or before the layer
+	i := sort.Search(len(c.observations), func(i int) bool { return c.observations[i].Layer > layerID }) - 1
+	prev := c.observations[i]
+	if i == len(c.observations)-1 {
+		return LayerTime(prev.Time, layerID-prev.Layer)
+	}
+	next := c.observations[i+1]
+	span := next.Time.Sub(prev.Time)
+	fraction := float64(layerID-prev.Layer) / float64(next.Layer-prev.Layer)
+	return prev.Time.Add(time.Duration(float64(span) * fraction))
+}
+
+// LastObserved returns the last observed layer.
+func (c *ObservedClock) LastObserved() Observation {
+	return c.observations[len(c.observations)-1]
+}
+
+// Drift returns the difference between the time of the layer according to the clock and its nominal time.
+func Drift(clock Clock, genesis time.Time, layerID types.Layer) time.Duration {
+	return clock.LayerTime(layerID).Sub(LayerTime(genesis, layerID))
+}
+
+// maxSearchLayer bounds the search for the layer at a given time.
+const maxSearchLayer = types.Layer(1 << 40)
+
+// LayerAt returns the layer in progress at the given time according to the clock, i.e., the last layer that starts at
+// or before it. It returns false if the time is before genesis, or after the start of layer maxSearchLayer.
+func LayerAt(clock Clock, t time.Time) (types.Layer, bool) {
+	if clock.LayerTime(0).After(t) {
+		return 0, false
+	}
+
+	// find a layer that starts after t by doubling, then binary search for the last layer that starts at or before t;
+	// layer times are non-decreasing. We give up at about ten million years, well before time.Time overflows.
+	lo, hi := types.Layer(0), types.Layer(1)
+	for !clock.LayerTime(hi).After(t) {
+		if hi >= maxSearchLayer {
+			return 0, false
+		}
+		lo, hi = hi, 2*hi
+	}
+	hi--
+	for lo < hi {
+		mid := lo + (hi-lo)/2 + 1
+		if clock.LayerTime(mid).After(t) {
+			hi = mid - 1
+		} else {
+			lo = mid
+		}
+	}
+	return lo, true
+}
+
+// ReadObservations reads observed layer times from CSV with a header row and the columns layer and time. Times are
+// either RFC 3339 timestamps or Unix timestamps in seconds.
+func ReadObservations(r io.Reader) ([]Observation, error) {
+	observations, err := keyed.ReadCSV(r, keyed.Layer, keyed.Column[time.Time]{Name: "time", Parse: parseTime},
+		func(layerID types.Layer, t time.Time) Observation { return Observation{Layer: layerID, Time: t} })
+	if err != nil {
+		return nil, err
+	}
+	if len(observations) == 0 {
+		return nil, errors.New("no observations")
+	}
+	return observations, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC 3339 or Unix seconds", s)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,372 @@
+// Package simulation steps through the emission schedule layer by layer and records snapshots of vesting, subsidy
+// and issuance at regular tick intervals and at the end of each calendar year.
+package simulation
+
+import (
+	"time"
+
+	"github.com/spacemeshos/economics/burn"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+)
+
+// OneLayer is the nominal duration of a mainnet layer.
+const OneLayer = 5 * time.Minute
+
+// layersPerDay is the number of layers in a day of nominal duration.
+const layersPerDay = types.Layer(24 * time.Hour / OneLayer)
+
+// LayerTime returns the nominal start time of a layer, given the time of genesis. A time.Duration only spans about 292
+// years, so we add whole days first to stay exact for layers beyond that.
+func LayerTime(genesis time.Time, layerID types.Layer) time.Time {
+	return genesis.AddDate(0, 0, int(layerID/layersPerDay)).Add(time.Duration(layerID%layersPerDay) * OneLayer)
+}
+
+// SubsidySchedule returns the accumulated subsidy as of a layer post-effective genesis, e.g., a *rewards.Schedule.
+type SubsidySchedule interface {
+	TotalAccumulatedSubsidyAtLayer(layersAfterEffectiveGenesis types.EffectiveLayer) uint64
+}
+
+type Config struct {
+	// Genesis is the date of the genesis layer
+	Genesis time.Time
+
+	// EffectiveGenesis is the first layer, post-genesis, in which subsidy is issued
+	EffectiveGenesis types.Layer
+
+	// TickInterval is the number of layers between snapshots
+	TickInterval uint64
+
+	// EndLayer is the final layer simulated (inclusive)
+	EndLayer types.Layer
+
+	// Clock maps layers to dates; if nil, layers are of nominal duration from genesis
+	Clock Clock
+
+	// Subsidy is the subsidy schedule; if nil, the mainnet schedule is used
+	Subsidy SubsidySchedule
+
+	// Vesting is the vesting schedule of the vault; if nil, the mainnet schedule is used
+	Vesting *vesting.Schedule
+
+	// Fees is the fee model; if nil, no fees are paid
+	Fees fees.Model
+
+	// EmptyLayers is the model of layers that pay no rewards; if nil, every layer pays its scheduled subsidy
+	EmptyLayers emptylayers.Model
+
+	// EmptyPolicy decides what happens to the subsidy scheduled for empty layers
+	EmptyPolicy emptylayers.Policy
+
+	// Burn is the model of fees burned; if nil, nothing is burned
+	Burn burn.Model
+
+	// Malfeasance is the model of eligibility weight held by identities proven malicious, which forfeit their share of
+	// the subsidy; if nil, no subsidy is forfeited
+	Malfeasance malfeasance.Model
+
+	// Layers, if non-nil, is a range of layers of which a snapshot each is kept in Result.Layers
+	Layers *LayerRange
+}
+
+// LayerClock returns the clock of the run: Clock, or the nominal clock from genesis if it's nil.
+func (cfg Config) LayerClock() Clock {
+	if cfg.Clock == nil {
+		return NominalClock{Genesis: cfg.Genesis}
+	}
+	return cfg.Clock
+}
+
+// LayerRange is a range of layers, inclusive.
+type LayerRange struct {
+	First, Last types.Layer
+}
+
+// Snapshot captures the state of the supply as of a given layer. The "new" fields are accumulated over the layers
+// since the previous snapshot of the same series. All amounts are denominated in smidge.
+type Snapshot struct {
+	Layer types.Layer
+	Epoch types.Epoch
+	Date  time.Time
+
+	// Drift is the difference between Date and the nominal time of the layer
+	Drift time.Duration
+
+	// Layers is the number of layers covered by this snapshot, i.e., since the previous snapshot, inclusive
+	Layers uint64
+
+	VaultNewVest     uint64
+	VaultTotalVest   uint64
+	VaultTotal       uint64
+	SubsidyPerLayer  uint64
+	SubsidyNew       uint64
+	SubsidyTotal     uint64
+	CirculatingTotal uint64
+	IssuanceTotal    uint64
+
+	// Fees are paid to smeshers on top of the subsidy. They're transferred from circulating supply, so they don't
+	// affect issuance.
+	FeesNew   uint64
+	FeesTotal uint64
+
+	// EmptyNew is the number of empty layers covered by this snapshot. SubsidyShortfall is the amount by which the
+	// subsidy issued falls short of the theoretical schedule as of this layer, i.e., the subsidy forfeited, or yet to be
+	// redistributed, because of empty layers or malfeasance.
+	EmptyNew         uint64
+	SubsidyShortfall uint64
+
+	// ForfeitedNew and ForfeitedTotal are the subsidy forfeited by malfeasant identities
+	ForfeitedNew   uint64
+	ForfeitedTotal uint64
+
+	// BurnedNew and BurnedTotal are the fees burned. Burned coins are removed from circulation, but still count
+	// towards (gross) issuance.
+	BurnedNew   uint64
+	BurnedTotal uint64
+}
+
+// NetIssuance returns the total issuance as of the layer, less the amount burned.
+func (s Snapshot) NetIssuance() uint64 {
+	return s.IssuanceTotal - s.BurnedTotal
+}
+
+// IssuanceMax returns the total issuance as of the layer had the full scheduled subsidy been paid, i.e., with no empty
+// layers and no malfeasance.
+func (s Snapshot) IssuanceMax() uint64 {
+	return s.IssuanceTotal + s.SubsidyShortfall
+}
+
+// CirculatingMax returns the circulating supply as of the layer had the full scheduled subsidy been paid.
+func (s Snapshot) CirculatingMax() uint64 {
+	return s.CirculatingTotal + s.SubsidyShortfall
+}
+
+// IssuanceStart returns the total issuance as of the layer preceding the snapshot period.
+func (s Snapshot) IssuanceStart() uint64 {
+	return s.IssuanceTotal - s.SubsidyNew
+}
+
+// CirculatingStart returns the circulating supply as of the layer preceding the snapshot period.
+func (s Snapshot) CirculatingStart() uint64 {
+	return s.CirculatingTotal + s.BurnedNew - s.SubsidyNew - s.VaultNewVest
+}
+
+type Result struct {
+	// Ticks contains one snapshot every TickInterval layers, plus one for the end layer
+	Ticks []Snapshot
+
+	// Years contains one snapshot per calendar year, taken at the last simulated layer of the year
+	Years []Snapshot
+
+	// Epochs contains one snapshot per epoch, taken at the last simulated layer of the epoch
+	Epochs []Snapshot
+
+	// Layers contains one snapshot per layer in the configured range, if any, up to the end layer
+	Layers []Snapshot
+
+	// BurnExceedsSubsidy is set if, in some layer from effective genesis, more was burned than issued as subsidy, and
+	// BurnExceedsSubsidyLayer is the first such layer
+	BurnExceedsSubsidy      bool
+	BurnExceedsSubsidyLayer types.Layer
+}
+
+// flows are the amounts that enter or leave the supply in a layer, or over a period.
+type flows struct {
+	vest, subsidy, fees, forfeited, burned uint64
+
+	// empty is the number of empty layers
+	empty uint64
+}
+
+// series accumulates per-period figures between two snapshots.
+type series struct {
+	snapshots []Snapshot
+	layers    uint64
+	new       flows
+}
+
+func (s *series) add(f flows) {
+	s.layers++
+	s.new.vest += f.vest
+	s.new.subsidy += f.subsidy
+	s.new.fees += f.fees
+	s.new.forfeited += f.forfeited
+	s.new.burned += f.burned
+	s.new.empty += f.empty
+}
+
+func (s *series) take(state Snapshot) {
+	state.Layers = s.layers
+	state.VaultNewVest = s.new.vest
+	state.SubsidyNew = s.new.subsidy
+	state.FeesNew = s.new.fees
+	state.ForfeitedNew = s.new.forfeited
+	state.BurnedNew = s.new.burned
+	state.EmptyNew = s.new.empty
+	s.snapshots = append(s.snapshots, state)
+
+	// reset these
+	s.layers = 0
+	s.new = flows{}
+}
+
+// Aggregate combines consecutive snapshots of a series into one covering all of their layers: the state is that of the
+// last snapshot, and the per-period figures are summed. It panics if there are no snapshots.
+func Aggregate(snapshots []Snapshot) Snapshot {
+	aggregate := snapshots[len(snapshots)-1]
+	aggregate.Layers = 0
+	aggregate.VaultNewVest, aggregate.SubsidyNew, aggregate.FeesNew = 0, 0, 0
+	aggregate.ForfeitedNew, aggregate.BurnedNew, aggregate.EmptyNew = 0, 0, 0
+	for _, s := range snapshots {
+		aggregate.Layers += s.Layers
+		aggregate.VaultNewVest += s.VaultNewVest
+		aggregate.SubsidyNew += s.SubsidyNew
+		aggregate.FeesNew += s.FeesNew
+		aggregate.ForfeitedNew += s.ForfeitedNew
+		aggregate.BurnedNew += s.BurnedNew
+		aggregate.EmptyNew += s.EmptyNew
+	}
+	return aggregate
+}
+
+// Run simulates every layer from genesis to the configured end layer. If onLayer is non-nil it's called after each
+// layer is processed, e.g., to report progress.
+func Run(cfg Config, onLayer func(layerID types.Layer)) Result {
+	var ticks, years, epochs, layers series
+	var result Result
+
+	// theoretical accumulated subsidy according to the schedule, which differs from the subsidy actually issued if
+	// there are empty layers or malfeasant identities; and subsidy from empty layers waiting to be redistributed
+	var scheduledTotal, undistributed uint64
+	subsidy := cfg.Subsidy
+	if subsidy == nil {
+		subsidy = rewards.Mainnet
+	}
+	clock := cfg.LayerClock()
+	vest := &vesting.Mainnet
+	if cfg.Vesting != nil {
+		vest = cfg.Vesting
+	}
+	state := Snapshot{VaultTotal: vest.TotalVaulted}
+	state.IssuanceTotal = state.VaultTotal // vaulted amount is issued but not circulating yet
+
+	// note: we could optimize this and just step by tick interval, but we do the simplest possible thing here and get
+	// as close as possible to reality by stepping through every single layer
+	for layerID := types.Layer(0); ; layerID++ {
+		state.Layer = layerID
+		state.Epoch = layerID.Epoch()
+		state.Date = clock.LayerTime(layerID)
+		if cfg.Clock != nil {
+			state.Drift = state.Date.Sub(LayerTime(cfg.Genesis, layerID))
+		}
+
+		// update vault
+		// vault vesting is calculated on the basis of layers post-genesis
+		state.VaultTotalVest = vest.AccumulatedVestAtLayer(layerID)
+		vestThisLayer := vest.VestAtLayer(layerID)
+		state.CirculatingTotal += vestThisLayer
+
+		// add new issuance
+		// issuance is calculated on the basis of layers post-effective genesis
+		// and no issuance occurs before effective genesis
+		var subsidyThisLayer uint64
+		if effectiveLayer, ok := layerID.Effective(cfg.EffectiveGenesis); ok {
+			scheduledTotalNew := subsidy.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+			subsidyThisLayer = scheduledTotalNew - scheduledTotal
+			scheduledTotal = scheduledTotalNew
+		}
+
+		// an empty layer pays nothing; the next layer that isn't empty may make up for it
+		empty := cfg.EmptyLayers != nil && cfg.EmptyLayers.IsEmpty(layerID)
+		switch {
+		case empty && cfg.EmptyPolicy == emptylayers.Redistribute:
+			undistributed += subsidyThisLayer
+			subsidyThisLayer = 0
+		case empty:
+			subsidyThisLayer = 0
+		default:
+			subsidyThisLayer += undistributed
+			undistributed = 0
+		}
+
+		// malfeasant identities are still counted, but their share is never paid out
+		var forfeitedThisLayer uint64
+		if cfg.Malfeasance != nil {
+			forfeitedThisLayer = malfeasance.Forfeited(subsidyThisLayer, cfg.Malfeasance.FractionAtEpoch(state.Epoch))
+			subsidyThisLayer -= forfeitedThisLayer
+		}
+		state.ForfeitedTotal += forfeitedThisLayer
+
+		state.SubsidyPerLayer = subsidyThisLayer
+		state.CirculatingTotal += subsidyThisLayer
+		state.IssuanceTotal += subsidyThisLayer
+		state.SubsidyTotal += subsidyThisLayer
+		state.SubsidyShortfall = scheduledTotal - state.SubsidyTotal
+
+		// there's no block to collect (or burn) fees in an empty layer
+		var feesThisLayer, burnedThisLayer uint64
+		if cfg.Fees != nil && !empty {
+			feesThisLayer = cfg.Fees.FeesAtLayer(layerID)
+		}
+		if cfg.Burn != nil && !empty {
+			// we can't burn more than is circulating
+			burnedThisLayer = cfg.Burn.BurnAtLayer(layerID, feesThisLayer)
+			if burnedThisLayer > state.CirculatingTotal {
+				burnedThisLayer = state.CirculatingTotal
+			}
+		}
+		state.FeesTotal += feesThisLayer
+		state.BurnedTotal += burnedThisLayer
+		state.CirculatingTotal -= burnedThisLayer
+		if !result.BurnExceedsSubsidy && layerID >= cfg.EffectiveGenesis && burnedThisLayer > subsidyThisLayer {
+			result.BurnExceedsSubsidy = true
+			result.BurnExceedsSubsidyLayer = layerID
+		}
+
+		f := flows{
+			vest:      vestThisLayer,
+			subsidy:   subsidyThisLayer,
+			fees:      feesThisLayer,
+			forfeited: forfeitedThisLayer,
+			burned:    burnedThisLayer,
+		}
+		if empty {
+			f.empty = 1
+		}
+		ticks.add(f)
+		years.add(f)
+		epochs.add(f)
+
+		if uint64(layerID)%cfg.TickInterval == 0 || layerID == cfg.EndLayer {
+			ticks.take(state)
+		}
+		if layerID == cfg.EndLayer || clock.LayerTime(layerID+1).Year() != state.Date.Year() {
+			years.take(state)
+		}
+		if layerID == cfg.EndLayer || (layerID+1).Epoch() != state.Epoch {
+			epochs.take(state)
+		}
+		if cfg.Layers != nil && cfg.Layers.First <= layerID && layerID <= cfg.Layers.Last {
+			layers.add(f)
+			layers.take(state)
+		}
+
+		if onLayer != nil {
+			onLayer(layerID)
+		}
+
+		// check here rather than in the loop condition in case the end layer is the last representable one
+		if layerID == cfg.EndLayer {
+			break
+		}
+	}
+	result.Ticks = ticks.snapshots
+	result.Years = years.snapshots
+	result.Epochs = epochs.snapshots
+	result.Layers = layers.snapshots
+	return result
+}
@@ -0,0 +1,405 @@
+package simulation
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spacemeshos/economics/burn"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/emptylayers"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/malfeasance"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Ticks(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         5*constants.OneEpoch + 10,
+	}
+	result := Run(cfg, nil)
+
+	// one tick per epoch boundary, including genesis, plus the end layer
+	require.Len(t, result.Ticks, 7)
+	assert.Equal(t, uint64(1), result.Ticks[0].Layers)
+	assert.Equal(t, uint64(10), result.Ticks[6].Layers)
+
+	var layers uint64
+	var subsidy uint64
+	for i, s := range result.Ticks {
+		layers += s.Layers
+		subsidy += s.SubsidyNew
+		assert.Equal(t, subsidy, s.SubsidyTotal, "expected new subsidy to add up to total at tick %d", i)
+		assert.Equal(t, s.VaultTotal+s.SubsidyTotal, s.IssuanceTotal)
+		assert.Equal(t, s.IssuanceStart()+s.SubsidyNew, s.IssuanceTotal)
+		assert.Equal(t, cfg.Genesis.Add(time.Duration(s.Layer)*OneLayer), s.Date)
+		assert.Equal(t, s.Layer.Epoch(), s.Epoch)
+	}
+	assert.Equal(t, uint64(cfg.EndLayer)+1, layers)
+
+	// no issuance before effective genesis
+	assert.Zero(t, result.Ticks[1].SubsidyTotal)
+	assert.Equal(t, rewards.TotalSubsidyAtLayer(0), result.Ticks[2].SubsidyNew)
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(types.EffectiveLayer(cfg.EndLayer-cfg.EffectiveGenesis)), subsidy)
+}
+
+func Test_Years(t *testing.T) {
+	// start just before the turn of the year
+	cfg := Config{
+		Genesis:          time.Date(2023, 12, 31, 23, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 0,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         100,
+	}
+	result := Run(cfg, nil)
+
+	// twelve layers in 2023 and the rest in 2024
+	require.Len(t, result.Years, 2)
+	assert.Equal(t, 2023, result.Years[0].Date.Year())
+	assert.Equal(t, types.Layer(11), result.Years[0].Layer)
+	assert.Equal(t, uint64(12), result.Years[0].Layers)
+	assert.Equal(t, 2024, result.Years[1].Date.Year())
+	assert.Equal(t, uint64(89), result.Years[1].Layers)
+	assert.Equal(t, result.Years[1].SubsidyTotal, result.Years[0].SubsidyNew+result.Years[1].SubsidyNew)
+}
+
+func Test_EpochsAndLayers(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         3*constants.OneEpoch + 10,
+		Layers:           &LayerRange{First: 2*constants.OneEpoch - 2, Last: 4 * constants.OneEpoch},
+	}
+	result := Run(cfg, nil)
+
+	// an epoch's snapshot is at its last layer, and the last epoch is partial
+	require.Len(t, result.Epochs, 4)
+	for i, e := range result.Epochs[:3] {
+		assert.Equal(t, types.Epoch(i), e.Epoch)
+		assert.Equal(t, types.Layer((i+1)*constants.OneEpoch-1), e.Layer)
+		assert.Equal(t, uint64(constants.OneEpoch), e.Layers)
+	}
+	assert.Equal(t, cfg.EndLayer, result.Epochs[3].Layer)
+	assert.Equal(t, uint64(11), result.Epochs[3].Layers)
+	assert.Zero(t, result.Epochs[1].SubsidyTotal)
+	assert.Equal(t, result.Epochs[2].SubsidyTotal, result.Epochs[2].SubsidyNew)
+	assert.Equal(t, result.Ticks[len(result.Ticks)-1].SubsidyTotal,
+		result.Epochs[2].SubsidyNew+result.Epochs[3].SubsidyNew)
+
+	// one snapshot per layer in range, up to the end layer
+	require.Len(t, result.Layers, int(cfg.EndLayer-cfg.Layers.First)+1)
+	for i, l := range result.Layers {
+		assert.Equal(t, cfg.Layers.First+types.Layer(i), l.Layer)
+		assert.Equal(t, uint64(1), l.Layers)
+		assert.Equal(t, l.SubsidyPerLayer, l.SubsidyNew)
+	}
+	assert.Zero(t, result.Layers[1].SubsidyNew)
+	assert.NotZero(t, result.Layers[2].SubsidyNew)
+	assert.Equal(t, result.Epochs[1].Layer, result.Layers[1].Layer)
+	assert.Equal(t, result.Epochs[2].CirculatingTotal, result.Layers[constants.OneEpoch+1].CirculatingTotal)
+}
+
+func Test_Aggregate(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         5*constants.OneEpoch + 10,
+		Fees:             fees.Constant(3),
+		Burn:             burn.Fraction(0.5),
+	}
+	ticks := Run(cfg, nil).Ticks
+
+	// aggregating ticks is the same as ticking less often, except for the first tick which covers genesis alone
+	cfg.TickInterval = 2 * constants.OneEpoch
+	coarse := Run(cfg, nil).Ticks
+	require.Len(t, coarse, 4)
+	assert.Equal(t, coarse[1], Aggregate(ticks[1:3]))
+	assert.Equal(t, coarse[2], Aggregate(ticks[3:5]))
+	assert.Equal(t, coarse[3], Aggregate(ticks[5:7]))
+	assert.Equal(t, ticks[5], Aggregate(ticks[5:6]))
+
+	all := Aggregate(ticks)
+	assert.Equal(t, uint64(cfg.EndLayer)+1, all.Layers)
+	assert.Equal(t, all.SubsidyTotal, all.SubsidyNew)
+	assert.Equal(t, all.FeesTotal, all.FeesNew)
+	assert.Equal(t, all.BurnedTotal, all.BurnedNew)
+	assert.Equal(t, ticks[6].Layer, all.Layer)
+}
+
+func Test_LayerTime(t *testing.T) {
+	genesis := time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, genesis, LayerTime(genesis, 0))
+	assert.Equal(t, genesis.Add(OneLayer), LayerTime(genesis, 1))
+	assert.Equal(t, genesis.Add(10*365*24*time.Hour), LayerTime(genesis, 10*constants.OneYear))
+
+	// beyond the range of time.Duration
+	assert.Equal(t, time.Date(3023, 7, 14, 0, 0, 0, 0, time.UTC), LayerTime(genesis, 365242*layersPerDay))
+	assert.Equal(t, time.Date(3023, 7, 14, 0, 5, 0, 0, time.UTC), LayerTime(genesis, 365242*layersPerDay+1))
+}
+
+func Test_Fees(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     100,
+		EndLayer:         1000,
+		Fees:             fees.Linear{Start: 0, Increase: 1},
+	}
+	result := Run(cfg, nil)
+
+	var total uint64
+	for _, s := range result.Ticks {
+		total += s.FeesNew
+		assert.Equal(t, total, s.FeesTotal)
+		assert.Equal(t, s.VaultTotal+s.SubsidyTotal, s.IssuanceTotal, "fees don't count towards issuance")
+	}
+	assert.Equal(t, uint64(1000*1001/2), total)
+	require.Len(t, result.Years, 1)
+	assert.Equal(t, total, result.Years[0].FeesNew)
+}
+
+func Test_EmptyLayers(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         4 * constants.OneEpoch,
+		Fees:             fees.Constant(1),
+	}
+	full := Run(cfg, nil)
+	scheduled := full.Ticks[len(full.Ticks)-1].SubsidyTotal
+
+	// forfeit: the subsidy for empty layers is never issued
+	cfg.EmptyLayers = emptylayers.List{0: {}, 2 * constants.OneEpoch: {}, 3 * constants.OneEpoch: {}}
+	forfeit := Run(cfg, nil)
+	require.Len(t, forfeit.Ticks, len(full.Ticks))
+	last := forfeit.Ticks[len(forfeit.Ticks)-1]
+	lost := rewards.TotalSubsidyAtLayer(0) + rewards.TotalSubsidyAtLayer(constants.OneEpoch)
+	assert.Equal(t, lost, last.SubsidyShortfall)
+	assert.Equal(t, scheduled-lost, last.SubsidyTotal)
+	assert.Equal(t, last.VaultTotal+last.SubsidyTotal, last.IssuanceTotal)
+	assert.Equal(t, uint64(4*constants.OneEpoch+1-3), last.FeesTotal, "no fees are paid in empty layers")
+
+	var empty uint64
+	for _, s := range forfeit.Ticks {
+		empty += s.EmptyNew
+	}
+	assert.Equal(t, uint64(3), empty)
+	assert.Equal(t, uint64(0), forfeit.Ticks[2].SubsidyPerLayer)
+
+	// redistribute: the subsidy for an empty layer is paid in the next layer
+	cfg.EmptyPolicy = emptylayers.Redistribute
+	redistribute := Run(cfg, nil)
+	last = redistribute.Ticks[len(redistribute.Ticks)-1]
+	assert.Equal(t, uint64(0), last.SubsidyShortfall)
+	assert.Equal(t, scheduled, last.SubsidyTotal)
+	assert.Equal(t, rewards.TotalSubsidyAtLayer(constants.OneEpoch), redistribute.Ticks[3].SubsidyShortfall,
+		"shortfall outstanding until the next layer")
+
+	// an empty final layer leaves a shortfall at the end
+	cfg.EmptyLayers = emptylayers.List{4 * constants.OneEpoch: {}}
+	last = Run(cfg, nil).Ticks[len(full.Ticks)-1]
+	assert.Equal(t, rewards.TotalSubsidyAtLayer(2*constants.OneEpoch), last.SubsidyShortfall)
+}
+
+func Test_Malfeasance(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         2*constants.OneEpoch + 1,
+		Malfeasance:      malfeasance.Constant(0.25),
+		EmptyLayers:      emptylayers.List{2 * constants.OneEpoch: {}},
+		EmptyPolicy:      emptylayers.Redistribute,
+	}
+	last := Run(cfg, nil).Ticks[3]
+
+	// the subsidy of the empty layer is redistributed, but the malfeasant share of it is forfeited
+	scheduled := rewards.TotalAccumulatedSubsidyAtLayer(1)
+	paid := scheduled - uint64(float64(scheduled)*0.25)
+	assert.Equal(t, paid, last.SubsidyPerLayer)
+	assert.Equal(t, paid, last.SubsidyTotal)
+	assert.Equal(t, scheduled-paid, last.SubsidyShortfall)
+	assert.Equal(t, scheduled-paid, last.ForfeitedTotal)
+	assert.Equal(t, last.IssuanceTotal+scheduled-paid, last.IssuanceMax())
+	assert.Equal(t, last.CirculatingTotal+scheduled-paid, last.CirculatingMax())
+}
+
+func Test_Burn(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         2*constants.OneEpoch + 1,
+		Fees:             fees.Constant(1000),
+		Burn:             burn.Fraction(1),
+	}
+	result := Run(cfg, nil)
+	last := result.Ticks[3]
+
+	// nothing circulates before effective genesis, so there's nothing to burn
+	assert.Equal(t, uint64(1000), result.Ticks[2].BurnedTotal)
+	assert.Equal(t, uint64(1000), last.BurnedNew)
+	assert.Equal(t, uint64(2000), last.BurnedTotal)
+	assert.Equal(t, last.SubsidyTotal-2000, last.CirculatingTotal)
+	assert.Equal(t, last.IssuanceTotal-2000, last.NetIssuance())
+	assert.Equal(t, result.Ticks[2].CirculatingTotal, last.CirculatingStart())
+	assert.False(t, result.BurnExceedsSubsidy)
+
+	// burning more than the subsidy of the second layer
+	volume, err := fees.NewTable([]fees.Record{{Layer: cfg.EndLayer, Fees: last.SubsidyPerLayer + 1}})
+	require.NoError(t, err)
+	cfg.Burn = burn.Series{Volume: volume}
+	result = Run(cfg, nil)
+	assert.True(t, result.BurnExceedsSubsidy)
+	assert.Equal(t, cfg.EndLayer, result.BurnExceedsSubsidyLayer)
+
+	// but never more than circulates
+	cfg.Burn = burn.Series{Volume: fees.Constant(math.MaxUint64)}
+	result = Run(cfg, nil)
+	assert.Equal(t, uint64(0), result.Ticks[3].CirculatingTotal)
+	assert.Equal(t, result.Ticks[3].SubsidyTotal, result.Ticks[3].BurnedTotal)
+}
+
+func Test_Vesting(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         4 * constants.OneEpoch,
+		Vesting: &vesting.Schedule{
+			TotalVaulted:  1000,
+			VestedAtCliff: 100,
+			VestStart:     10,
+			VestEnd:       2 * constants.OneEpoch,
+		},
+	}
+	ticks := Run(cfg, nil).Ticks
+
+	// the vault vests according to the given schedule rather than the mainnet one
+	assert.Equal(t, uint64(1000), ticks[0].VaultTotal)
+	assert.Zero(t, ticks[0].VaultTotalVest)
+	assert.Equal(t, cfg.Vesting.AccumulatedVestAtLayer(constants.OneEpoch), ticks[1].VaultTotalVest)
+	assert.Equal(t, uint64(1000), ticks[2].VaultTotalVest)
+	assert.Equal(t, uint64(1000)+ticks[4].SubsidyTotal, ticks[4].CirculatingTotal)
+	assert.Equal(t, ticks[4].CirculatingTotal, ticks[4].IssuanceTotal)
+}
+
+func Test_ObservedClock(t *testing.T) {
+	genesis := time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC)
+	clock, err := NewObservedClock(genesis, []Observation{
+		{Layer: 200, Time: genesis.Add(200*OneLayer + time.Hour)},
+		{Layer: 100, Time: genesis.Add(100*OneLayer + 30*time.Minute)},
+	})
+	require.NoError(t, err)
+
+	// genesis counts as an observation, layers in between are interpolated and later layers extrapolated
+	assert.Equal(t, genesis, clock.LayerTime(0))
+	assert.Equal(t, genesis.Add(50*OneLayer+15*time.Minute), clock.LayerTime(50))
+	assert.Equal(t, genesis.Add(150*OneLayer+45*time.Minute), clock.LayerTime(150))
+	assert.Equal(t, genesis.Add(1000*OneLayer+time.Hour), clock.LayerTime(1000))
+	assert.Equal(t, Observation{Layer: 200, Time: genesis.Add(200*OneLayer + time.Hour)}, clock.LastObserved())
+	assert.Equal(t, time.Hour, Drift(clock, genesis, 10*constants.OneYear))
+	assert.Equal(t, time.Duration(0), Drift(NominalClock{genesis}, genesis, 10*constants.OneYear))
+
+	for _, layerID := range []types.Layer{0, 1, 50, 99, 100, 150, 200, 201, constants.OneYear, 1 << 39} {
+		found, ok := LayerAt(clock, clock.LayerTime(layerID))
+		require.True(t, ok)
+		assert.Equal(t, layerID, found)
+		found, ok = LayerAt(clock, clock.LayerTime(layerID).Add(time.Second))
+		require.True(t, ok)
+		assert.Equal(t, layerID, found, "a layer is in progress until the next one starts")
+	}
+	_, ok := LayerAt(clock, genesis.Add(-time.Second))
+	assert.False(t, ok)
+	_, ok = LayerAt(clock, genesis.AddDate(100_000_000, 0, 0))
+	assert.False(t, ok)
+
+	// an observation of layer zero replaces genesis
+	clock, err = NewObservedClock(genesis, []Observation{{Layer: 0, Time: genesis.Add(time.Minute)}})
+	require.NoError(t, err)
+	assert.Equal(t, genesis.Add(time.Minute+OneLayer), clock.LayerTime(1))
+
+	_, err = NewObservedClock(genesis, []Observation{{Layer: 1, Time: genesis}})
+	assert.Error(t, err, "time must increase with layers")
+	_, err = NewObservedClock(genesis, []Observation{{Layer: 1, Time: genesis.Add(time.Hour)}, {Layer: 1}})
+	assert.Error(t, err, "duplicate layer")
+}
+
+func Test_ReadObservations(t *testing.T) {
+	observations, err := ReadObservations(strings.NewReader(
+		"layer,time\n4032,2023-07-28T02:30:00+02:00\n8064,1691712000\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Observation{
+		{Layer: 4032, Time: time.Date(2023, 7, 28, 0, 30, 0, 0, time.UTC)},
+		{Layer: 8064, Time: time.Date(2023, 8, 11, 0, 0, 0, 0, time.UTC)},
+	}, observations)
+
+	for name, input := range map[string]string{
+		"bad header": "layer,date\n1,1\n",
+		"bad layer":  "layer,time\nx,1\n",
+		"bad time":   "layer,time\n1,yesterday\n",
+		"empty":      "layer,time\n",
+	} {
+		_, err := ReadObservations(strings.NewReader(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func Test_Clock(t *testing.T) {
+	cfg := Config{
+		Genesis:          time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     100,
+		EndLayer:         600,
+	}
+	nominal := Run(cfg, nil)
+
+	// the chain runs an hour late from layer 100, which moves the end of the year
+	var err error
+	cfg.Clock, err = NewObservedClock(cfg.Genesis, []Observation{{Layer: 100, Time: LayerTime(cfg.Genesis, 100).Add(time.Hour)}})
+	require.NoError(t, err)
+	observed := Run(cfg, nil)
+	assert.Equal(t, time.Hour, observed.Ticks[6].Drift)
+	assert.Equal(t, nominal.Ticks[6].Date.Add(time.Hour), observed.Ticks[6].Date)
+	assert.Equal(t, time.Duration(0), nominal.Ticks[6].Drift)
+
+	require.Len(t, observed.Years, 2)
+	assert.Equal(t, nominal.Years[0].Layers-12, observed.Years[0].Layers)
+	assert.Equal(t, cfg.EndLayer, observed.Years[1].Layer)
+}
+
+func Test_MalfeasanceByEpoch(t *testing.T) {
+	table, err := malfeasance.NewTable([]malfeasance.Record{{Epoch: 3, Fraction: 0.5}})
+	require.NoError(t, err)
+	cfg := Config{
+		Genesis:          time.Date(2023, 7, 14, 0, 0, 0, 0, time.UTC),
+		EffectiveGenesis: 2 * constants.OneEpoch,
+		TickInterval:     constants.OneEpoch,
+		EndLayer:         4*constants.OneEpoch - 1,
+		Malfeasance:      table,
+	}
+	ticks := Run(cfg, nil).Ticks
+
+	// nothing is forfeited in epoch 2, half of the subsidy in epoch 3; the tick at the first layer of epoch 3 covers
+	// the rest of epoch 2 and that one layer
+	require.Len(t, ticks, 5)
+	assert.Equal(t, rewards.TotalSubsidyAtLayer(constants.OneEpoch)/2, ticks[3].ForfeitedNew)
+	var forfeited uint64
+	for layerID := types.EffectiveLayer(constants.OneEpoch); layerID < 2*constants.OneEpoch; layerID++ {
+		forfeited += rewards.TotalSubsidyAtLayer(layerID) / 2
+	}
+	assert.Equal(t, forfeited, ticks[4].ForfeitedTotal)
+	assert.Equal(t, ticks[4].ForfeitedTotal, ticks[4].SubsidyShortfall)
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(2*constants.OneEpoch-1), ticks[4].SubsidyTotal+forfeited)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/jedib0t/go-pretty/v6/text"
+)
+
+// solveCommand derives the schedule constants from a policy goal.
+func solveCommand(args []string) {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	total := fs.Uint64("total", constants.TotalIssuance/constants.OneSmesh, "total issuance, in SMESH")
+	vaulted := fs.Uint64("vaulted", constants.TotalVaulted/constants.OneSmesh, "amount vaulted at genesis, in SMESH")
+	target := fs.String("target", "", "policy goal (required): tenyear:<SMESH>, issuance:<SMESH>:<years>, "+
+		"fraction:<fraction of subsidy>:<years> or halflife:<years>")
+	format := fs.String("format", formatTable, "output format: table, csv or json")
+	_ = fs.Parse(args)
+
+	if *target == "" {
+		log.Fatal("missing target")
+	}
+	t, err := rewards.ParseTarget(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	totalIssuance, err := toSmidge(*total, "total issuance")
+	if err != nil {
+		log.Fatal(err)
+	}
+	totalVaulted, err := toSmidge(*vaulted, "total vaulted")
+	if err != nil {
+		log.Fatal(err)
+	}
+	solution, err := rewards.Solve(totalIssuance, totalVaulted, t)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := renderSolution(os.Stdout, *format, solution); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// toSmidge converts an amount in SMESH to smidge, checking that it fits.
+func toSmidge(smesh uint64, name string) (uint64, error) {
+	if smesh > math.MaxUint64/constants.OneSmesh {
+		return 0, fmt.Errorf("%s of %d SMESH out of range", name, smesh)
+	}
+	return smesh * constants.OneSmesh, nil
+}
+
+// parameter is a named constant of a solved schedule.
+type parameter struct {
+	name  string
+	value *decimal.Big
+	unit  string
+}
+
+// solutionParameters lists the constants of a solved schedule, as in the rewards package.
+func solutionParameters(solution rewards.Solution) []parameter {
+	years := func(layers *decimal.Big) *decimal.Big {
+		return rewards.Ctx.Quo(decimal.WithContext(rewards.Ctx), layers,
+			decimal.WithContext(rewards.Ctx).SetUint64(constants.OneYear))
+	}
+	// the ten year target is in SMESH, as taken by a tenyear: target
+	smidge := rewards.Ctx.RoundToInt(decimal.WithContext(rewards.Ctx).Copy(solution.TenYearIssuance)).Int(nil)
+	tenYearTarget := decimal.WithContext(rewards.Ctx).SetBigMantScale(smidge, 9)
+	return []parameter{
+		{"TotalSubsidy", solution.TotalSubsidy, "smidge"},
+		{"HalfLife", solution.HalfLife, "layers"},
+		{"HalfLife", years(solution.HalfLife), "years"},
+		{"Lambda", solution.Lambda, "per layer"},
+		{"FinalLayer", solution.FinalLayer, "layers post-effective genesis"},
+		{"FinalLayer", years(solution.FinalLayer), "years post-effective genesis"},
+		{"TenYearIssuance", solution.TenYearIssuance, "smidge"},
+		{"TenYearTarget", tenYearTarget, "SMESH"},
+	}
+}
+
+// parameterColumns shows values with full precision. JSON values are strings, since they exceed the precision of a
+// JSON number.
+func parameterColumns() []column[parameter] {
+	return []column[parameter]{
+		{
+			name:  "parameter",
+			cell:  func(_ *printer, p parameter) any { return p.name },
+			value: func(p parameter) any { return p.name },
+		},
+		{
+			name:  "value",
+			align: text.AlignRight,
+			cell:  func(_ *printer, p parameter) any { return p.value.String() },
+			value: func(p parameter) any { return p.value.String() },
+		},
+		{
+			name:  "unit",
+			cell:  func(_ *printer, p parameter) any { return p.unit },
+			value: func(p parameter) any { return p.unit },
+		},
+	}
+}
+
+func renderSolution(w io.Writer, format string, solution rewards.Solution) error {
+	columns := parameterColumns()
+	params := solutionParameters(solution)
+	switch format {
+	case formatTable:
+		renderTable(w, defaultPrinter(), columns, params, "Please note:\n"+
+			"- Values are computed with 34 digits of precision (decimal128)\n"+
+			"- TenYearTarget is TenYearIssuance rounded to the smidge, in SMESH, as taken by a tenyear: target\n")
+		return nil
+	case formatCSV:
+		return renderCSV(w, columns, params)
+	case formatJSON:
+		return renderJSON(w, []jsonSection[parameter]{{"parameters", columns, params}})
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
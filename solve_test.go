@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/ericlagergren/decimal"
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RenderSolution(t *testing.T) {
+	solution, err := rewards.Solve(constants.TotalIssuance, constants.TotalVaulted,
+		rewards.IssuanceTarget{Issuance: constants.TenYearTarget, Years: decimal.New(10, 0)})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderSolution(&buf, formatCSV, solution))
+	assert.Contains(t, buf.String(), "Lambda,"+rewards.Lambda.String()+",per layer\n")
+	assert.Contains(t, buf.String(), fmt.Sprintf("TenYearTarget,%d.000000000,SMESH\n",
+		constants.TenYearTarget/constants.OneSmesh))
+}
+
+func Test_ToSmidge(t *testing.T) {
+	smidge, err := toSmidge(constants.TotalVaulted/constants.OneSmesh, "total vaulted")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(constants.TotalVaulted), smidge)
+
+	_, err = toSmidge(math.MaxUint64/constants.OneSmesh+1, "total vaulted")
+	assert.ErrorContains(t, err, "total vaulted")
+}
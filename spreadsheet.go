@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/xlsx"
+)
+
+// spreadsheetCell returns the cell of a column in a spreadsheet: amounts in SMESH, dates and percentages as such, and
+// other values as in the machine readable formats. A value left undefined is an empty cell.
+func spreadsheetCell[T any](c column[T], row T) xlsx.Cell {
+	switch {
+	case c.value != nil && c.value(row) == nil:
+		return xlsx.Cell{}
+	case c.amount != nil:
+		amount := c.amount(row)
+		return xlsx.Number(fmt.Sprintf("%d.%09d", amount/constants.OneSmesh, amount%constants.OneSmesh), xlsx.Decimal)
+	case c.date != nil:
+		return xlsx.DateCell(c.date(row))
+	}
+	switch v := c.value(row).(type) {
+	case uint64:
+		return xlsx.Number(strconv.FormatUint(v, 10), xlsx.General)
+	case int:
+		return xlsx.Number(strconv.Itoa(v), xlsx.General)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return xlsx.Cell{}
+		}
+		if c.percent != 0 {
+			return xlsx.Float(v/c.percent, xlsx.Percent)
+		}
+		return xlsx.Float(v, xlsx.General)
+	case json.Number:
+		return xlsx.Number(string(v), xlsx.General)
+	case string:
+		return xlsx.String(v)
+	default:
+		return xlsx.Cell{}
+	}
+}
+
+// spreadsheetSheet returns a sheet of the given rows, with the leading columns that identify a row, such as the layer,
+// epoch and date, kept in view.
+func spreadsheetSheet[T any](name string, columns []column[T], rows []T) xlsx.Sheet {
+	s := xlsx.Sheet{Name: name, Header: make([]string, len(columns)), Rows: make([][]xlsx.Cell, len(rows))}
+	for i, c := range columns {
+		s.Header[i] = c.name
+	}
+	for s.FrozenColumns < len(columns) {
+		switch columns[s.FrozenColumns].name {
+		case "layer", "epoch", "date", "year", "milestone", "parameter":
+			s.FrozenColumns++
+			continue
+		}
+		break
+	}
+	for i, r := range rows {
+		s.Rows[i] = make([]xlsx.Cell, len(columns))
+		for j, c := range columns {
+			s.Rows[i][j] = spreadsheetCell(c, r)
+		}
+	}
+	return s
+}
+
+// writeSpreadsheet writes a simulation run as an XLSX workbook with a sheet each for the ticks, the summary per
+// calendar year, the milestones and the parameters. Amounts are in SMESH.
+func writeSpreadsheet(w io.Writer, settings []setting, milestones []milestone, opts renderOptions,
+	result simulation.Result,
+) error {
+	ticks, err := selectTickColumns(opts)
+	if err != nil {
+		return err
+	}
+	return xlsx.Write(w, []xlsx.Sheet{
+		spreadsheetSheet("Ticks", ticks, result.Ticks),
+		spreadsheetSheet("Years", yearColumns(), result.Years),
+		spreadsheetSheet("Milestones", milestoneColumns(), milestones),
+		spreadsheetSheet("Parameters", settingSheetColumns(), settings),
+	})
+}
+
+// writeSpreadsheetFile writes the spreadsheet to a file.
+func writeSpreadsheetFile(path string, settings []setting, milestones []milestone, opts renderOptions,
+	result simulation.Result,
+) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeSpreadsheet(f, settings, milestones, opts, result); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
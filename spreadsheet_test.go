@@ -0,0 +1,62 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Spreadsheet(t *testing.T) {
+	cfg := testConfig(constants.VestStart)
+	result := simulation.Run(cfg, nil)
+	settings := simulationSettings(cfg, []setting{{"fees", ""}})
+	var buf bytes.Buffer
+	require.NoError(t, writeSpreadsheet(&buf, settings, simulationMilestones(cfg, result), renderOptions{inflation: true},
+		result))
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	read := func(name string) string {
+		f, err := z.Open(name)
+		require.NoError(t, err, name)
+		defer f.Close()
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		return string(data)
+	}
+	workbook := read("xl/workbook.xml")
+	for i, name := range []string{"Ticks", "Years", "Milestones", "Parameters"} {
+		assert.Contains(t, workbook, fmt.Sprintf(`<sheet name="%s" sheetId="%d"`, name, i+1))
+	}
+
+	// the layer, epoch and date are kept in view, and values are typed
+	ticks := read("xl/worksheets/sheet1.xml")
+	assert.Contains(t, ticks, `<pane xSplit="3" ySplit="1" topLeftCell="D2"`)
+	assert.Contains(t, ticks, `<c r="C2" s="6"><v>45121</v></c>`)
+	last := result.Ticks[len(result.Ticks)-1]
+	row := strconv.Itoa(len(result.Ticks) + 1)
+	assert.Contains(t, ticks, fmt.Sprintf(`<c r="A%s" s="2"><v>%d</v></c>`, row, last.Layer))
+	assert.Contains(t, ticks, fmt.Sprintf(`<c r="H%s" s="4"><v>%d.%09d</v></c>`, row,
+		last.SubsidyPerLayer/constants.OneSmesh, last.SubsidyPerLayer%constants.OneSmesh))
+	assert.Contains(t, ticks, fmt.Sprintf(`<c r="M%s" s="5"><v>1</v></c>`, "2"))
+	assert.Equal(t, len(result.Ticks)+1, strings.Count(ticks, "<row "))
+
+	years := read("xl/worksheets/sheet2.xml")
+	assert.Contains(t, years, `<c r="A2" s="2"><v>2023</v></c>`)
+	assert.Contains(t, read("xl/worksheets/sheet3.xml"), "vesting cliff")
+	// parameters have their layer, amount and date as numbers and dates
+	parameters := read("xl/worksheets/sheet4.xml")
+	assert.Contains(t, parameters, `<t xml:space="preserve">none</t>`)
+	assert.Contains(t, parameters, `2023-07-14</t></is></c><c r="E2" s="6"><v>45121</v></c></row>`)
+	assert.Contains(t, parameters, `<c r="C3" s="2"><v>8064</v></c><c r="E3" s="6"><v>45149</v></c></row>`)
+	assert.Contains(t, parameters, `SMESH</t></is></c><c r="D6" s="4"><v>2400000000.000000000</v></c></row>`)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/spacemeshos/economics/types"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	_ "modernc.org/sqlite" // pure Go, so the database can be written without cgo
+)
+
+// sqliteTime is the format of dates in the database, which SQLite's date and time functions understand. Times are UTC.
+const sqliteTime = "2006-01-02 15:04:05"
+
+// sqliteTables are the tables written to the database. Other tables are left alone, so the database can hold data
+// of its own to join with the simulation output.
+var sqliteTables = []string{"parameters", "epochs", "layers"}
+
+// parseLayerRange parses a range of layers given as first:last.
+func parseLayerRange(spec string) (*simulation.LayerRange, error) {
+	first, last, ok := strings.Cut(spec, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected first:last, got %q", spec)
+	}
+	from, err := strconv.ParseUint(first, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid first layer %q", first)
+	}
+	to, err := strconv.ParseUint(last, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last layer %q", last)
+	}
+	if from > to {
+		return nil, fmt.Errorf("first layer %d is after last layer %d", from, to)
+	}
+	return &simulation.LayerRange{First: types.Layer(from), Last: types.Layer(to)}, nil
+}
+
+// sqliteAmountColumns returns the columns of amounts of the simulation output, which are stored in smidge.
+func sqliteAmountColumns(opts renderOptions) []snapshotColumn {
+	var amounts []snapshotColumn
+	for _, c := range tickColumns(opts) {
+		if c.amount != nil {
+			amounts = append(amounts, c)
+		}
+	}
+	return amounts
+}
+
+// sqliteKeyColumns are the columns of the epochs and layers tables other than the amounts, whose names the amounts
+// mustn't take.
+var sqliteKeyColumns = []string{"epoch", "firstLayer", "lastLayer", "layers", "layer", "date"}
+
+// sqliteIdentifier quotes a name as an SQL identifier.
+func sqliteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// sqliteSchema returns the statements that create the tables: the parameters of the run, a row per epoch and a row per
+// layer in the range selected, each with the amounts as of its last layer and over its layers. Column names must be
+// distinct, regardless of case as in SQLite.
+func sqliteSchema(amounts []snapshotColumn) ([]string, error) {
+	names := make(map[string]bool, len(sqliteKeyColumns)+len(amounts))
+	for _, name := range sqliteKeyColumns {
+		names[strings.ToLower(name)] = true
+	}
+	var columns string
+	for _, c := range amounts {
+		if names[strings.ToLower(c.name)] {
+			return nil, fmt.Errorf("column %q: duplicate column name in the database", c.name)
+		}
+		names[strings.ToLower(c.name)] = true
+		columns += ", " + sqliteIdentifier(c.name) + " INTEGER NOT NULL"
+	}
+	return []string{
+		`CREATE TABLE parameters (name TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+		`CREATE TABLE epochs (epoch INTEGER PRIMARY KEY, firstLayer INTEGER NOT NULL, lastLayer INTEGER NOT NULL, ` +
+			`layers INTEGER NOT NULL, date TEXT NOT NULL` + columns + `)`,
+		`CREATE UNIQUE INDEX epochs_lastLayer ON epochs (lastLayer)`,
+		`CREATE INDEX epochs_date ON epochs (date)`,
+		`CREATE TABLE layers (layer INTEGER PRIMARY KEY, epoch INTEGER NOT NULL, date TEXT NOT NULL` + columns + `)`,
+		`CREATE INDEX layers_epoch ON layers (epoch)`,
+		`CREATE INDEX layers_date ON layers (date)`,
+	}, nil
+}
+
+// sqliteAmounts returns the amounts of a snapshot, which must fit in SQLite's signed integers.
+func sqliteAmounts(amounts []snapshotColumn, s simulation.Snapshot) ([]any, error) {
+	values := make([]any, len(amounts))
+	for i, c := range amounts {
+		amount := c.amount(s)
+		if amount > math.MaxInt64 {
+			return nil, fmt.Errorf("layer %d: %s of %d smidge is too large to store", s.Layer, c.name, amount)
+		}
+		values[i] = int64(amount)
+	}
+	return values, nil
+}
+
+// insertRows inserts rows into a table with a prepared statement. row returns the values of each row in turn.
+func insertRows(tx *sql.Tx, table string, columns, rows int, row func(i int) ([]any, error)) error {
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", table,
+		strings.TrimSuffix(strings.Repeat("?, ", columns), ", ")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i := 0; i < rows; i++ {
+		values, err := row(i)
+		if err != nil {
+			return err
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// writeSQLite writes a simulation run into a SQLite database, replacing the tables written by a previous run. The
+// database is created if it doesn't exist.
+func writeSQLite(path string, settings []setting, opts renderOptions, result simulation.Result) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	amounts := sqliteAmountColumns(opts)
+	for _, table := range sqliteTables {
+		if _, err := tx.Exec("DROP TABLE IF EXISTS " + table); err != nil {
+			return err
+		}
+	}
+	schema, err := sqliteSchema(amounts)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range schema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	p := message.NewPrinter(language.English)
+	err = insertRows(tx, "parameters", 2, len(settings), func(i int) ([]any, error) {
+		return []any{settings[i].name, settings[i].text(p)}, nil
+	})
+	if err != nil {
+		return err
+	}
+	err = insertRows(tx, "epochs", 5+len(amounts), len(result.Epochs), func(i int) ([]any, error) {
+		e := result.Epochs[i]
+		values, err := sqliteAmounts(amounts, e)
+		if err != nil {
+			return nil, err
+		}
+		firstLayer := int64(e.Layer) - int64(e.Layers) + 1
+		return append([]any{int64(e.Epoch), firstLayer, int64(e.Layer), int64(e.Layers),
+			e.Date.UTC().Format(sqliteTime)}, values...), nil
+	})
+	if err != nil {
+		return err
+	}
+	err = insertRows(tx, "layers", 3+len(amounts), len(result.Layers), func(i int) ([]any, error) {
+		l := result.Layers[i]
+		values, err := sqliteAmounts(amounts, l)
+		if err != nil {
+			return nil, err
+		}
+		return append([]any{int64(l.Layer), int64(l.Epoch), l.Date.UTC().Format(sqliteTime)}, values...), nil
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
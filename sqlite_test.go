@@ -0,0 +1,89 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/fees"
+	"github.com/spacemeshos/economics/simulation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SQLite(t *testing.T) {
+	layers, err := parseLayerRange("8060:8070")
+	require.NoError(t, err)
+	cfg := testConfig(5 * constants.OneEpoch)
+	cfg.Fees = fees.Constant(7)
+	cfg.Layers = layers
+	result := simulation.Run(cfg, nil)
+	path := filepath.Join(t.TempDir(), "schedule.db")
+
+	// tables other than ours are kept
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer db.Close()
+	_, err = db.Exec("CREATE TABLE prices (date TEXT, usd REAL)")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE epochs (stale INTEGER)")
+	require.NoError(t, err)
+
+	settings := simulationSettings(cfg, []setting{{"fees", "constant:7"}})
+	require.NoError(t, writeSQLite(path, settings, renderOptions{fees: true}, result))
+	require.NoError(t, writeSQLite(path, settings, renderOptions{fees: true}, result))
+	query := func(query string, dest ...any) {
+		require.NoError(t, db.QueryRow(query).Scan(dest...), query)
+	}
+	var n int
+	query("SELECT count(*) FROM prices", &n)
+	assert.Zero(t, n)
+	query("SELECT count(*) FROM parameters", &n)
+	assert.Equal(t, len(settings), n)
+	var value string
+	query("SELECT value FROM parameters WHERE name = 'fees'", &value)
+	assert.Equal(t, "constant:7", value)
+
+	// one row per epoch, the last one partial, with amounts in smidge
+	query("SELECT count(*) FROM epochs", &n)
+	assert.Equal(t, 6, n)
+	var first, last, count, subsidy, circulating, feesNew int64
+	var date string
+	query("SELECT firstLayer, lastLayer, layers, date, subsidyNew, circulatingTotal, feesNew FROM epochs "+
+		"WHERE epoch = 2", &first, &last, &count, &date, &subsidy, &circulating, &feesNew)
+	assert.Equal(t, []int64{2 * constants.OneEpoch, 3*constants.OneEpoch - 1, constants.OneEpoch},
+		[]int64{first, last, count})
+	assert.Equal(t, result.Epochs[2].Date.UTC().Format("2006-01-02 15:04:05"), date)
+	assert.Equal(t, int64(result.Epochs[2].SubsidyNew), subsidy)
+	assert.Equal(t, int64(result.Epochs[2].CirculatingTotal), circulating)
+	assert.Equal(t, int64(7*constants.OneEpoch), feesNew)
+	query("SELECT layers FROM epochs WHERE epoch = 5", &count)
+	assert.Equal(t, int64(1), count)
+	query("SELECT sum(subsidyNew) FROM epochs", &subsidy)
+	assert.Equal(t, int64(result.Ticks[len(result.Ticks)-1].SubsidyTotal), subsidy)
+
+	// one row per layer in range, which joins with the epochs
+	query("SELECT count(*), min(layer), max(layer) FROM layers", &n, &first, &last)
+	assert.Equal(t, []int64{11, 8060, 8070}, []int64{int64(n), first, last})
+	query("SELECT l.subsidyNew, e.subsidyPerLayer FROM layers l JOIN epochs e ON e.lastLayer = l.layer",
+		&subsidy, &feesNew)
+	assert.Zero(t, subsidy)
+	assert.Zero(t, feesNew)
+	query("SELECT subsidyNew FROM layers WHERE layer = 8064", &subsidy)
+	assert.Equal(t, int64(result.Layers[4].SubsidyPerLayer), subsidy)
+	assert.NotZero(t, subsidy)
+
+	for _, spec := range []string{"", "1", "2:1", "a:2", "1:b"} {
+		_, err := parseLayerRange(spec)
+		assert.Error(t, err, spec)
+	}
+	// amount columns mustn't collide with the key columns or each other, and names are quoted as SQL
+	_, err = sqliteSchema([]snapshotColumn{{name: "Date"}})
+	assert.Error(t, err)
+	_, err = sqliteSchema([]snapshotColumn{{name: "a"}, {name: "A"}})
+	assert.Error(t, err)
+	schema, err := sqliteSchema([]snapshotColumn{{name: `a"b`}})
+	require.NoError(t, err)
+	assert.Contains(t, schema[1], `, "a""b" INTEGER NOT NULL)`)
+}
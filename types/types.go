@@ -0,0 +1,54 @@
+// Package types defines the units used to index the schedules. Layers are counted either from genesis or from
+// effective genesis (the first layer in which subsidy is issued), and the two are not interchangeable: vesting is
+// defined in terms of the former and subsidy in terms of the latter. Distinct types make it a compile error to mix
+// them up.
+package types
+
+import (
+	"math"
+
+	"github.com/spacemeshos/economics/constants"
+)
+
+// Layer is a layer number counted from genesis (the genesis layer is zero).
+type Layer uint64
+
+// EffectiveLayer is a layer number counted from effective genesis (the effective genesis layer is zero).
+type EffectiveLayer uint64
+
+// Epoch is an epoch number counted from genesis (the genesis epoch is zero).
+type Epoch uint64
+
+// MaxLayer is the largest representable layer.
+const MaxLayer = Layer(math.MaxUint64)
+
+// Epoch returns the epoch that contains the layer.
+func (l Layer) Epoch() Epoch {
+	return Epoch(l / constants.OneEpoch)
+}
+
+// Effective converts a layer to a layer counted from the given effective genesis. It returns false if the layer
+// precedes effective genesis.
+func (l Layer) Effective(effectiveGenesis Layer) (EffectiveLayer, bool) {
+	if l < effectiveGenesis {
+		return 0, false
+	}
+	return EffectiveLayer(l - effectiveGenesis), true
+}
+
+// Layer converts an effective layer to a layer counted from genesis, given the effective genesis layer. It returns
+// false if the result is not representable.
+func (l EffectiveLayer) Layer(effectiveGenesis Layer) (Layer, bool) {
+	if uint64(l) > uint64(MaxLayer-effectiveGenesis) {
+		return MaxLayer, false
+	}
+	return effectiveGenesis + Layer(l), true
+}
+
+// FirstLayer returns the first layer of the epoch. It returns false if the result is not representable.
+func (e Epoch) FirstLayer() (Layer, bool) {
+	if uint64(e) > uint64(MaxLayer)/constants.OneEpoch {
+		return MaxLayer, false
+	}
+	return Layer(e) * constants.OneEpoch, true
+}
@@ -0,0 +1,50 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Epoch(t *testing.T) {
+	assert.Equal(t, Epoch(0), Layer(0).Epoch())
+	assert.Equal(t, Epoch(0), Layer(constants.OneEpoch-1).Epoch())
+	assert.Equal(t, Epoch(1), Layer(constants.OneEpoch).Epoch())
+	assert.Equal(t, Epoch(math.MaxUint64/constants.OneEpoch), MaxLayer.Epoch())
+
+	first, ok := Epoch(2).FirstLayer()
+	assert.True(t, ok)
+	assert.Equal(t, Layer(2*constants.OneEpoch), first)
+
+	// the last epoch is only partially representable, but its first layer is
+	first, ok = MaxLayer.Epoch().FirstLayer()
+	assert.True(t, ok)
+	assert.Equal(t, MaxLayer.Epoch(), first.Epoch())
+	_, ok = (MaxLayer.Epoch() + 1).FirstLayer()
+	assert.False(t, ok)
+}
+
+func Test_Effective(t *testing.T) {
+	genesis := Layer(2 * constants.OneEpoch)
+	_, ok := (genesis - 1).Effective(genesis)
+	assert.False(t, ok)
+
+	effective, ok := genesis.Effective(genesis)
+	assert.True(t, ok)
+	assert.Equal(t, EffectiveLayer(0), effective)
+
+	effective, ok = MaxLayer.Effective(genesis)
+	assert.True(t, ok)
+	assert.Equal(t, EffectiveLayer(math.MaxUint64-2*constants.OneEpoch), effective)
+
+	// round trip
+	layer, ok := effective.Layer(genesis)
+	assert.True(t, ok)
+	assert.Equal(t, MaxLayer, layer)
+
+	// beyond the last representable layer
+	_, ok = (effective + 1).Layer(genesis)
+	assert.False(t, ok)
+}
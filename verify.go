@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/verify"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+)
+
+// verifyCommand checks the schedule invariants over every layer in a range, by default from genesis until one layer
+// past the final layer in which subsidy is issued. It exits with a nonzero status if any invariant is violated.
+func verifyCommand(args []string) {
+	cfg := verify.DefaultConfig(effectiveGenesis)
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	quiet := fs.Bool("q", false, "quiet mode (don't render progress)")
+	fromLayer := fs.Uint64("from", uint64(cfg.FromLayer), "first layer (post-genesis) to check")
+	toLayer := fs.Uint64("to", uint64(cfg.ToLayer), "last layer (post-genesis) to check")
+	workers := fs.Int("workers", 0, "number of worker goroutines (default one per CPU core)")
+	chunkSize := fs.Uint64("chunk", verify.DefaultChunkSize, "number of layers per unit of work")
+	_ = fs.Parse(args)
+
+	cfg.FromLayer = types.Layer(*fromLayer)
+	cfg.ToLayer = types.Layer(*toLayer)
+	cfg.Workers = *workers
+	cfg.ChunkSize = *chunkSize
+	if cfg.ChunkSize == 0 || cfg.ChunkSize > math.MaxInt64 {
+		log.Fatal("invalid chunk size")
+	}
+	log.Printf("verifying layers %d to %d\n", cfg.FromLayer, cfg.ToLayer)
+
+	pw := progress.NewWriter()
+	pw.SetUpdateFrequency(time.Millisecond * 100)
+	if !*quiet {
+		go pw.Render()
+	}
+	// the tracker counts layers in an int64, which the whole range of layers doesn't fit
+	total := int64(math.MaxInt64)
+	if span := uint64(cfg.ToLayer - cfg.FromLayer); span < math.MaxInt64 {
+		total = int64(span) + 1
+	}
+	tracker := progress.Tracker{Total: total, Units: progress.Units{
+		Formatter:        progress.FormatNumber,
+		Notation:         " layers",
+		NotationPosition: progress.UnitsNotationPositionAfter,
+	}}
+	pw.AppendTracker(&tracker)
+
+	start := time.Now()
+	report, err := verify.Run(cfg, func(layers uint64) {
+		tracker.Increment(int64(layers))
+	})
+	tracker.MarkAsDone()
+	if !*quiet {
+		pw.Stop()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("checked %d layers in %d chunks in %s\n", report.Layers, report.Chunks, time.Since(start).Round(time.Second))
+	log.Printf("total subsidy %d smidge, total vest %d smidge\n", report.Subsidy, report.Vest)
+	if report.OK() {
+		log.Println("all invariants hold")
+		return
+	}
+	for _, v := range report.Violations {
+		log.Println(v)
+	}
+	log.Printf("%d violations (at most %d reported per chunk)\n", len(report.Violations), verify.MaxViolations)
+	os.Exit(1)
+}
@@ -0,0 +1,263 @@
+// Package verify exhaustively checks invariants of the subsidy and vesting schedules over a range of layers.
+package verify
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/spacemeshos/economics/vesting"
+)
+
+const (
+	// DefaultChunkSize is the number of layers checked by a single unit of work. Chunk boundaries depend only on the
+	// range and the chunk size, never on the number of workers, so results are reproducible.
+	DefaultChunkSize = 1 << 16
+
+	// MaxViolations is the maximum number of violations recorded per chunk.
+	MaxViolations = 100
+)
+
+// Check names, used to identify violations.
+const (
+	CheckSubsidyMonotone   = "accumulated subsidy is monotone"
+	CheckSubsidyDecreasing = "per-layer subsidy is non-increasing (within rounding)"
+	CheckSubsidySum        = "per-layer subsidy sums to accumulated subsidy"
+	CheckVestMonotone      = "accumulated vest is monotone"
+	CheckVestSum           = "per-layer vest sums to accumulated vest"
+	CheckVestTotal         = "vest sums to total vaulted"
+	CheckIssuanceCap       = "total issuance does not exceed cap"
+)
+
+type Config struct {
+	// EffectiveGenesis is the first layer, post-genesis, in which subsidy is issued
+	EffectiveGenesis types.Layer
+
+	// FromLayer and ToLayer are the first and last layers post-genesis to check (inclusive)
+	FromLayer types.Layer
+	ToLayer   types.Layer
+
+	// Workers is the number of goroutines used; if zero, one per CPU core
+	Workers int
+
+	// ChunkSize is the number of layers per unit of work; if zero, DefaultChunkSize
+	ChunkSize uint64
+}
+
+// DefaultConfig returns a config that checks every layer from genesis until one layer beyond the final layer in
+// which subsidy is issued.
+func DefaultConfig(effectiveGenesis types.Layer) Config {
+	finalLayer, ok := rewards.FinalLayer.Uint64()
+	if !ok {
+		panic("final layer out of range")
+	}
+	toLayer, ok := types.EffectiveLayer(finalLayer + 1).Layer(effectiveGenesis)
+	if !ok {
+		panic("final layer out of range")
+	}
+	return Config{EffectiveGenesis: effectiveGenesis, ToLayer: toLayer}
+}
+
+// Violation records a layer at which an invariant does not hold. Layer is post-genesis.
+type Violation struct {
+	Layer  types.Layer
+	Check  string
+	Detail string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("layer %d: %s: %s", v.Layer, v.Check, v.Detail)
+}
+
+type Report struct {
+	Layers     uint64
+	Chunks     int
+	Subsidy    uint64
+	Vest       uint64
+	Violations []Violation
+}
+
+// OK returns true if no invariant was violated.
+func (r Report) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// chunk holds the partial results for a contiguous range of layers.
+type chunk struct {
+	from, to   types.Layer
+	subsidy    uint64
+	vest       uint64
+	violations []Violation
+}
+
+func (c *chunk) violate(layerID types.Layer, check, format string, args ...any) {
+	if len(c.violations) < MaxViolations {
+		c.violations = append(c.violations, Violation{layerID, check, fmt.Sprintf(format, args...)})
+	}
+}
+
+func accumulatedSubsidy(effectiveGenesis, layerID types.Layer) uint64 {
+	if effectiveLayer, ok := layerID.Effective(effectiveGenesis); ok {
+		return rewards.TotalAccumulatedSubsidyAtLayer(effectiveLayer)
+	}
+	return 0
+}
+
+func subsidy(effectiveGenesis, layerID types.Layer) uint64 {
+	if effectiveLayer, ok := layerID.Effective(effectiveGenesis); ok {
+		return rewards.TotalSubsidyAtLayer(effectiveLayer)
+	}
+	return 0
+}
+
+func (c *chunk) run(effectiveGenesis types.Layer) {
+	// start from the state as of the layer preceding the chunk
+	var prevSubsidyTotal, prevSubsidy, prevVestTotal uint64
+	if c.from > 0 {
+		prevSubsidyTotal = accumulatedSubsidy(effectiveGenesis, c.from-1)
+		prevSubsidy = subsidy(effectiveGenesis, c.from-1)
+		prevVestTotal = vesting.AccumulatedVestAtLayer(c.from - 1)
+	}
+	startSubsidyTotal, startVestTotal := prevSubsidyTotal, prevVestTotal
+
+	for layerID := c.from; ; layerID++ {
+		layerSubsidy := subsidy(effectiveGenesis, layerID)
+		subsidyTotal := accumulatedSubsidy(effectiveGenesis, layerID)
+		if subsidyTotal < prevSubsidyTotal {
+			c.violate(layerID, CheckSubsidyMonotone, "%d < %d", subsidyTotal, prevSubsidyTotal)
+		}
+
+		// the first layer of issuance is the only one allowed to exceed its predecessor. Note that per-layer subsidy is
+		// the difference between two accumulated figures that are each rounded down, so once the unrounded per-layer
+		// subsidy declines by less than one smidge per layer (far into the tail) the rounded figure may exceed its
+		// predecessor by one smidge. Anything more than that is a violation.
+		if layerID > effectiveGenesis && layerSubsidy > prevSubsidy+1 {
+			c.violate(layerID, CheckSubsidyDecreasing, "%d > %d", layerSubsidy, prevSubsidy)
+		}
+
+		vest := vesting.VestAtLayer(layerID)
+		vestTotal := vesting.AccumulatedVestAtLayer(layerID)
+		if vestTotal < prevVestTotal {
+			c.violate(layerID, CheckVestMonotone, "%d < %d", vestTotal, prevVestTotal)
+		}
+
+		if issuance := constants.TotalVaulted + subsidyTotal; issuance > constants.TotalIssuance {
+			c.violate(layerID, CheckIssuanceCap, "%d > %d", issuance, uint64(constants.TotalIssuance))
+		}
+
+		c.subsidy += layerSubsidy
+		c.vest += vest
+		prevSubsidyTotal, prevSubsidy, prevVestTotal = subsidyTotal, layerSubsidy, vestTotal
+
+		// check here rather than in the loop condition in case the chunk ends at the last representable layer
+		if layerID == c.to {
+			break
+		}
+	}
+
+	if c.subsidy != prevSubsidyTotal-startSubsidyTotal {
+		c.violate(c.to, CheckSubsidySum, "layers %d-%d sum to %d, expected %d",
+			c.from, c.to, c.subsidy, prevSubsidyTotal-startSubsidyTotal)
+	}
+	if c.vest != prevVestTotal-startVestTotal {
+		c.violate(c.to, CheckVestSum, "layers %d-%d sum to %d, expected %d",
+			c.from, c.to, c.vest, prevVestTotal-startVestTotal)
+	}
+}
+
+// Run checks every layer in the configured range. Work is split into fixed-size chunks which are generated as
+// workers become free and processed concurrently, so that memory doesn't grow with the range. If onChunk is non-nil
+// it's called (from multiple goroutines) with the number of layers in each chunk as it completes, e.g., to report
+// progress. It returns an error if the range is empty.
+func Run(cfg Config, onChunk func(layers uint64)) (Report, error) {
+	if cfg.FromLayer > cfg.ToLayer {
+		return Report{}, fmt.Errorf("first layer %d is after last layer %d", cfg.FromLayer, cfg.ToLayer)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultChunkSize
+	}
+
+	work := make(chan *chunk)
+	go func() {
+		defer close(work)
+		for from := cfg.FromLayer; ; {
+			to := cfg.ToLayer
+			if uint64(to-from) >= cfg.ChunkSize {
+				to = from + types.Layer(cfg.ChunkSize) - 1
+			}
+			work <- &chunk{from: from, to: to}
+
+			// stop before wrapping past the last representable layer
+			if to == cfg.ToLayer {
+				return
+			}
+			from = to + 1
+		}
+	}()
+
+	done := make(chan *chunk)
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range work {
+				c.run(cfg.EffectiveGenesis)
+				if onChunk != nil {
+					onChunk(uint64(c.to-c.from) + 1)
+				}
+				done <- c
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// the sums don't depend on the order in which chunks complete, and violations are sorted below
+	var report Report
+	for c := range done {
+		report.Chunks++
+		report.Layers += uint64(c.to-c.from) + 1
+		report.Subsidy += c.subsidy
+		report.Vest += c.vest
+		report.Violations = append(report.Violations, c.violations...)
+	}
+
+	// check the totals across the whole range
+	expectedSubsidy := accumulatedSubsidy(cfg.EffectiveGenesis, cfg.ToLayer)
+	expectedVest := vesting.AccumulatedVestAtLayer(cfg.ToLayer)
+	if cfg.FromLayer > 0 {
+		expectedSubsidy -= accumulatedSubsidy(cfg.EffectiveGenesis, cfg.FromLayer-1)
+		expectedVest -= vesting.AccumulatedVestAtLayer(cfg.FromLayer - 1)
+	}
+	if report.Subsidy != expectedSubsidy {
+		report.Violations = append(report.Violations, Violation{cfg.ToLayer, CheckSubsidySum,
+			fmt.Sprintf("layers %d-%d sum to %d, expected %d",
+				cfg.FromLayer, cfg.ToLayer, report.Subsidy, expectedSubsidy)})
+	}
+	if report.Vest != expectedVest {
+		report.Violations = append(report.Violations, Violation{cfg.ToLayer, CheckVestSum,
+			fmt.Sprintf("layers %d-%d sum to %d, expected %d",
+				cfg.FromLayer, cfg.ToLayer, report.Vest, expectedVest)})
+	}
+
+	// if the range covers the entire vesting period, everything must have vested
+	if cfg.FromLayer == 0 && cfg.ToLayer >= constants.VestEnd && report.Vest != constants.TotalVaulted {
+		report.Violations = append(report.Violations, Violation{cfg.ToLayer, CheckVestTotal,
+			fmt.Sprintf("vest sums to %d, expected %d", report.Vest, uint64(constants.TotalVaulted))})
+	}
+
+	sort.SliceStable(report.Violations, func(i, j int) bool {
+		return report.Violations[i].Layer < report.Violations[j].Layer
+	})
+	return report, nil
+}
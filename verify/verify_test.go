@@ -0,0 +1,92 @@
+package verify
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/rewards"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// run checks the configured range, which must be valid.
+func run(t *testing.T, cfg Config, onChunk func(layers uint64)) Report {
+	t.Helper()
+	report, err := Run(cfg, onChunk)
+	require.NoError(t, err)
+	return report
+}
+
+func Test_Subsidy(t *testing.T) {
+	cfg := Config{EffectiveGenesis: 2 * constants.OneEpoch, ToLayer: 3 * constants.OneEpoch, ChunkSize: 1000}
+	report := run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(cfg.ToLayer)+1, report.Layers)
+	assert.Equal(t, 13, report.Chunks)
+	assert.Equal(t, rewards.TotalAccumulatedSubsidyAtLayer(constants.OneEpoch), report.Subsidy)
+}
+
+func Test_Deterministic(t *testing.T) {
+	// results don't depend on the number of workers
+	cfg := Config{EffectiveGenesis: 10, FromLayer: 5, ToLayer: 5000, ChunkSize: 100}
+	cfg.Workers = 1
+	expected := run(t, cfg, nil)
+	cfg.Workers = 7
+	var layers uint64
+	actual := run(t, cfg, func(n uint64) { layers += n })
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, expected.Layers, layers)
+}
+
+func Test_Vesting(t *testing.T) {
+	// push effective genesis out of range so that we only check vesting
+	cfg := Config{EffectiveGenesis: math.MaxUint32, ToLayer: constants.VestEnd + 1, ChunkSize: 1 << 16}
+	report := run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(constants.TotalVaulted), report.Vest)
+	assert.Zero(t, report.Subsidy)
+
+	// a partial range is checked against accumulated vest rather than the total
+	cfg.FromLayer = constants.VestStart + 10
+	cfg.ToLayer = constants.VestStart + 20
+	report = run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(11*constants.VestPerLayer), report.Vest)
+}
+
+func Test_MaxLayer(t *testing.T) {
+	// the range may cross the boundary of 32-bit layers
+	cfg := Config{EffectiveGenesis: 0, FromLayer: math.MaxUint32 - 10, ToLayer: math.MaxUint32 + 10, ChunkSize: 7}
+	report := run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(21), report.Layers)
+	assert.Zero(t, report.Subsidy)
+
+	// and may end at the largest representable layer without wrapping
+	cfg = Config{EffectiveGenesis: 0, FromLayer: types.MaxLayer - 10, ToLayer: types.MaxLayer, ChunkSize: 7}
+	report = run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(11), report.Layers)
+	assert.Equal(t, 2, report.Chunks)
+}
+
+func Test_Range(t *testing.T) {
+	_, err := Run(Config{FromLayer: 2, ToLayer: 1}, nil)
+	assert.ErrorContains(t, err, "first layer 2 is after last layer 1")
+}
+
+func Test_DefaultConfig(t *testing.T) {
+	cfg := DefaultConfig(2 * constants.OneEpoch)
+	finalLayer, ok := rewards.FinalLayer.Uint64()
+	require.True(t, ok)
+	assert.Zero(t, cfg.FromLayer)
+	assert.Equal(t, types.Layer(finalLayer)+2*constants.OneEpoch+1, cfg.ToLayer)
+
+	// the final layer issues the last smidge, and nothing is issued beyond it
+	cfg.FromLayer = cfg.ToLayer - 1
+	report := run(t, cfg, nil)
+	assert.True(t, report.OK(), "unexpected violations %v", report.Violations)
+	assert.Equal(t, uint64(1), report.Subsidy)
+}
@@ -0,0 +1,95 @@
+package vesting
+
+import (
+	"math"
+	"testing"
+	"testing/quick"
+
+	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkLayer asserts the invariants that should hold for a schedule at any layer.
+func checkLayer(t *testing.T, s Schedule, layerID types.Layer) {
+	accumulated := s.AccumulatedVestAtLayer(layerID)
+	vest := s.VestAtLayer(layerID)
+	require.LessOrEqual(t, accumulated, s.TotalVaulted, "expected vest at layer %d not to exceed total", layerID)
+	require.LessOrEqual(t, vest, accumulated)
+
+	// monotone and additive
+	var prev uint64
+	if layerID > 0 {
+		prev = s.AccumulatedVestAtLayer(layerID - 1)
+	}
+	require.LessOrEqual(t, prev, accumulated, "expected accumulated vest to be monotone at layer %d", layerID)
+	require.Equal(t, accumulated-prev, vest, "expected layer %d vest to be the accumulated difference", layerID)
+
+	// round down: until the final layer, accumulated vest is the cliff plus a whole number of per-layer amounts
+	switch {
+	case layerID < s.VestStart:
+		require.Zero(t, accumulated)
+	case layerID < s.VestEnd:
+		require.Equal(t, s.VestedAtCliff+uint64(layerID-s.VestStart)*s.VestPerLayer(), accumulated)
+	default:
+		require.Equal(t, s.TotalVaulted, accumulated)
+	}
+}
+
+func FuzzSchedule(f *testing.F) {
+	f.Add(uint64(constants.TotalVaulted), uint64(constants.VestedAtCliff), uint64(constants.VestStart),
+		uint64(constants.VestEnd), uint64(constants.VestEnd))
+	f.Add(uint64(constants.TotalVaulted), uint64(constants.TotalVaulted/4), uint64(0), uint64(1), uint64(0))
+	f.Add(uint64(math.MaxUint64), uint64(0), uint64(0), uint64(math.MaxUint32), uint64(math.MaxUint32-1))
+	f.Add(uint64(math.MaxUint64), uint64(math.MaxUint64), uint64(math.MaxUint64-1), uint64(math.MaxUint64),
+		uint64(math.MaxUint64))
+	f.Add(uint64(math.MaxUint64), uint64(1), uint64(0), uint64(math.MaxUint64), uint64(math.MaxUint64-1))
+	f.Add(uint64(10), uint64(0), uint64(100), uint64(1000), uint64(500))
+	f.Fuzz(func(t *testing.T, totalVaulted, vestedAtCliff, vestStart, vestEnd, layer uint64) {
+		s := Schedule{
+			TotalVaulted:  totalVaulted,
+			VestedAtCliff: vestedAtCliff,
+			VestStart:     types.Layer(vestStart),
+			VestEnd:       types.Layer(vestEnd),
+		}
+		if s.Validate() != nil {
+			t.Skip("invalid schedule")
+		}
+		checkLayer(t, s, types.Layer(layer))
+
+		// everything vests by the end, and the final layer makes up for rounding
+		require.Equal(t, totalVaulted, s.AccumulatedVestAtLayer(s.VestEnd))
+		if s.VestEnd < types.MaxLayer {
+			require.Zero(t, s.VestAtLayer(s.VestEnd+1))
+		}
+		remainder := (totalVaulted - vestedAtCliff) - s.VestPerLayer()*s.VestLayers()
+		require.Less(t, remainder, s.VestLayers())
+		require.Equal(t, s.VestPerLayer()+remainder, s.VestAtLayer(s.VestEnd))
+	})
+}
+
+func Test_Validate(t *testing.T) {
+	assert.NoError(t, Mainnet.Validate())
+	assert.Error(t, Schedule{TotalVaulted: 10, VestStart: 10, VestEnd: 10}.Validate())
+	assert.Error(t, Schedule{TotalVaulted: 10, VestedAtCliff: 11, VestStart: 0, VestEnd: 10}.Validate())
+
+	// the package-level functions use the mainnet schedule
+	assert.Equal(t, uint64(constants.VestPerLayer), Mainnet.VestPerLayer())
+	assert.Equal(t, uint64(constants.VestLayers), Mainnet.VestLayers())
+}
+
+func Test_VestProperties(t *testing.T) {
+	err := quick.Check(func(layerID types.Layer) bool {
+		checkLayer(t, Mainnet, layerID)
+		return !t.Failed()
+	}, &quick.Config{MaxCount: 1000})
+	assert.NoError(t, err)
+
+	// restrict to the vesting period, where most of the interesting behavior is
+	err = quick.Check(func(offset types.Layer) bool {
+		checkLayer(t, Mainnet, constants.VestStart+offset%(constants.VestLayers+2))
+		return !t.Failed()
+	}, &quick.Config{MaxCount: 1000})
+	assert.NoError(t, err)
+}
@@ -1,45 +1,100 @@
 package vesting
 
 import (
+	"errors"
 	"log"
 
 	"github.com/spacemeshos/economics/constants"
+	"github.com/spacemeshos/economics/types"
 )
 
-func AccumulatedVestAtLayer(layersAfterGenesis uint32) uint64 {
-	if layersAfterGenesis < constants.VestStart {
+// Schedule is a linear vesting schedule with an optional cliff. The package-level functions use the Mainnet schedule.
+type Schedule struct {
+	// TotalVaulted is the total amount, in smidge, that vests over the life of the schedule
+	TotalVaulted uint64
+
+	// VestedAtCliff is the amount, in smidge, that vests at once in the VestStart layer
+	VestedAtCliff uint64
+
+	// VestStart and VestEnd are the first and last layers post-genesis in which coins vest
+	VestStart types.Layer
+	VestEnd   types.Layer
+}
+
+// Mainnet is the vesting schedule defined by the mainnet constants.
+var Mainnet = Schedule{
+	TotalVaulted:  constants.TotalVaulted,
+	VestedAtCliff: constants.VestedAtCliff,
+	VestStart:     constants.VestStart,
+	VestEnd:       constants.VestEnd,
+}
+
+// Validate returns an error if the schedule parameters are inconsistent.
+func (s Schedule) Validate() error {
+	if s.VestEnd <= s.VestStart {
+		return errors.New("vest end must be after vest start")
+	}
+	if s.VestedAtCliff > s.TotalVaulted {
+		return errors.New("amount vested at cliff must not exceed total vaulted")
+	}
+	return nil
+}
+
+// VestLayers returns the number of layers over which coins vest, exclusive of the start layer and inclusive of the end
+// layer.
+func (s Schedule) VestLayers() uint64 {
+	return uint64(s.VestEnd - s.VestStart)
+}
+
+// VestPerLayer returns the amount that vests in each layer after the cliff, rounded down to the nearest smidge. We make
+// up for this rounding in the final vesting layer.
+func (s Schedule) VestPerLayer() uint64 {
+	return (s.TotalVaulted - s.VestedAtCliff) / s.VestLayers()
+}
+
+func (s Schedule) AccumulatedVestAtLayer(layersAfterGenesis types.Layer) uint64 {
+	if layersAfterGenesis < s.VestStart {
 		return 0
-	} else if layersAfterGenesis >= constants.VestEnd {
-		return constants.TotalVaulted
+	} else if layersAfterGenesis >= s.VestEnd {
+		return s.TotalVaulted
 	}
 
 	// Note: this rounds down to the nearest int number of smidge below the intended vest as of the input layer.
 	// No need to check for overflow on the subtraction but we can overflow on the multiplication.
-	numLayers := uint64(layersAfterGenesis - constants.VestStart)
-	vest := constants.VestPerLayer * numLayers
-	if vest/constants.VestPerLayer != numLayers {
+	vestPerLayer := s.VestPerLayer()
+	numLayers := uint64(layersAfterGenesis - s.VestStart)
+	vest := vestPerLayer * numLayers
+	if vestPerLayer != 0 && vest/vestPerLayer != numLayers {
 		log.Fatal("integer overflow")
 	}
-	return constants.VestedAtCliff + vest
+	return s.VestedAtCliff + vest
 }
 
-func VestAtLayer(layersAfterGenesis uint32) uint64 {
+func (s Schedule) VestAtLayer(layersAfterGenesis types.Layer) uint64 {
 	// base case: no vesting before vest start, no vesting after vest end
-	if layersAfterGenesis < constants.VestStart {
+	if layersAfterGenesis < s.VestStart {
 		return 0
-	} else if layersAfterGenesis > constants.VestEnd {
+	} else if layersAfterGenesis > s.VestEnd {
 		return 0
 	}
 
 	// vest as of the previous layer
 	var prevLayerAccumulatedVest, curLayerAccumulatedVest uint64
 	if layersAfterGenesis > 0 {
-		prevLayerAccumulatedVest = AccumulatedVestAtLayer(layersAfterGenesis - 1)
+		prevLayerAccumulatedVest = s.AccumulatedVestAtLayer(layersAfterGenesis - 1)
 	}
 
 	// intended vest as of this layer
-	curLayerAccumulatedVest = AccumulatedVestAtLayer(layersAfterGenesis)
+	curLayerAccumulatedVest = s.AccumulatedVestAtLayer(layersAfterGenesis)
 
 	// return the difference
 	return curLayerAccumulatedVest - prevLayerAccumulatedVest
 }
+
+func AccumulatedVestAtLayer(layersAfterGenesis types.Layer) uint64 {
+	return Mainnet.AccumulatedVestAtLayer(layersAfterGenesis)
+}
+
+func VestAtLayer(layersAfterGenesis types.Layer) uint64 {
+	return Mainnet.VestAtLayer(layersAfterGenesis)
+}
@@ -0,0 +1,359 @@
+// Package xlsx writes spreadsheets in the Office Open XML format read by Excel, LibreOffice and Google Sheets, with just
+// what tables of output need: typed cells, number formats, a bold header row and frozen panes.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the number format of a cell.
+type Format int
+
+const (
+	// General shows numbers as entered
+	General Format = iota
+
+	// Integer shows numbers rounded to integers, with thousands separators
+	Integer
+
+	// Decimal shows numbers with two decimals and thousands separators
+	Decimal
+
+	// Percent shows fractions as percentages with two decimals
+	Percent
+
+	// Date shows dates as yyyy-mm-dd
+	Date
+)
+
+// styles are the indexes of the cell formats in the style sheet: the header, followed by one per number format
+const (
+	styleHeader = 1
+	styleFormat = 2
+)
+
+type kind int
+
+const (
+	kindEmpty kind = iota
+	kindString
+	kindNumber
+)
+
+// Cell is a value of a spreadsheet. The zero value is an empty cell.
+type Cell struct {
+	kind   kind
+	value  string
+	format Format
+}
+
+// String returns a text cell.
+func String(s string) Cell {
+	return Cell{kind: kindString, value: s}
+}
+
+// Number returns a numeric cell, given as a decimal, e.g., "1234.5". Spreadsheets hold numbers as floating point, so
+// only about 15 significant digits are kept.
+func Number(value string, format Format) Cell {
+	return Cell{kind: kindNumber, value: value, format: format}
+}
+
+// Float returns a numeric cell.
+func Float(value float64, format Format) Cell {
+	return Number(strconv.FormatFloat(value, 'g', -1, 64), format)
+}
+
+// epoch is day zero of spreadsheet dates
+var epoch = time.Date(1899, time.December, 30, 0, 0, 0, 0, time.UTC)
+
+// DateCell returns a date cell. The time of day is dropped.
+func DateCell(t time.Time) Cell {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return Number(strconv.Itoa(int(date.Sub(epoch).Hours()/24)), Date)
+}
+
+// Sheet is a table: a header row followed by rows of cells.
+type Sheet struct {
+	// Name is the name of the tab: at most 31 characters, none of which is one of []:*?/\
+	Name string
+
+	Header []string
+	Rows   [][]Cell
+
+	// FrozenColumns is the number of leftmost columns kept in view when scrolling sideways. The header row is always
+	// kept in view.
+	FrozenColumns int
+}
+
+// Validate returns an error if a sheet can't be written.
+func (s *Sheet) Validate() error {
+	if s.Name == "" || len([]rune(s.Name)) > 31 || strings.ContainsAny(s.Name, `[]:*?/\`) {
+		return fmt.Errorf("invalid sheet name %q", s.Name)
+	}
+	if s.FrozenColumns < 0 || s.FrozenColumns > len(s.Header) {
+		return fmt.Errorf("sheet %q: can't freeze %d of %d columns", s.Name, s.FrozenColumns, len(s.Header))
+	}
+	for i, row := range s.Rows {
+		if len(row) > len(s.Header) {
+			return fmt.Errorf("sheet %q: row %d has %d cells, but there are %d columns", s.Name, i+1, len(row),
+				len(s.Header))
+		}
+		for j, c := range row {
+			if c.kind != kindNumber {
+				continue
+			}
+			if v, err := strconv.ParseFloat(c.value, 64); err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				return fmt.Errorf("sheet %q: cell %s%d: invalid number %q", s.Name, ColumnName(j), i+2, c.value)
+			}
+		}
+	}
+	return nil
+}
+
+// ColumnName returns the name of a column given its index from zero: A, B, ..., Z, AA, AB and so on.
+func ColumnName(i int) string {
+	var name []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = append([]byte{byte('A' + (i-1)%26)}, name...)
+	}
+	return string(name)
+}
+
+// Write writes a workbook of the given sheets, in order.
+func Write(w io.Writer, sheets []Sheet) error {
+	if len(sheets) == 0 {
+		return errors.New("a workbook needs at least one sheet")
+	}
+	names := make(map[string]bool, len(sheets))
+	for i := range sheets {
+		if err := sheets[i].Validate(); err != nil {
+			return err
+		}
+		// names are unique regardless of case
+		name := strings.ToLower(sheets[i].Name)
+		if names[name] {
+			return fmt.Errorf("duplicate sheet name %q", sheets[i].Name)
+		}
+		names[name] = true
+	}
+
+	z := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		write func(w io.Writer) error
+	}{
+		{"[Content_Types].xml", func(w io.Writer) error { return writeContentTypes(w, len(sheets)) }},
+		{"_rels/.rels", writeString(rootRelationships)},
+		{"xl/workbook.xml", func(w io.Writer) error { return writeWorkbook(w, sheets) }},
+		{"xl/_rels/workbook.xml.rels", func(w io.Writer) error { return writeWorkbookRelationships(w, len(sheets)) }},
+		{"xl/styles.xml", writeString(styles)},
+	}
+	for i := range sheets {
+		sheet := &sheets[i]
+		files = append(files, struct {
+			name  string
+			write func(w io.Writer) error
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.write})
+	}
+	for _, file := range files {
+		fw, err := z.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if err := file.write(fw); err != nil {
+			return fmt.Errorf("%s: %w", file.name, err)
+		}
+	}
+	return z.Close()
+}
+
+func writeString(s string) func(w io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, s)
+		return err
+	}
+}
+
+// escape returns text escaped for XML.
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const rootRelationships = xmlHeader +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" ` +
+	`Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles has the fonts, fills, borders and cell formats the cells refer to. Cell format 1 is the header; the others
+// follow the order of the number formats. Number formats 3, 4 and 10 are built in.
+const styles = xmlHeader +
+	`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9E1F2"/><bgColor indexed="64"/></patternFill></fill>` +
+	`</fills>` +
+	`<borders count="2"><border><left/><right/><top/><bottom/><diagonal/></border>` +
+	`<border><left/><right/><top/><bottom style="thin"><color auto="1"/></bottom><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="7">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1"/>` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="3" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func writeContentTypes(w io.Writer, sheets int) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeWorkbook(w io.Writer, sheets []Sheet) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.Name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeWorkbookRelationships(w io.Writer, sheets int) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" `+
+			`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" `+
+		`Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`,
+		sheets+1)
+	b.WriteString(`</Relationships>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// widths returns the width of each column, in characters, wide enough for the header and roughly for the values.
+func (s *Sheet) widths() []int {
+	widths := make([]int, len(s.Header))
+	for i, h := range s.Header {
+		widths[i] = len([]rune(h)) + 2
+	}
+	for _, row := range s.Rows {
+		for i, c := range row {
+			n := len([]rune(c.value))
+			switch {
+			case c.format == Date:
+				n = len("yyyy-mm-dd")
+			case c.kind == kindNumber:
+				// leave room for thousands separators
+				n += n / 3
+			}
+			if n+2 > widths[i] {
+				widths[i] = n + 2
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > 60 {
+			widths[i] = 60
+		}
+	}
+	return widths
+}
+
+func (s *Sheet) write(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	// freeze the header row and the leftmost columns
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+	topLeft := ColumnName(s.FrozenColumns) + "2"
+	if s.FrozenColumns > 0 {
+		fmt.Fprintf(&b, `<pane xSplit="%d" ySplit="1" topLeftCell="%s" activePane="bottomRight" state="frozen"/>`,
+			s.FrozenColumns, topLeft)
+		fmt.Fprintf(&b, `<selection pane="bottomRight" activeCell="%s" sqref="%s"/>`, topLeft, topLeft)
+	} else {
+		fmt.Fprintf(&b, `<pane ySplit="1" topLeftCell="%s" activePane="bottomLeft" state="frozen"/>`, topLeft)
+		fmt.Fprintf(&b, `<selection pane="bottomLeft" activeCell="%s" sqref="%s"/>`, topLeft, topLeft)
+	}
+	b.WriteString(`</sheetView></sheetViews>`)
+
+	if len(s.Header) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths() {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	b.WriteString(`<row r="1">`)
+	for i, h := range s.Header {
+		fmt.Fprintf(&b, `<c r="%s1" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, ColumnName(i), styleHeader,
+			escape(h))
+	}
+	b.WriteString(`</row>`)
+	for i, row := range s.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+2)
+		for j, c := range row {
+			ref := ColumnName(j) + strconv.Itoa(i+2)
+			switch c.kind {
+			case kindString:
+				fmt.Fprintf(&b, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref,
+					escape(c.value))
+			case kindNumber:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleFormat+int(c.format), escape(c.value))
+			}
+		}
+		b.WriteString(`</row>`)
+		if b.Len() > 1<<20 {
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,103 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readFile returns a file of a workbook.
+func readFile(t *testing.T, workbook []byte, name string) string {
+	z, err := zip.NewReader(bytes.NewReader(workbook), int64(len(workbook)))
+	require.NoError(t, err)
+	f, err := z.Open(name)
+	require.NoError(t, err, name)
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	require.NoError(t, err)
+
+	// every part is well formed
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err, name)
+	}
+	return string(data)
+}
+
+func Test_ColumnName(t *testing.T) {
+	for i, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		assert.Equal(t, name, ColumnName(i), i)
+	}
+}
+
+func Test_DateCell(t *testing.T) {
+	assert.Equal(t, Number("45121", Date), DateCell(time.Date(2023, time.July, 14, 12, 30, 0, 0, time.UTC)))
+	assert.Equal(t, Number("1", Date), DateCell(time.Date(1899, time.December, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func Test_Write(t *testing.T) {
+	sheets := []Sheet{
+		{
+			Name:   "Ticks",
+			Header: []string{"layer", "date", "amount", "pct", "note"},
+			Rows: [][]Cell{
+				{Number("0", General), DateCell(time.Date(2023, time.July, 14, 0, 0, 0, 0, time.UTC)),
+					Number("150000000.000000001", Decimal), Float(0.0625, Percent), String("a <b> & c")},
+				{Number("4032", General), {}, Number("-1", Integer)},
+			},
+			FrozenColumns: 2,
+		},
+		{Name: "Parameters & notes", Header: []string{"name", "value"}, Rows: [][]Cell{{String("genesis")}}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, sheets))
+
+	workbook := readFile(t, buf.Bytes(), "xl/workbook.xml")
+	assert.Contains(t, workbook, `<sheet name="Ticks" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, workbook, `<sheet name="Parameters &amp; notes" sheetId="2" r:id="rId2"/>`)
+	assert.Contains(t, readFile(t, buf.Bytes(), "[Content_Types].xml"), `/xl/worksheets/sheet2.xml`)
+	assert.Contains(t, readFile(t, buf.Bytes(), "xl/_rels/workbook.xml.rels"), `Target="worksheets/sheet2.xml"`)
+	readFile(t, buf.Bytes(), "_rels/.rels")
+	readFile(t, buf.Bytes(), "xl/styles.xml")
+
+	sheet := readFile(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	assert.Contains(t, sheet, `<pane xSplit="2" ySplit="1" topLeftCell="C2" activePane="bottomRight" state="frozen"/>`)
+	assert.Contains(t, sheet, `<c r="A1" s="1" t="inlineStr"><is><t>layer</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="A2" s="2"><v>0</v></c>`)
+	assert.Contains(t, sheet, `<c r="B2" s="6"><v>45121</v></c>`)
+	assert.Contains(t, sheet, `<c r="C2" s="4"><v>150000000.000000001</v></c>`)
+	assert.Contains(t, sheet, `<c r="D2" s="5"><v>0.0625</v></c>`)
+	assert.Contains(t, sheet, `<t xml:space="preserve">a &lt;b&gt; &amp; c</t>`)
+	assert.Contains(t, sheet, `<row r="3"><c r="A3" s="2"><v>4032</v></c><c r="C3" s="3"><v>-1</v></c></row>`)
+	assert.Contains(t, sheet, `<col min="3" max="3" width="27" customWidth="1"/>`)
+
+	sheet = readFile(t, buf.Bytes(), "xl/worksheets/sheet2.xml")
+	assert.Contains(t, sheet, `<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+}
+
+func Test_Validate(t *testing.T) {
+	for _, sheets := range [][]Sheet{
+		nil,
+		{{Name: ""}},
+		{{Name: "a/b"}},
+		{{Name: "a name that is longer than thirty-one characters"}},
+		{{Name: "a", Header: []string{"x"}, FrozenColumns: 2}},
+		{{Name: "a", Header: []string{"x"}, Rows: [][]Cell{{String("1"), String("2")}}}},
+		{{Name: "a", Header: []string{"x"}, Rows: [][]Cell{{Number("1,000", Integer)}}}},
+		{{Name: "a", Header: []string{"x"}, Rows: [][]Cell{{Float(math.NaN(), General)}}}},
+		{{Name: "Ticks"}, {Name: "ticks"}},
+	} {
+		assert.Error(t, Write(io.Discard, sheets), "%+v", sheets)
+	}
+}